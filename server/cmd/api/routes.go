@@ -7,13 +7,18 @@ import (
 	echomw "github.com/labstack/echo/v4/middleware"
 
 	"github.com/Siya360/take-flight/server/internal/middleware"
+	"github.com/Siya360/take-flight/server/internal/validator"
 	"github.com/Siya360/take-flight/server/pkg/admin/handler"
 	"github.com/Siya360/take-flight/server/pkg/admin/service"
+	airporthandler "github.com/Siya360/take-flight/server/pkg/airports/handler"
+	airportservice "github.com/Siya360/take-flight/server/pkg/airports/service"
 	authhandler "github.com/Siya360/take-flight/server/pkg/auth/handler"
 	authservice "github.com/Siya360/take-flight/server/pkg/auth/service"
 	bookinghandler "github.com/Siya360/take-flight/server/pkg/bookings/handler"
+	bookingmodel "github.com/Siya360/take-flight/server/pkg/bookings/model"
 	bookingservice "github.com/Siya360/take-flight/server/pkg/bookings/service"
 	flighthandler "github.com/Siya360/take-flight/server/pkg/flights/handler"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
 	flightservice "github.com/Siya360/take-flight/server/pkg/flights/service"
 	userhandler "github.com/Siya360/take-flight/server/pkg/users/handler"
 	userservice "github.com/Siya360/take-flight/server/pkg/users/service"
@@ -24,6 +29,9 @@ type Config struct {
 	JWT struct {
 		Secret string
 	}
+	// ManageBookingLimit throttles the unauthenticated manage-my-booking
+	// routes, so record locators cannot be guessed against common surnames
+	ManageBookingLimit *middleware.RateLimitConfig
 }
 
 // Server represents the API server
@@ -32,6 +40,7 @@ type Server struct {
 	config         *Config
 	authService    *authservice.AuthService
 	userService    *userservice.UserService
+	airportService *airportservice.AirportService
 	flightService  *flightservice.FlightService
 	bookingService *bookingservice.BookingService
 	adminService   *service.AdminService
@@ -43,11 +52,13 @@ func NewServer(
 	config *Config,
 	authService *authservice.AuthService,
 	userService *userservice.UserService,
+	airportService *airportservice.AirportService,
 	flightService *flightservice.FlightService,
 	bookingService *bookingservice.BookingService,
 	adminService *service.AdminService,
 ) *Server {
 	e := echo.New()
+	e.Validator = validator.NewValidator()
 
 	// Configure middleware
 	e.Use(echomw.Logger())
@@ -61,6 +72,7 @@ func NewServer(
 		config:         config,
 		authService:    authService,
 		userService:    userService,
+		airportService: airportService,
 		flightService:  flightService,
 		bookingService: bookingService,
 		adminService:   adminService,
@@ -99,19 +111,62 @@ func (s *Server) setupRoutes() {
 		userGroup.DELETE("/:id", userHandler.DeleteUser)
 	}
 
+	// Airport routes
+	airportHandler := airporthandler.NewAirportHandler(s.airportService)
+	airportGroup := s.echo.Group("/api/airports")
+	{
+		airportGroup.GET("", airportHandler.ListAirports)
+		airportGroup.GET("/search", airportHandler.SearchAirports)
+		airportGroup.GET("/:code", airportHandler.GetAirport)
+	}
+
 	// Flight routes
 	flightHandler := flighthandler.NewFlightHandler(s.flightService)
 	flightGroup := s.echo.Group("/api/flights")
 	{
 		// Public routes
 		flightGroup.GET("", flightHandler.SearchFlights)
+		flightGroup.GET("/itineraries", flightHandler.SearchItineraries)
+		flightGroup.POST("/trips", flightHandler.SearchTrips)
+		flightGroup.GET("/calendar", flightHandler.GetFareCalendar)
 		flightGroup.GET("/:id", flightHandler.GetFlight)
+		flightGroup.GET("/:id/seatmap", flightHandler.GetSeatMap)
+		flightGroup.GET("/:id/status", flightHandler.GetStatusHistory)
+		flightGroup.GET("/:id/price-history", flightHandler.GetPriceHistory)
 
 		// Protected routes
 		adminFlights := flightGroup.Group("", s.authMiddleware.RequireAdmin)
 		adminFlights.POST("", flightHandler.CreateFlight)
+		adminFlights.POST("/import", flightHandler.ImportFlights)
+		adminFlights.GET("/export", flightHandler.ExportFlights)
 		adminFlights.PUT("/:id", flightHandler.UpdateFlight)
 		adminFlights.DELETE("/:id", flightHandler.DeleteFlight)
+		adminFlights.PUT("/:id/seatmap", flightHandler.ConfigureSeatMap)
+		adminFlights.POST("/:id/delay", flightHandler.TransitionStatus(flightmodel.FlightStatusDelayed))
+		adminFlights.POST("/:id/board", flightHandler.TransitionStatus(flightmodel.FlightStatusBoarding))
+		adminFlights.POST("/:id/depart", flightHandler.TransitionStatus(flightmodel.FlightStatusDeparted))
+		adminFlights.POST("/:id/land", flightHandler.TransitionStatus(flightmodel.FlightStatusLanded))
+		adminFlights.POST("/:id/divert", flightHandler.TransitionStatus(flightmodel.FlightStatusDiverted))
+		adminFlights.POST("/:id/cancel", flightHandler.TransitionStatus(flightmodel.FlightStatusCancelled))
+		adminFlights.GET("/pricing/rules", flightHandler.GetPricingRules)
+		adminFlights.PUT("/pricing/rules", flightHandler.UpdatePricingRules)
+		adminFlights.GET("/overbooking", flightHandler.GetOverbookingPolicy)
+		adminFlights.PUT("/overbooking", flightHandler.UpdateOverbookingPolicy)
+		adminFlights.GET("/cache/stats", flightHandler.GetCacheStats)
+		adminFlights.GET("/schedules", flightHandler.ListSchedules)
+		adminFlights.POST("/schedules", flightHandler.CreateSchedule)
+		adminFlights.POST("/schedules/generate", flightHandler.GenerateSchedules)
+		adminFlights.GET("/schedules/:id", flightHandler.GetSchedule)
+		adminFlights.PUT("/schedules/:id", flightHandler.UpdateSchedule)
+		adminFlights.POST("/schedules/:id/generate", flightHandler.GenerateSchedule)
+	}
+
+	// Price watch routes
+	priceWatchGroup := s.echo.Group("/api/price-watches", s.authMiddleware.Authenticate)
+	{
+		priceWatchGroup.POST("", flightHandler.CreatePriceWatch)
+		priceWatchGroup.GET("", flightHandler.ListPriceWatches)
+		priceWatchGroup.DELETE("/:id", flightHandler.DeletePriceWatch)
 	}
 
 	// Booking routes
@@ -120,9 +175,48 @@ func (s *Server) setupRoutes() {
 	{
 		bookingGroup.POST("", bookingHandler.CreateBooking)
 		bookingGroup.GET("", bookingHandler.SearchBookings)
+		bookingGroup.POST("/holds", bookingHandler.HoldSeats)
+		bookingGroup.GET("/holds/:id", bookingHandler.GetHold)
+		bookingGroup.DELETE("/holds/:id", bookingHandler.ReleaseHold)
 		bookingGroup.GET("/:id", bookingHandler.GetBooking)
 		bookingGroup.PUT("/:id", bookingHandler.UpdateBooking)
+		bookingGroup.PUT("/:id/seats", bookingHandler.SelectSeats)
+		bookingGroup.PUT("/:id/passengers/:passenger_id", bookingHandler.UpdatePassenger)
+		bookingGroup.DELETE("/:id/passengers/:passenger_id", bookingHandler.RemovePassenger)
+		bookingGroup.GET("/:id/passengers/:passenger_id/removal-quote", bookingHandler.QuoteRemoval)
+		bookingGroup.POST("/:id/change-quote", bookingHandler.QuoteChange)
 		bookingGroup.POST("/:id/cancel", bookingHandler.CancelBooking)
+		bookingGroup.GET("/:id/cancellation-quote", bookingHandler.QuoteCancellation)
+		bookingGroup.POST("/:id/disruption/rebook", bookingHandler.AcceptRebooking)
+		bookingGroup.POST("/:id/disruption/refund", bookingHandler.AcceptRefund)
+		bookingGroup.POST("/:id/check-in", bookingHandler.CheckIn)
+		bookingGroup.POST("/:id/pay", bookingHandler.PayBooking)
+		bookingGroup.GET("/:id/payments", bookingHandler.ListPayments)
+		bookingGroup.GET("/:id/refunds", bookingHandler.ListRefunds)
+		bookingGroup.POST("/disruptions/:flight_id", bookingHandler.DisruptFlight, s.authMiddleware.RequireAdmin)
+		bookingGroup.GET("/flights/:flight_id/denied-boarding", bookingHandler.DeniedBoarding, s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/confirm", bookingHandler.TransitionStatus(bookingmodel.BookingStatusConfirmed), s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/ticket", bookingHandler.TransitionStatus(bookingmodel.BookingStatusTicketed), s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/complete", bookingHandler.TransitionStatus(bookingmodel.BookingStatusCompleted), s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/expire", bookingHandler.TransitionStatus(bookingmodel.BookingStatusExpired), s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/complete-refund", bookingHandler.TransitionStatus(bookingmodel.BookingStatusRefunded), s.authMiddleware.RequireAdmin)
+		bookingGroup.POST("/:id/refunds/:refund_id/complete", bookingHandler.CompleteRefund, s.authMiddleware.RequireAdmin)
+	}
+
+	// Payment routes; the webhook is authenticated by the processor's signature
+	paymentGroup := s.echo.Group("/api/payments")
+	{
+		paymentGroup.POST("/webhook", bookingHandler.PaymentWebhook)
+		paymentGroup.POST("/:reference/challenge", bookingHandler.CompleteChallenge, s.authMiddleware.Authenticate)
+	}
+
+	// Manage-my-booking routes for travellers without an account, by record locator and last name
+	manageGroup := s.echo.Group("/api/manage-booking", middleware.RateLimit(s.config.ManageBookingLimit))
+	{
+		manageGroup.POST("", bookingHandler.ManageBooking)
+		manageGroup.PUT("/contact", bookingHandler.AddGuestContact)
+		manageGroup.POST("/cancel", bookingHandler.GuestCancel)
+		manageGroup.POST("/refunds", bookingHandler.GuestRefunds)
 	}
 
 	// Admin routes
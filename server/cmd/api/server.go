@@ -18,15 +18,22 @@ import (
 
 	"github.com/Siya360/take-flight/server/internal/cache"
 	"github.com/Siya360/take-flight/server/internal/database"
+	"github.com/Siya360/take-flight/server/internal/middleware"
 	adminmongo "github.com/Siya360/take-flight/server/pkg/admin/repository/mongodb"
 	adminservice "github.com/Siya360/take-flight/server/pkg/admin/service"
+	airportembedded "github.com/Siya360/take-flight/server/pkg/airports/repository/embedded"
+	airportservice "github.com/Siya360/take-flight/server/pkg/airports/service"
+	"github.com/Siya360/take-flight/server/pkg/amadeus"
 	authmongo "github.com/Siya360/take-flight/server/pkg/auth/repository/mongodb"
 	authservice "github.com/Siya360/take-flight/server/pkg/auth/service"
+	bookingmodel "github.com/Siya360/take-flight/server/pkg/bookings/model"
 	bookingmongo "github.com/Siya360/take-flight/server/pkg/bookings/repository/mongodb"
 	bookingservice "github.com/Siya360/take-flight/server/pkg/bookings/service"
 	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
 	flightmongo "github.com/Siya360/take-flight/server/pkg/flights/repository/mongodb"
 	flightservice "github.com/Siya360/take-flight/server/pkg/flights/service"
+	"github.com/Siya360/take-flight/server/pkg/payments"
 	usermongo "github.com/Siya360/take-flight/server/pkg/users/repository/mongodb"
 	userservice "github.com/Siya360/take-flight/server/pkg/users/service"
 )
@@ -53,6 +60,53 @@ type AppConfig struct {
 		RefreshSecret string        `yaml:"refreshSecret"`
 		RefreshTTL    time.Duration `yaml:"refreshTTL"`
 	} `yaml:"jwt"`
+	Flights struct {
+		Connections struct {
+			Min      time.Duration                           `yaml:"min"`
+			Max      time.Duration                           `yaml:"max"`
+			Airports map[string]flightmodel.ConnectionWindow `yaml:"airports"`
+		} `yaml:"connections"`
+		Providers struct {
+			Amadeus struct {
+				Enabled        bool          `yaml:"enabled"`
+				SearchTimeout  time.Duration `yaml:"searchTimeout"`
+				amadeus.Config `yaml:",inline"`
+			} `yaml:"amadeus"`
+		} `yaml:"providers"`
+		Holds struct {
+			TTL           time.Duration `yaml:"ttl"`
+			SweepInterval time.Duration `yaml:"sweepInterval"`
+		} `yaml:"holds"`
+		Cache struct {
+			TTL time.Duration `yaml:"ttl"`
+		} `yaml:"cache"`
+		PriceWatches struct {
+			Interval time.Duration `yaml:"interval"`
+		} `yaml:"priceWatches"`
+	} `yaml:"flights"`
+	Bookings struct {
+		Disruption struct {
+			AutoRebook     bool          `yaml:"autoRebook"`
+			DelayThreshold time.Duration `yaml:"delayThreshold"`
+			SearchWindow   time.Duration `yaml:"searchWindow"`
+		} `yaml:"disruption"`
+		PassengerPricing struct {
+			Child  *float64 `yaml:"child"`
+			Infant *float64 `yaml:"infant"`
+		} `yaml:"passengerPricing"`
+		Refunds         bookingmodel.RefundPolicy `yaml:"refunds"`
+		ManageRateLimit struct {
+			Limit  int           `yaml:"limit"`
+			Window time.Duration `yaml:"window"`
+		} `yaml:"manageRateLimit"`
+	} `yaml:"bookings"`
+	Payments struct {
+		Gateway       string `yaml:"gateway"`
+		WebhookSecret string `yaml:"webhookSecret"`
+		Fake          struct {
+			Scenarios map[string]payments.Scenario `yaml:"scenarios"`
+		} `yaml:"fake"`
+	} `yaml:"payments"`
 }
 
 // Application represents the main application structure
@@ -151,9 +205,27 @@ func (app *Application) setupServer() error {
 	authRepo := authmongo.NewMongoAuthRepository(db)
 	userRepo := usermongo.NewMongoUserRepository(db)
 	flightRepo := flightmongo.NewMongoFlightRepository(db)
+	seatMapRepo := flightmongo.NewMongoSeatMapRepository(db)
+	pricingRepo := flightmongo.NewMongoPricingRulesRepository(db)
+	flightStatusRepo := flightmongo.NewMongoFlightStatusRepository(db)
+	scheduleRepo := flightmongo.NewMongoScheduleRepository(db)
+	seatHoldRepo := flightmongo.NewMongoSeatHoldRepository(db)
+	overbookingRepo := flightmongo.NewMongoOverbookingRepository(db)
+	priceWatchRepo := flightmongo.NewMongoPriceWatchRepository(db)
+	priceHistoryRepo := flightmongo.NewMongoPriceHistoryRepository(db)
 	bookingRepo := bookingmongo.NewMongoBookingRepository(db)
+	paymentRepo := bookingmongo.NewMongoPaymentRepository(db)
+	refundRepo := bookingmongo.NewMongoRefundRepository(db)
 	adminRepo := adminmongo.NewMongoAdminRepository(db)
 
+	if err := bookingRepo.EnsureIndexes(context.Background()); err != nil {
+		return fmt.Errorf("failed to create booking indexes: %v", err)
+	}
+	airportRepo, err := airportembedded.NewEmbeddedAirportRepository()
+	if err != nil {
+		return fmt.Errorf("failed to load airport registry: %v", err)
+	}
+
 	// Create auth service config
 	authConfig := &common.Config{
 		JWT: common.JWTConfig{
@@ -163,11 +235,41 @@ func (app *Application) setupServer() error {
 		},
 	}
 
+	refundPolicy := app.refundPolicy()
+	if !refundPolicy.IsValid() {
+		return fmt.Errorf("invalid refund policy: tiers need non-negative hours and a share between 0 and 1")
+	}
+	paymentGateway, err := app.paymentGateway()
+	if err != nil {
+		return err
+	}
+
 	// Initialize services
 	authService := authservice.NewAuthService(authConfig, authRepo, app.cacheClient)
 	userService := userservice.NewUserService(userRepo)
-	flightService := flightservice.NewFlightService(flightRepo)
-	bookingService := bookingservice.NewBookingService(bookingRepo, flightService, app.cacheClient)
+	airportService := airportservice.NewAirportService(airportRepo)
+	flightCache := cache.NewCacheBuilder(app.cacheClient).WithTTL(app.flightCacheTTL())
+	flightService := flightservice.NewFlightService(flightRepo).
+		WithConnectionPolicy(app.connectionPolicy()).
+		WithCalendarCache(flightCache.BuildSimple()).
+		WithSeatMaps(seatMapRepo).
+		WithPricing(flightservice.NewRuleBasedPricing(pricingRepo), app.cacheClient).
+		WithStatusHistory(flightStatusRepo).
+		WithSchedules(scheduleRepo).
+		WithSeatHolds(seatHoldRepo, app.config.Flights.Holds.TTL).
+		WithOverbooking(overbookingRepo).
+		WithPriceWatches(priceWatchRepo, priceHistoryRepo, logNotifier{}).
+		WithAirports(airportService).
+		WithFlightCache(app.cacheClient, flightCache.BuildSimple(), flightCache.BuildSimple(), app.flightCacheTTL())
+	app.registerFlightProviders(flightService, airportService)
+	bookingService := bookingservice.NewBookingService(bookingRepo, flightService, app.cacheClient).
+		WithDisruptionPolicy(app.disruptionPolicy()).
+		WithPassengerPricing(app.passengerPricing()).
+		WithPayments(paymentRepo, paymentGateway).
+		WithRefunds(refundRepo).
+		WithRefundPolicy(refundPolicy).
+		WithNotifier(logNotifier{})
+	flightService.WithDisruptionHandler(bookingService)
 	adminService := adminservice.NewAdminService(adminRepo, adminRepo, app.cacheClient)
 
 	// Initialize server
@@ -175,12 +277,14 @@ func (app *Application) setupServer() error {
 		JWT: struct{ Secret string }{
 			Secret: app.config.JWT.Secret,
 		},
+		ManageBookingLimit: app.manageBookingLimit(),
 	}
 
 	app.server = NewServer(
 		serverConfig,
 		authService,
 		userService,
+		airportService,
 		flightService,
 		bookingService,
 		adminService,
@@ -189,10 +293,151 @@ func (app *Application) setupServer() error {
 	return nil
 }
 
+// manageBookingLimit limits each client address to a few manage-my-booking
+// requests, keeping the defaults for any limit left unset
+func (app *Application) manageBookingLimit() *middleware.RateLimitConfig {
+	config := middleware.DefaultRateLimitConfig()
+	config.Redis = app.redisClient
+	config.Limit = 20
+	config.Window = 15 * time.Minute
+	config.KeyFunc = func(c echo.Context) string {
+		return fmt.Sprintf("ratelimit:manage-booking:%s", c.RealIP())
+	}
+
+	limit := app.config.Bookings.ManageRateLimit
+	if limit.Limit > 0 {
+		config.Limit = limit.Limit
+	}
+	if limit.Window > 0 {
+		config.Window = limit.Window
+	}
+	return config
+}
+
+// registerFlightProviders adds the enabled external flight providers to searches
+func (app *Application) registerFlightProviders(flightService *flightservice.FlightService, airportService *airportservice.AirportService) {
+	amadeusConfig := app.config.Flights.Providers.Amadeus
+	if amadeusConfig.Enabled {
+		if amadeusConfig.ClientID == "" {
+			amadeusConfig.ClientID = os.Getenv("AMADEUS_CLIENT_ID")
+		}
+		if amadeusConfig.ClientSecret == "" {
+			amadeusConfig.ClientSecret = os.Getenv("AMADEUS_CLIENT_SECRET")
+		}
+		mapper := amadeus.Mapper{
+			Location: func(code string) *time.Location {
+				loc, _ := airportService.Location(context.Background(), code)
+				return loc
+			},
+		}
+		provider := amadeus.NewProvider(amadeus.NewClient(amadeusConfig.Config), mapper)
+		flightService.WithProvider(provider, amadeusConfig.SearchTimeout)
+	}
+}
+
+// disruptionPolicy builds the handling of cancelled and delayed flights from
+// configuration, keeping the built-in defaults for any duration left unset
+func (app *Application) disruptionPolicy() bookingmodel.DisruptionPolicy {
+	policy := bookingmodel.DefaultDisruptionPolicy()
+	disruption := app.config.Bookings.Disruption
+	policy.AutoRebook = disruption.AutoRebook
+	if disruption.DelayThreshold > 0 {
+		policy.DelayThreshold = disruption.DelayThreshold
+	}
+	if disruption.SearchWindow > 0 {
+		policy.SearchWindow = disruption.SearchWindow
+	}
+	return policy
+}
+
+// passengerPricing builds the fare shares of children and infants from
+// configuration, keeping the defaults for any share left unset
+func (app *Application) passengerPricing() bookingmodel.PassengerPricing {
+	pricing := bookingmodel.DefaultPassengerPricing()
+	shares := app.config.Bookings.PassengerPricing
+	if shares.Child != nil {
+		pricing.Child = *shares.Child
+	}
+	if shares.Infant != nil {
+		pricing.Infant = *shares.Infant
+	}
+	return pricing
+}
+
+// refundPolicy builds the refund tiers from configuration, refunding in full
+// up to departure when none are set
+func (app *Application) refundPolicy() bookingmodel.RefundPolicy {
+	if len(app.config.Bookings.Refunds.Tiers) == 0 {
+		return bookingmodel.DefaultRefundPolicy()
+	}
+	return app.config.Bookings.Refunds
+}
+
+// paymentGateway returns the configured payment processor, or nil when
+// payments are not taken. A processor needs a webhook secret, without which
+// anyone could forge its webhooks.
+func (app *Application) paymentGateway() (bookingservice.PaymentGateway, error) {
+	config := app.config.Payments
+	if config.WebhookSecret == "" {
+		config.WebhookSecret = os.Getenv("PAYMENTS_WEBHOOK_SECRET")
+	}
+	if config.Gateway != "" && config.WebhookSecret == "" {
+		return nil, fmt.Errorf("payment gateway %q needs payments.webhookSecret or PAYMENTS_WEBHOOK_SECRET", config.Gateway)
+	}
+	switch config.Gateway {
+	case payments.GatewayFake:
+		return payments.NewFakeGateway(config.WebhookSecret, config.Fake.Scenarios), nil
+	}
+	return nil, nil
+}
+
+// logNotifier writes passenger notifications to the server log until a
+// delivery channel is configured
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, notification *bookingmodel.Notification) error {
+	log.Printf("notify user %s about booking %s: %s", notification.UserID, notification.BookingID, notification.Subject)
+	return nil
+}
+
+func (logNotifier) NotifyPriceDrop(ctx context.Context, watch *flightmodel.PriceWatch, flight *flightmodel.Flight) error {
+	log.Printf("notify user %s about price watch %s: flight %s now %.2f", watch.UserID, watch.ID, flight.FlightNumber, watch.LastPrice)
+	return nil
+}
+
+// connectionPolicy builds the itinerary connection rules from configuration,
+// keeping the built-in defaults for any bound left unset
+func (app *Application) connectionPolicy() flightmodel.ConnectionPolicy {
+	policy := flightmodel.DefaultConnectionPolicy()
+	connections := app.config.Flights.Connections
+	if connections.Min > 0 {
+		policy.Default.Min = connections.Min
+	}
+	if connections.Max > 0 {
+		policy.Default.Max = connections.Max
+	}
+	policy.Airports = connections.Airports
+	return policy
+}
+
+// flightCacheTTL is how long flights and searches stay cached; the
+// generations that invalidate them live as long
+func (app *Application) flightCacheTTL() time.Duration {
+	if ttl := app.config.Flights.Cache.TTL; ttl > 0 {
+		return ttl
+	}
+	return flightservice.DefaultFlightCacheTTL
+}
+
 // Start begins the application
 func (app *Application) Start() error {
 	signal.Notify(app.shutdownSignal, os.Interrupt)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.expireSeatHolds(ctx)
+	go app.evaluatePriceWatches(ctx)
+
 	go func() {
 		addr := fmt.Sprintf("%s:%d", app.config.Server.Host, app.config.Server.Port)
 		if err := app.server.Start(addr); err != nil && err != http.ErrServerClosed {
@@ -204,6 +449,48 @@ func (app *Application) Start() error {
 	return app.Shutdown()
 }
 
+// expireSeatHolds periodically returns the seats of expired holds to inventory
+func (app *Application) expireSeatHolds(ctx context.Context) {
+	interval := app.config.Flights.Holds.SweepInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := app.server.flightService.ExpireHolds(ctx, now); err != nil {
+				log.Printf("failed to expire seat holds: %v", err)
+			}
+		}
+	}
+}
+
+// evaluatePriceWatches periodically checks watched fares and sends price-drop alerts
+func (app *Application) evaluatePriceWatches(ctx context.Context) {
+	interval := app.config.Flights.PriceWatches.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := app.server.flightService.EvaluatePriceWatches(ctx, now); err != nil {
+				log.Printf("failed to evaluate price watches: %v", err)
+			}
+		}
+	}
+}
+
 // Shutdown gracefully stops the application
 func (app *Application) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -12,6 +12,8 @@ import (
 var (
 	passwordRegex = regexp.MustCompile(`^[a-zA-Z0-9!@#$%^&*()_+\-=\[\]{};:'",.<>/?]{8,}$`)
 	phoneRegex    = regexp.MustCompile(`^\+?[1-9]\d{1,14}$`)
+	nameRegex     = regexp.MustCompile(`^\p{L}[\p{L}' .-]{0,63}$`)
+	documentRegex = regexp.MustCompile(`^[A-Z0-9]{5,20}$`)
 )
 
 // RegisterSchemas registers all validation schemas
@@ -39,6 +41,12 @@ func RegisterSchemas(v *validator.Validate) {
 
 	// Credit card validation
 	v.RegisterValidation("creditcard", validateCreditCard)
+
+	// Passenger name validation
+	v.RegisterValidation("person_name", validatePersonName)
+
+	// Travel document number validation
+	v.RegisterValidation("document_number", validateDocumentNumber)
 }
 
 // Password validation
@@ -51,6 +59,16 @@ func validatePhone(fl validator.FieldLevel) bool {
 	return phoneRegex.MatchString(fl.Field().String())
 }
 
+// Passenger name validation: letters, spaces, apostrophes, dots and hyphens
+func validatePersonName(fl validator.FieldLevel) bool {
+	return nameRegex.MatchString(fl.Field().String())
+}
+
+// Travel document number validation: upper case letters and digits as printed
+func validateDocumentNumber(fl validator.FieldLevel) bool {
+	return documentRegex.MatchString(fl.Field().String())
+}
+
 // Future date validation
 func validateFutureDate(fl validator.FieldLevel) bool {
 	t, ok := fl.Field().Interface().(time.Time)
@@ -126,6 +126,20 @@ func getErrorMessage(err validator.FieldError) string {
 		return "Must contain only alphanumeric characters"
 	case "datetime":
 		return fmt.Sprintf("Must be a valid datetime in format %s", err.Param())
+	case "required_without":
+		return fmt.Sprintf("This field is required without %s", strings.ToLower(err.Param()))
+	case "phone":
+		return "Must be a phone number in international format"
+	case "past":
+		return "Must be in the past"
+	case "future":
+		return "Must be in the future"
+	case "iso3166_1_alpha2":
+		return "Must be a two-letter country code"
+	case "person_name":
+		return "Must contain only letters, spaces, apostrophes and hyphens"
+	case "document_number":
+		return "Must contain only upper case letters and digits"
 	default:
 		return fmt.Sprintf("Failed validation for %s", err.Tag())
 	}
@@ -133,8 +147,5 @@ func getErrorMessage(err validator.FieldError) string {
 
 // registerCustomValidations registers custom validation functions
 func registerCustomValidations(v *validator.Validate) {
-	// Example of a custom validation:
-	// v.RegisterValidation("customtag", func(fl validator.FieldLevel) bool {
-	//     return /* custom validation logic */
-	// })
+	RegisterSchemas(v)
 }
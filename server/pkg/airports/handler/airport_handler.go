@@ -0,0 +1,47 @@
+// pkg/airports/handler/airport_handler.go
+
+package handler
+
+import (
+	"strconv"
+
+	"github.com/Siya360/take-flight/server/pkg/airports/service"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/labstack/echo/v4"
+)
+
+type AirportHandler struct {
+	airportService *service.AirportService
+}
+
+func NewAirportHandler(airportService *service.AirportService) *AirportHandler {
+	return &AirportHandler{
+		airportService: airportService,
+	}
+}
+
+func (h *AirportHandler) ListAirports(c echo.Context) error {
+	airports, err := h.airportService.ListAirports(c.Request().Context())
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, airports)
+}
+
+func (h *AirportHandler) SearchAirports(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	suggestions, err := h.airportService.SearchAirports(c.Request().Context(), c.QueryParam("q"), limit)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, suggestions)
+}
+
+func (h *AirportHandler) GetAirport(c echo.Context) error {
+	code := c.Param("code")
+	airport, err := h.airportService.GetAirport(c.Request().Context(), code)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, airport)
+}
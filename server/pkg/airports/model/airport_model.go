@@ -0,0 +1,56 @@
+// pkg/airports/model/airport_model.go
+
+package model
+
+import (
+	"time"
+)
+
+// Airport is an entry in the airport reference registry, keyed by IATA code.
+type Airport struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	TimeZone  string  `json:"time_zone"`
+}
+
+// Location returns the airport's IANA time zone.
+func (a *Airport) Location() (*time.Location, error) {
+	return time.LoadLocation(a.TimeZone)
+}
+
+// Metro groups the airports serving one metropolitan area under a city code,
+// such as LON for the London airports.
+type Metro struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Country  string   `json:"country"`
+	Airports []string `json:"airports"`
+}
+
+// Kinds of autocomplete suggestion.
+const (
+	SuggestionTypeAirport = "airport"
+	SuggestionTypeMetro   = "metro"
+)
+
+// Limits on the number of autocomplete suggestions returned.
+const (
+	DefaultSuggestionLimit = 10
+	MaxSuggestionLimit     = 25
+)
+
+// Suggestion is one autocomplete match: an airport, or a metro area along
+// with its member airports.
+type Suggestion struct {
+	Type     string     `json:"type"`
+	Code     string     `json:"code"`
+	Name     string     `json:"name"`
+	City     string     `json:"city"`
+	Country  string     `json:"country"`
+	Airports []*Airport `json:"airports,omitempty"`
+	Score    int        `json:"-"`
+}
@@ -0,0 +1,158 @@
+// pkg/airports/repository/embedded/airport_repository.go
+
+package embedded
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Airport time zones must resolve even on hosts without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/Siya360/take-flight/server/pkg/airports/model"
+)
+
+//go:embed airports.csv
+var airportsCSV []byte
+
+//go:embed metros.csv
+var metrosCSV []byte
+
+// EmbeddedAirportRepository serves the airport registry from the dataset
+// compiled into the binary. It is read-only and safe for concurrent use.
+type EmbeddedAirportRepository struct {
+	airports map[string]*model.Airport
+	sorted   []*model.Airport
+	metros   map[string]*model.Metro
+	metroIDs []string
+}
+
+// NewEmbeddedAirportRepository parses the embedded dataset, failing on any
+// malformed row, unknown time zone or metro member that is not an airport.
+func NewEmbeddedAirportRepository() (*EmbeddedAirportRepository, error) {
+	reader := csv.NewReader(bytes.NewReader(airportsCSV))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read airport dataset: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("airport dataset is empty")
+	}
+
+	repo := &EmbeddedAirportRepository{
+		airports: make(map[string]*model.Airport, len(rows)-1),
+	}
+	for i, row := range rows[1:] {
+		line := i + 2
+		if len(row) != 7 {
+			return nil, fmt.Errorf("airport dataset line %d: expected 7 fields, got %d", line, len(row))
+		}
+		latitude, err := strconv.ParseFloat(row[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("airport dataset line %d: invalid latitude: %v", line, err)
+		}
+		longitude, err := strconv.ParseFloat(row[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("airport dataset line %d: invalid longitude: %v", line, err)
+		}
+		if _, err := time.LoadLocation(row[6]); err != nil {
+			return nil, fmt.Errorf("airport dataset line %d: invalid time zone: %v", line, err)
+		}
+
+		airport := &model.Airport{
+			Code:      strings.ToUpper(row[0]),
+			Name:      row[1],
+			City:      row[2],
+			Country:   row[3],
+			Latitude:  latitude,
+			Longitude: longitude,
+			TimeZone:  row[6],
+		}
+		if _, ok := repo.airports[airport.Code]; ok {
+			return nil, fmt.Errorf("airport dataset line %d: duplicate code %s", line, airport.Code)
+		}
+		repo.airports[airport.Code] = airport
+		repo.sorted = append(repo.sorted, airport)
+	}
+
+	sort.Slice(repo.sorted, func(i, j int) bool {
+		return repo.sorted[i].Code < repo.sorted[j].Code
+	})
+
+	if err := repo.loadMetros(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *EmbeddedAirportRepository) loadMetros() error {
+	rows, err := csv.NewReader(bytes.NewReader(metrosCSV)).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read metro dataset: %v", err)
+	}
+
+	r.metros = make(map[string]*model.Metro, len(rows))
+	for i, row := range rows[1:] {
+		line := i + 2
+		if len(row) != 4 {
+			return fmt.Errorf("metro dataset line %d: expected 4 fields, got %d", line, len(row))
+		}
+		metro := &model.Metro{
+			Code:     strings.ToUpper(row[0]),
+			Name:     row[1],
+			Country:  row[2],
+			Airports: strings.Fields(strings.ToUpper(row[3])),
+		}
+		if _, ok := r.airports[metro.Code]; ok {
+			return fmt.Errorf("metro dataset line %d: code %s is also an airport", line, metro.Code)
+		}
+		if _, ok := r.metros[metro.Code]; ok {
+			return fmt.Errorf("metro dataset line %d: duplicate code %s", line, metro.Code)
+		}
+		for _, code := range metro.Airports {
+			if _, ok := r.airports[code]; !ok {
+				return fmt.Errorf("metro dataset line %d: unknown airport %s", line, code)
+			}
+		}
+		r.metros[metro.Code] = metro
+		r.metroIDs = append(r.metroIDs, metro.Code)
+	}
+
+	sort.Strings(r.metroIDs)
+	return nil
+}
+
+func (r *EmbeddedAirportRepository) FindByCode(ctx context.Context, code string) (*model.Airport, error) {
+	airport, ok := r.airports[strings.ToUpper(code)]
+	if !ok {
+		return nil, nil
+	}
+	return airport, nil
+}
+
+func (r *EmbeddedAirportRepository) List(ctx context.Context) ([]*model.Airport, error) {
+	return r.sorted, nil
+}
+
+func (r *EmbeddedAirportRepository) FindMetro(ctx context.Context, code string) (*model.Metro, error) {
+	metro, ok := r.metros[strings.ToUpper(code)]
+	if !ok {
+		return nil, nil
+	}
+	return metro, nil
+}
+
+func (r *EmbeddedAirportRepository) ListMetros(ctx context.Context) ([]*model.Metro, error) {
+	metros := make([]*model.Metro, 0, len(r.metroIDs))
+	for _, code := range r.metroIDs {
+		metros = append(metros, r.metros[code])
+	}
+	return metros, nil
+}
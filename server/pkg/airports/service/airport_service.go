@@ -0,0 +1,258 @@
+// pkg/airports/service/airport_service.go
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Siya360/take-flight/server/pkg/airports/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+)
+
+const (
+	errMsgAirportNotFound = "Airport not found"
+	errMsgFailedToList    = "Failed to list airports"
+	errMsgMetroNotFound   = "Metro area not found"
+	errMsgEmptyQuery      = "Search query must not be empty"
+)
+
+// Scores of the ways a query can match, best first
+const (
+	scoreExactCode  = 100
+	scoreCodePrefix = 90
+	scorePrefix     = 70
+	scoreWordPrefix = 60
+	scoreContains   = 40
+	scoreFuzzy      = 20
+	// Metro areas rank just above their own airports
+	scoreMetroBonus = 5
+)
+
+// foldAccents lets queries typed without diacritics match names that have them
+var foldAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ı", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ğ", "g", "ñ", "n", "ş", "s",
+)
+
+type AirportRepository interface {
+	FindByCode(ctx context.Context, code string) (*model.Airport, error)
+	List(ctx context.Context) ([]*model.Airport, error)
+	FindMetro(ctx context.Context, code string) (*model.Metro, error)
+	ListMetros(ctx context.Context) ([]*model.Metro, error)
+}
+
+type AirportService struct {
+	repo AirportRepository
+}
+
+func NewAirportService(repo AirportRepository) *AirportService {
+	return &AirportService{
+		repo: repo,
+	}
+}
+
+// GetAirport looks an airport up by its IATA code, ignoring case
+func (s *AirportService) GetAirport(ctx context.Context, code string) (*model.Airport, error) {
+	airport, err := s.repo.FindByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || airport == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgAirportNotFound, http.StatusNotFound)
+	}
+	return airport, nil
+}
+
+func (s *AirportService) ListAirports(ctx context.Context) ([]*model.Airport, error) {
+	airports, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToList, http.StatusInternalServerError)
+	}
+	return airports, nil
+}
+
+// Location returns the time zone of an airport
+func (s *AirportService) Location(ctx context.Context, code string) (*time.Location, error) {
+	airport, err := s.GetAirport(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := airport.Location()
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, err.Error(), http.StatusInternalServerError)
+	}
+	return loc, nil
+}
+
+// MetroAirports returns the codes of the airports serving a metro area
+func (s *AirportService) MetroAirports(ctx context.Context, code string) ([]string, error) {
+	metro, err := s.repo.FindMetro(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || metro == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgMetroNotFound, http.StatusNotFound)
+	}
+	return metro.Airports, nil
+}
+
+// SearchAirports suggests airports and metro areas for a type-ahead query.
+// Codes, airport names and city names are matched by prefix, then substring,
+// then with a small edit distance to tolerate typos.
+func (s *AirportService) SearchAirports(ctx context.Context, query string, limit int) ([]*model.Suggestion, error) {
+	query = fold(strings.TrimSpace(query))
+	if query == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgEmptyQuery, http.StatusBadRequest)
+	}
+	if limit <= 0 {
+		limit = model.DefaultSuggestionLimit
+	}
+	if limit > model.MaxSuggestionLimit {
+		limit = model.MaxSuggestionLimit
+	}
+
+	airports, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToList, http.StatusInternalServerError)
+	}
+	metros, err := s.repo.ListMetros(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToList, http.StatusInternalServerError)
+	}
+
+	suggestions := []*model.Suggestion{}
+	for _, airport := range airports {
+		if score := matchScore(query, airport.Code, airport.City, airport.Name); score > 0 {
+			suggestions = append(suggestions, &model.Suggestion{
+				Type:    model.SuggestionTypeAirport,
+				Code:    airport.Code,
+				Name:    airport.Name,
+				City:    airport.City,
+				Country: airport.Country,
+				Score:   score,
+			})
+		}
+	}
+	for _, metro := range metros {
+		score := matchScore(query, metro.Code, metro.Name)
+		if score == 0 {
+			continue
+		}
+		suggestion := &model.Suggestion{
+			Type:    model.SuggestionTypeMetro,
+			Code:    metro.Code,
+			Name:    metro.Name,
+			City:    metro.Name,
+			Country: metro.Country,
+			Score:   score + scoreMetroBonus,
+		}
+		for _, code := range metro.Airports {
+			if airport, err := s.repo.FindByCode(ctx, code); err == nil && airport != nil {
+				suggestion.Airports = append(suggestion.Airports, airport)
+			}
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Code < suggestions[j].Code
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// matchScore rates how well a folded query matches a code and a set of
+// names, returning zero for no match
+func matchScore(query, code string, names ...string) int {
+	code = strings.ToLower(code)
+	if code == query {
+		return scoreExactCode
+	}
+	if strings.HasPrefix(code, query) {
+		return scoreCodePrefix
+	}
+
+	best := 0
+	for _, name := range names {
+		name = fold(name)
+		switch {
+		case strings.HasPrefix(name, query):
+			return scorePrefix
+		case best < scoreWordPrefix && strings.Contains(name, " "+query):
+			best = scoreWordPrefix
+		case best < scoreContains && strings.Contains(name, query):
+			best = scoreContains
+		}
+	}
+	if best > 0 {
+		return best
+	}
+
+	// Typo tolerance grows with the length of the query
+	allowed := 0
+	switch n := utf8.RuneCountInString(query); {
+	case n >= 7:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return 0
+	}
+	for _, name := range names {
+		for _, word := range strings.FieldsFunc(fold(name), isSeparator) {
+			if editDistance(query, truncate(word, utf8.RuneCountInString(query))) <= allowed ||
+				editDistance(query, word) <= allowed {
+				return scoreFuzzy
+			}
+		}
+	}
+	return 0
+}
+
+func fold(s string) string {
+	return foldAccents.Replace(strings.ToLower(s))
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '/' || r == '.'
+}
+
+// truncate returns the first n runes of s
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Siya360/take-flight/server/pkg/airports/model"
+	"github.com/Siya360/take-flight/server/pkg/airports/repository/embedded"
+)
+
+func newTestService(t *testing.T) *AirportService {
+	repo, err := embedded.NewEmbeddedAirportRepository()
+	if err != nil {
+		t.Fatalf("failed to load airports: %v", err)
+	}
+	return NewAirportService(repo)
+}
+
+func TestSearchAirportsRanksMetroAndCodeMatches(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	suggestions, err := svc.SearchAirports(ctx, "lon", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := suggestions[0]
+	if first.Type != model.SuggestionTypeMetro || first.Code != "LON" {
+		t.Fatalf("expected LON metro first, got %+v", first)
+	}
+	members := map[string]bool{}
+	for _, airport := range first.Airports {
+		members[airport.Code] = true
+	}
+	for _, code := range []string{"LHR", "LGW", "STN"} {
+		if !members[code] {
+			t.Fatalf("expected %s in London metro, got %v", code, members)
+		}
+	}
+
+	suggestions, _ = svc.SearchAirports(ctx, "JFK", 0)
+	if suggestions[0].Code != "JFK" || suggestions[0].Type != model.SuggestionTypeAirport {
+		t.Fatalf("expected exact code match first, got %+v", suggestions[0])
+	}
+}
+
+func TestSearchAirportsToleratesTyposAndAccents(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	cases := map[string]string{
+		"frankfrut": "FRA",
+		"sao paulo": "GRU",
+		"heathrow":  "LHR",
+		"kennedy":   "JFK",
+	}
+	for query, want := range cases {
+		suggestions, err := svc.SearchAirports(ctx, query, 0)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", query, err)
+		}
+		found := false
+		for _, suggestion := range suggestions {
+			if suggestion.Code == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected %s for %q, got %+v", want, query, suggestions)
+		}
+	}
+
+	if _, err := svc.SearchAirports(ctx, "  ", 0); err == nil {
+		t.Fatal("expected empty query to be rejected")
+	}
+}
@@ -0,0 +1,264 @@
+// pkg/amadeus/amadeustest/server.go
+
+// Package amadeustest provides an in-process fake of the Amadeus API for
+// testing code that uses the amadeus client without network access.
+package amadeustest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/amadeus"
+)
+
+// Credentials accepted by the fake server unless changed.
+const (
+	ClientID     = "test-client-id"
+	ClientSecret = "test-client-secret"
+)
+
+// Server is a fake Amadeus API serving flight offers registered with AddOffer.
+// It issues tokens for the configured credentials and can be told to fail
+// upcoming requests to exercise retries.
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+	// TokenTTL is the lifetime reported for issued tokens
+	TokenTTL time.Duration
+
+	mu             sync.Mutex
+	offers         []amadeus.FlightOffer
+	tokens         map[string]bool
+	failures       []int
+	tokenRequests  int
+	searchRequests int
+	lastQuery      map[string]string
+}
+
+// NewServer starts a fake Amadeus server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		TokenTTL:     30 * time.Minute,
+		tokens:       make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/security/oauth2/token", s.handleToken)
+	mux.HandleFunc("/v2/shopping/flight-offers", s.handleFlightOffers)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config returns a client configuration pointing at the fake server.
+func (s *Server) Config() amadeus.Config {
+	return amadeus.Config{
+		BaseURL:      s.URL,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RetryDelay:   time.Millisecond,
+	}
+}
+
+// AddOffer registers an offer to be returned by matching searches.
+func (s *Server) AddOffer(offer amadeus.FlightOffer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offers = append(s.offers, offer)
+}
+
+// FailNext makes the next requests fail with the given status codes, in order.
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// RevokeTokens invalidates every token issued so far.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// TokenRequests returns how many tokens have been requested.
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+// SearchRequests returns how many flight offer searches have been received,
+// including failed ones.
+func (s *Server) SearchRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.searchRequests
+}
+
+// LastQuery returns the query parameters of the latest flight offer search.
+func (s *Server) LastQuery() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastQuery
+}
+
+// injectedFailure pops the next queued failure, if any. Callers hold s.mu.
+func (s *Server) injectedFailure() int {
+	if len(s.failures) == 0 {
+		return 0
+	}
+	status := s.failures[0]
+	s.failures = s.failures[1:]
+	return status
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests++
+
+	if status := s.injectedFailure(); status != 0 {
+		writeError(w, status, "Injected failure")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "Invalid grant")
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID || r.PostForm.Get("client_secret") != s.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "Invalid client")
+		return
+	}
+
+	token := fmt.Sprintf("token-%d", s.tokenRequests)
+	s.tokens[token] = true
+	writeJSON(w, http.StatusOK, amadeus.TokenResponse{
+		Type:        "amadeusOAuth2Token",
+		TokenType:   "Bearer",
+		AccessToken: token,
+		ExpiresIn:   int(s.TokenTTL.Seconds()),
+		State:       "approved",
+	})
+}
+
+func (s *Server) handleFlightOffers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searchRequests++
+
+	if status := s.injectedFailure(); status != 0 {
+		writeError(w, status, "Injected failure")
+		return
+	}
+	if !s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		writeError(w, http.StatusUnauthorized, "Access token expired")
+		return
+	}
+
+	query := r.URL.Query()
+	s.lastQuery = make(map[string]string, len(query))
+	for key := range query {
+		s.lastQuery[key] = query.Get(key)
+	}
+
+	origin := query.Get("originLocationCode")
+	destination := query.Get("destinationLocationCode")
+	date := query.Get("departureDate")
+	if origin == "" || destination == "" || date == "" {
+		writeError(w, http.StatusBadRequest, "Missing mandatory query parameter")
+		return
+	}
+	adults, err := strconv.Atoi(query.Get("adults"))
+	if err != nil || adults < 1 {
+		writeError(w, http.StatusBadRequest, "Invalid number of adults")
+		return
+	}
+
+	resp := amadeus.FlightOffersResponse{Data: []amadeus.FlightOffer{}}
+	for _, offer := range s.offers {
+		if matches(offer, origin, destination, date, adults, query.Get("nonStop") == "true") {
+			resp.Data = append(resp.Data, offer)
+		}
+	}
+	if max, err := strconv.Atoi(query.Get("max")); err == nil && max > 0 && len(resp.Data) > max {
+		resp.Data = resp.Data[:max]
+	}
+	resp.Meta.Count = len(resp.Data)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// matches reports whether the outbound itinerary of an offer fits a search
+func matches(offer amadeus.FlightOffer, origin, destination, date string, adults int, nonStop bool) bool {
+	if len(offer.Itineraries) == 0 || len(offer.Itineraries[0].Segments) == 0 {
+		return false
+	}
+	segments := offer.Itineraries[0].Segments
+	first, last := segments[0], segments[len(segments)-1]
+	return first.Departure.IataCode == origin &&
+		last.Arrival.IataCode == destination &&
+		strings.HasPrefix(first.Departure.At, date) &&
+		offer.NumberOfBookableSeats >= adults &&
+		(!nonStop || len(segments) == 1)
+}
+
+// NewOffer builds a one-way, non-stop economy offer for one adult. Times are
+// local to their airports, as Amadeus reports them.
+func NewOffer(id, carrier, number, origin, destination string, departure, arrival time.Time, total float64, seats int) amadeus.FlightOffer {
+	price := strconv.FormatFloat(total, 'f', 2, 64)
+	return amadeus.FlightOffer{
+		Type:                  "flight-offer",
+		ID:                    id,
+		Source:                "GDS",
+		OneWay:                true,
+		NumberOfBookableSeats: seats,
+		Itineraries: []amadeus.OfferItinerary{{
+			Segments: []amadeus.Segment{{
+				ID:          "1",
+				Departure:   amadeus.FlightEndpoint{IataCode: origin, At: departure.Format(amadeus.LocalTimeLayout)},
+				Arrival:     amadeus.FlightEndpoint{IataCode: destination, At: arrival.Format(amadeus.LocalTimeLayout)},
+				CarrierCode: carrier,
+				Number:      number,
+			}},
+		}},
+		Price:                  amadeus.Price{Currency: "USD", Total: price, Base: price, GrandTotal: price},
+		ValidatingAirlineCodes: []string{carrier},
+		TravelerPricings: []amadeus.TravelerPricing{{
+			TravelerID:   "1",
+			FareOption:   "STANDARD",
+			TravelerType: "ADULT",
+			Price:        amadeus.Price{Currency: "USD", Total: price, Base: price},
+			FareDetailsBySegment: []amadeus.FareDetailsBySegment{{
+				SegmentID: "1",
+				Cabin:     amadeus.TravelClassEconomy,
+				FareBasis: "Y" + carrier,
+				Class:     "Y",
+			}},
+		}},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.amadeus+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, title string) {
+	writeJSON(w, status, amadeus.ErrorResponse{
+		Errors: []amadeus.ErrorDetail{{Status: status, Title: title}},
+	})
+}
@@ -0,0 +1,257 @@
+// pkg/amadeus/client.go
+
+package amadeus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Base URLs of the Amadeus self-service environments.
+const (
+	TestBaseURL       = "https://test.api.amadeus.com"
+	ProductionBaseURL = "https://api.amadeus.com"
+)
+
+const (
+	tokenPath        = "/v1/security/oauth2/token"
+	flightOffersPath = "/v2/shopping/flight-offers"
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultRetryDelay = 200 * time.Millisecond
+	// Tokens are renewed this long before Amadeus expires them
+	tokenExpirySkew = 30 * time.Second
+)
+
+// Config holds the Amadeus credentials and connection settings. Zero values
+// fall back to the test environment and the default timeout and retries.
+type Config struct {
+	BaseURL      string        `yaml:"baseURL"`
+	ClientID     string        `yaml:"clientID"`
+	ClientSecret string        `yaml:"clientSecret"`
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxRetries   int           `yaml:"maxRetries"`
+	RetryDelay   time.Duration `yaml:"retryDelay"`
+}
+
+// Client is a typed Amadeus API client. It caches its access token until
+// shortly before expiry and retries throttled or failed requests with
+// exponential backoff. It is safe for concurrent use.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewClient creates a client for the given configuration
+func NewClient(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = TestBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRetryDelay
+	}
+
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// WithHTTPClient replaces the HTTP client used for requests
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
+// SearchFlightOffers runs a flight offers search
+func (c *Client) SearchFlightOffers(ctx context.Context, req FlightOffersRequest) (*FlightOffersResponse, error) {
+	if req.Origin == "" || req.Destination == "" || req.DepartureDate.IsZero() {
+		return nil, errors.New("amadeus: origin, destination and departure date are required")
+	}
+	if req.Adults < 1 {
+		req.Adults = 1
+	}
+
+	query := url.Values{}
+	query.Set("originLocationCode", req.Origin)
+	query.Set("destinationLocationCode", req.Destination)
+	query.Set("departureDate", req.DepartureDate.Format(DateLayout))
+	query.Set("adults", strconv.Itoa(req.Adults))
+	if req.ReturnDate != nil {
+		query.Set("returnDate", req.ReturnDate.Format(DateLayout))
+	}
+	if req.TravelClass != "" {
+		query.Set("travelClass", req.TravelClass)
+	}
+	if req.NonStop {
+		query.Set("nonStop", "true")
+	}
+	if req.CurrencyCode != "" {
+		query.Set("currencyCode", req.CurrencyCode)
+	}
+	if req.Max > 0 {
+		query.Set("max", strconv.Itoa(req.Max))
+	}
+
+	var resp FlightOffersResponse
+	if err := c.get(ctx, flightOffersPath, query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// get performs an authenticated GET, renewing the token once if Amadeus
+// rejects it
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	endpoint := c.config.BaseURL + path + "?" + query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.do(ctx, func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Accept", "application/vnd.amadeus+json")
+			return req, nil
+		}, out)
+
+		var apiErr *APIError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.invalidateToken(token)
+			continue
+		}
+		return err
+	}
+}
+
+// accessToken returns the cached token, requesting a new one when it is
+// missing or about to expire
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.config.ClientID)
+	form.Set("client_secret", c.config.ClientSecret)
+	body := form.Encode()
+
+	var resp TokenResponse
+	err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.BaseURL+tokenPath, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("amadeus: token response had no access token")
+	}
+
+	c.token = resp.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpirySkew)
+	return c.token, nil
+}
+
+// invalidateToken drops the cached token unless another request already replaced it
+func (c *Client) invalidateToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends a request built by newRequest and decodes a JSON response into
+// out, retrying network errors, throttling and server errors with backoff
+func (c *Client) do(ctx context.Context, newRequest func() (*http.Request, error), out interface{}) error {
+	delay := c.config.RetryDelay
+	var lastErr error
+
+	for attempt := 0; attempt < c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+
+		retry, err := c.send(req, out)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+		if !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("amadeus: giving up after %d attempts: %w", c.config.MaxRetries, lastErr)
+}
+
+// send performs one request and reports whether a failure is worth retrying
+func (c *Client) send(req *http.Request, out interface{}) (bool, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var body ErrorResponse
+		if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &body) == nil {
+			apiErr.Errors = body.Errors
+		}
+		return apiErr.Temporary(), apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("amadeus: failed to decode response: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,148 @@
+package amadeus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/amadeus"
+	"github.com/Siya360/take-flight/server/pkg/amadeus/amadeustest"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+func TestSearchFlightOffersCachesTokenAndRetries(t *testing.T) {
+	server := amadeustest.NewServer()
+	defer server.Close()
+
+	departure := time.Date(2026, 9, 1, 18, 30, 0, 0, time.UTC)
+	server.AddOffer(amadeustest.NewOffer("1", "BA", "178", "JFK", "LHR", departure, departure.Add(7*time.Hour), 612.40, 9))
+
+	client := amadeus.NewClient(server.Config())
+	ctx := context.Background()
+	req := amadeus.FlightOffersRequest{Origin: "JFK", Destination: "LHR", DepartureDate: departure, NonStop: true}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.SearchFlightOffers(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Data) != 1 || resp.Data[0].ID != "1" {
+			t.Fatalf("unexpected offers: %+v", resp.Data)
+		}
+	}
+	if server.TokenRequests() != 1 {
+		t.Fatalf("expected token to be cached, got %d token requests", server.TokenRequests())
+	}
+	if query := server.LastQuery(); query["departureDate"] != "2026-09-01" || query["nonStop"] != "true" || query["adults"] != "1" {
+		t.Fatalf("unexpected query: %v", query)
+	}
+
+	// Throttling and server errors are retried
+	server.FailNext(http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	if _, err := client.SearchFlightOffers(ctx, req); err != nil {
+		t.Fatalf("expected retries to succeed, got %v", err)
+	}
+
+	// Client errors are not
+	before := server.SearchRequests()
+	server.FailNext(http.StatusBadRequest)
+	_, err := client.SearchFlightOffers(ctx, req)
+	var apiErr *amadeus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || server.SearchRequests() != before+1 {
+		t.Fatalf("expected a single failed attempt, got %v after %d requests", err, server.SearchRequests()-before)
+	}
+
+	// A rejected token is renewed once
+	server.RevokeTokens()
+	if _, err := client.SearchFlightOffers(ctx, req); err != nil {
+		t.Fatalf("expected token renewal, got %v", err)
+	}
+	if server.TokenRequests() != 2 {
+		t.Fatalf("expected a second token, got %d token requests", server.TokenRequests())
+	}
+}
+
+func TestSearchFlightOffersRejectsBadCredentials(t *testing.T) {
+	server := amadeustest.NewServer()
+	defer server.Close()
+
+	config := server.Config()
+	config.ClientSecret = "wrong"
+	_, err := amadeus.NewClient(config).SearchFlightOffers(context.Background(), amadeus.FlightOffersRequest{
+		Origin: "JFK", Destination: "LHR", DepartureDate: time.Now(),
+	})
+	var apiErr *amadeus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestMapperUsesAirportTimeZones(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	london, _ := time.LoadLocation("Europe/London")
+	mapper := amadeus.Mapper{Location: func(code string) *time.Location {
+		return map[string]*time.Location{"JFK": newYork, "LHR": london}[code]
+	}}
+
+	local := time.Date(2026, 9, 1, 18, 30, 0, 0, time.UTC)
+	offer := amadeustest.NewOffer("7", "BA", "178", "JFK", "LHR", local, local.Add(12*time.Hour), 1000.01, 4)
+	offer.Itineraries = append(offer.Itineraries, amadeus.OfferItinerary{Segments: []amadeus.Segment{{
+		ID:          "2",
+		Departure:   amadeus.FlightEndpoint{IataCode: "LHR", At: "2026-09-08T11:00:00"},
+		Arrival:     amadeus.FlightEndpoint{IataCode: "JFK", At: "2026-09-08T14:00:00"},
+		CarrierCode: "BA",
+		Number:      "177",
+	}}})
+
+	itineraries, err := mapper.Itineraries(offer, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(itineraries) != 2 {
+		t.Fatalf("expected outbound and return itineraries, got %d", len(itineraries))
+	}
+	outbound := itineraries[0].Legs[0]
+	if outbound.ID != "amadeus:7:1" || outbound.FlightNumber != "BA178" || outbound.AvailableSeats != 4 {
+		t.Fatalf("unexpected flight: %+v", outbound)
+	}
+	if !outbound.DepartureTime.Equal(time.Date(2026, 9, 1, 22, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected New York departure time, got %s", outbound.DepartureTime.UTC())
+	}
+	if itineraries[1].DurationMinutes != 8*60 {
+		t.Fatalf("expected an 8 hour return across time zones, got %d minutes", itineraries[1].DurationMinutes)
+	}
+	if len(outbound.Fares) != 1 || outbound.Fares[0].Cabin != model.CabinEconomy {
+		t.Fatalf("expected an economy fare, got %+v", outbound.Fares)
+	}
+	if total := itineraries[0].TotalPrice + itineraries[1].TotalPrice; total != 1000.01 {
+		t.Fatalf("expected the offer price to be split across legs, got %.2f", total)
+	}
+}
+
+func TestProviderReturnsNonStopFlights(t *testing.T) {
+	server := amadeustest.NewServer()
+	defer server.Close()
+
+	departure := time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+	server.AddOffer(amadeustest.NewOffer("1", "AA", "100", "JFK", "LAX", departure, departure.Add(3*time.Hour), 240, 9))
+	server.AddOffer(amadeustest.NewOffer("2", "UA", "200", "JFK", "LAX", departure.Add(time.Hour), departure.Add(4*time.Hour), 260, 1))
+
+	provider := amadeus.NewProvider(amadeus.NewClient(server.Config()), amadeus.Mapper{})
+	flights, err := provider.SearchFlights(context.Background(), model.SearchFlightRequest{
+		DepartureCity: "JFK",
+		ArrivalCity:   "LAX",
+		DepartureDate: departure,
+		Passengers:    2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(flights) != 1 || flights[0].FlightNumber != "AA100" || flights[0].Price != 240 {
+		t.Fatalf("expected only the offer with room for two, got %+v", flights)
+	}
+	if server.LastQuery()["adults"] != "2" {
+		t.Fatalf("expected passengers to be sent as adults, got %v", server.LastQuery())
+	}
+}
@@ -0,0 +1,178 @@
+// pkg/amadeus/mapper.go
+
+package amadeus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+// FlightIDPrefix marks flights that came from Amadeus rather than our inventory.
+const FlightIDPrefix = "amadeus:"
+
+// cabins maps Amadeus cabins onto the cabins we sell
+var cabins = map[string]model.CabinClass{
+	TravelClassEconomy:        model.CabinEconomy,
+	TravelClassPremiumEconomy: model.CabinPremium,
+	TravelClassBusiness:       model.CabinBusiness,
+	TravelClassFirst:          model.CabinFirst,
+}
+
+// Mapper converts flight offers into our flight and itinerary models.
+type Mapper struct {
+	// Location resolves the time zone of an airport. Segment times are local
+	// to their airport, so without it they are read as UTC.
+	Location func(iataCode string) *time.Location
+}
+
+// Itineraries maps every itinerary of an offer, outbound first. The price
+// each passenger pays for the offer is spread evenly over all its segments
+// so the itineraries add up to the offer price.
+func (m Mapper) Itineraries(offer FlightOffer, passengers int) ([]*model.Itinerary, error) {
+	if passengers < 1 {
+		passengers = 1
+	}
+
+	segments := 0
+	for _, itinerary := range offer.Itineraries {
+		segments += len(itinerary.Segments)
+	}
+	if segments == 0 {
+		return nil, fmt.Errorf("amadeus: offer %s has no segments", offer.ID)
+	}
+
+	perPassenger, err := passengerPrice(offer, passengers)
+	if err != nil {
+		return nil, err
+	}
+	prices := splitPrice(perPassenger, segments)
+
+	fares := make(map[string]FareDetailsBySegment)
+	if len(offer.TravelerPricings) > 0 {
+		for _, detail := range offer.TravelerPricings[0].FareDetailsBySegment {
+			fares[detail.SegmentID] = detail
+		}
+	}
+
+	itineraries := make([]*model.Itinerary, 0, len(offer.Itineraries))
+	index := 0
+	for _, itinerary := range offer.Itineraries {
+		legs := make([]*model.Flight, 0, len(itinerary.Segments))
+		for _, segment := range itinerary.Segments {
+			flight, err := m.flight(offer, segment, fares[segment.ID], prices[index])
+			if err != nil {
+				return nil, err
+			}
+			legs = append(legs, flight)
+			index++
+		}
+		if len(legs) > 0 {
+			itineraries = append(itineraries, model.NewItinerary(legs, passengers))
+		}
+	}
+	return itineraries, nil
+}
+
+// Flights maps the outbound legs of one-way offers into flights. Offers
+// with connections or a return are skipped since they cannot be sold as a
+// single flight.
+func (m Mapper) Flights(offers []FlightOffer, passengers int) ([]*model.Flight, error) {
+	flights := []*model.Flight{}
+	for _, offer := range offers {
+		if len(offer.Itineraries) != 1 || len(offer.Itineraries[0].Segments) != 1 {
+			continue
+		}
+		itineraries, err := m.Itineraries(offer, passengers)
+		if err != nil {
+			return nil, err
+		}
+		flights = append(flights, itineraries[0].Legs[0])
+	}
+	return flights, nil
+}
+
+// flight maps one segment of an offer
+func (m Mapper) flight(offer FlightOffer, segment Segment, fare FareDetailsBySegment, price float64) (*model.Flight, error) {
+	departure, err := m.localTime(segment.Departure)
+	if err != nil {
+		return nil, err
+	}
+	arrival, err := m.localTime(segment.Arrival)
+	if err != nil {
+		return nil, err
+	}
+
+	flight := &model.Flight{
+		ID:             FlightIDPrefix + offer.ID + ":" + segment.ID,
+		FlightNumber:   segment.CarrierCode + segment.Number,
+		DepartureCity:  segment.Departure.IataCode,
+		ArrivalCity:    segment.Arrival.IataCode,
+		DepartureTime:  departure,
+		ArrivalTime:    arrival,
+		Capacity:       offer.NumberOfBookableSeats,
+		AvailableSeats: offer.NumberOfBookableSeats,
+		Price:          price,
+		Status:         model.FlightStatusScheduled,
+	}
+	if cabin, ok := cabins[fare.Cabin]; ok {
+		flight.Fares = []model.FareBucket{{
+			Code:  fare.FareBasis,
+			Cabin: cabin,
+			Price: price,
+			Seats: offer.NumberOfBookableSeats,
+		}}
+	}
+	return flight, nil
+}
+
+// localTime reads a segment time in its airport's time zone
+func (m Mapper) localTime(endpoint FlightEndpoint) (time.Time, error) {
+	loc := time.UTC
+	if m.Location != nil {
+		if resolved := m.Location(endpoint.IataCode); resolved != nil {
+			loc = resolved
+		}
+	}
+	t, err := time.ParseInLocation(LocalTimeLayout, endpoint.At, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("amadeus: invalid time %q at %s: %w", endpoint.At, endpoint.IataCode, err)
+	}
+	return t, nil
+}
+
+// passengerPrice returns what one passenger pays for the whole offer
+func passengerPrice(offer FlightOffer, passengers int) (float64, error) {
+	if len(offer.TravelerPricings) > 0 && offer.TravelerPricings[0].Price.Total != "" {
+		return parseAmount(offer.TravelerPricings[0].Price.Total)
+	}
+	total, err := parseAmount(offer.Price.Total)
+	if err != nil {
+		return 0, err
+	}
+	return total / float64(passengers), nil
+}
+
+func parseAmount(amount string) (float64, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amadeus: invalid amount %q: %w", amount, err)
+	}
+	return value, nil
+}
+
+// splitPrice divides a price into n parts in whole cents, putting any
+// remainder on the first part
+func splitPrice(price float64, n int) []float64 {
+	cents := int64(math.Round(price * 100))
+	share := cents / int64(n)
+	parts := make([]float64, n)
+	for i := range parts {
+		parts[i] = float64(share) / 100
+	}
+	parts[0] = float64(cents-share*int64(n-1)) / 100
+	return parts
+}
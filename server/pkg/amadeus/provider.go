@@ -0,0 +1,51 @@
+// pkg/amadeus/provider.go
+
+package amadeus
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+// ProviderName identifies Amadeus among flight providers.
+const ProviderName = "amadeus"
+
+// Provider serves non-stop Amadeus flight offers as flights, for use as a
+// flight provider alongside our own catalog. Only the requested departure
+// date is searched; flexible date windows are not expanded.
+type Provider struct {
+	client *Client
+	mapper Mapper
+}
+
+// NewProvider creates a provider searching through the given client
+func NewProvider(client *Client, mapper Mapper) *Provider {
+	return &Provider{
+		client: client,
+		mapper: mapper,
+	}
+}
+
+func (p *Provider) Name() string {
+	return ProviderName
+}
+
+func (p *Provider) SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	passengers := criteria.Passengers
+	if passengers < 1 {
+		passengers = 1
+	}
+
+	resp, err := p.client.SearchFlightOffers(ctx, FlightOffersRequest{
+		Origin:        criteria.DepartureCity,
+		Destination:   criteria.ArrivalCity,
+		DepartureDate: criteria.DepartureDate,
+		Adults:        passengers,
+		NonStop:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.mapper.Flights(resp.Data, passengers)
+}
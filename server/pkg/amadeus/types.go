@@ -0,0 +1,171 @@
+// pkg/amadeus/types.go
+
+package amadeus
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the format of dates in Amadeus requests.
+const DateLayout = "2006-01-02"
+
+// LocalTimeLayout is the format of segment times, which are local to their
+// airport and carry no UTC offset.
+const LocalTimeLayout = "2006-01-02T15:04:05"
+
+// Travel classes accepted by the flight offers search.
+const (
+	TravelClassEconomy        = "ECONOMY"
+	TravelClassPremiumEconomy = "PREMIUM_ECONOMY"
+	TravelClassBusiness       = "BUSINESS"
+	TravelClassFirst          = "FIRST"
+)
+
+// TokenResponse is the body returned by the OAuth2 client credentials grant.
+type TokenResponse struct {
+	Type        string `json:"type"`
+	TokenType   string `json:"token_type"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	State       string `json:"state"`
+}
+
+// FlightOffersRequest holds the query of a flight offers search.
+type FlightOffersRequest struct {
+	Origin        string
+	Destination   string
+	DepartureDate time.Time
+	ReturnDate    *time.Time
+	Adults        int
+	TravelClass   string
+	NonStop       bool
+	CurrencyCode  string
+	Max           int
+}
+
+// FlightOffersResponse is the body of a flight offers search.
+type FlightOffersResponse struct {
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+	Data         []FlightOffer `json:"data"`
+	Dictionaries Dictionaries  `json:"dictionaries"`
+}
+
+// Dictionaries resolves the codes used in flight offers.
+type Dictionaries struct {
+	Locations  map[string]LocationEntry `json:"locations,omitempty"`
+	Aircraft   map[string]string        `json:"aircraft,omitempty"`
+	Currencies map[string]string        `json:"currencies,omitempty"`
+	Carriers   map[string]string        `json:"carriers,omitempty"`
+}
+
+type LocationEntry struct {
+	CityCode    string `json:"cityCode"`
+	CountryCode string `json:"countryCode"`
+}
+
+// FlightOffer is one priced journey. It has one itinerary for a one-way
+// search and two for a round trip.
+type FlightOffer struct {
+	Type                     string            `json:"type"`
+	ID                       string            `json:"id"`
+	Source                   string            `json:"source"`
+	InstantTicketingRequired bool              `json:"instantTicketingRequired"`
+	OneWay                   bool              `json:"oneWay"`
+	LastTicketingDate        string            `json:"lastTicketingDate"`
+	NumberOfBookableSeats    int               `json:"numberOfBookableSeats"`
+	Itineraries              []OfferItinerary  `json:"itineraries"`
+	Price                    Price             `json:"price"`
+	ValidatingAirlineCodes   []string          `json:"validatingAirlineCodes"`
+	TravelerPricings         []TravelerPricing `json:"travelerPricings"`
+}
+
+type OfferItinerary struct {
+	Duration string    `json:"duration"`
+	Segments []Segment `json:"segments"`
+}
+
+type Segment struct {
+	ID            string         `json:"id"`
+	Departure     FlightEndpoint `json:"departure"`
+	Arrival       FlightEndpoint `json:"arrival"`
+	CarrierCode   string         `json:"carrierCode"`
+	Number        string         `json:"number"`
+	Aircraft      Aircraft       `json:"aircraft"`
+	Duration      string         `json:"duration"`
+	NumberOfStops int            `json:"numberOfStops"`
+}
+
+type FlightEndpoint struct {
+	IataCode string `json:"iataCode"`
+	Terminal string `json:"terminal,omitempty"`
+	At       string `json:"at"`
+}
+
+type Aircraft struct {
+	Code string `json:"code"`
+}
+
+// Price amounts are decimal strings in the offer's currency.
+type Price struct {
+	Currency   string `json:"currency"`
+	Total      string `json:"total"`
+	Base       string `json:"base"`
+	GrandTotal string `json:"grandTotal,omitempty"`
+}
+
+type TravelerPricing struct {
+	TravelerID           string                 `json:"travelerId"`
+	FareOption           string                 `json:"fareOption"`
+	TravelerType         string                 `json:"travelerType"`
+	Price                Price                  `json:"price"`
+	FareDetailsBySegment []FareDetailsBySegment `json:"fareDetailsBySegment"`
+}
+
+type FareDetailsBySegment struct {
+	SegmentID string `json:"segmentId"`
+	Cabin     string `json:"cabin"`
+	FareBasis string `json:"fareBasis"`
+	Class     string `json:"class"`
+}
+
+// ErrorResponse is the body Amadeus returns with a failed request.
+type ErrorResponse struct {
+	Errors []ErrorDetail `json:"errors"`
+}
+
+type ErrorDetail struct {
+	Status int    `json:"status"`
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// APIError is returned for any non-2xx response from Amadeus.
+type APIError struct {
+	StatusCode int
+	Errors     []ErrorDetail
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("amadeus: request failed with status %d", e.StatusCode)
+	}
+	details := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		if detail.Detail != "" {
+			details = append(details, detail.Title+": "+detail.Detail)
+		} else {
+			details = append(details, detail.Title)
+		}
+	}
+	return fmt.Sprintf("amadeus: request failed with status %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == 429 || e.StatusCode >= 500
+}
@@ -3,9 +3,15 @@
 package handler
 
 import (
+	"io"
+	"net/http"
+	"strconv"
+
+	authmodel "github.com/Siya360/take-flight/server/pkg/auth/model"
 	"github.com/Siya360/take-flight/server/pkg/bookings/model"
 	"github.com/Siya360/take-flight/server/pkg/bookings/service"
 	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
 	"github.com/labstack/echo/v4"
 )
 
@@ -24,6 +30,9 @@ func (h *BookingHandler) CreateBooking(c echo.Context) error {
 	if err := common.ParseJSON(c, &req); err != nil {
 		return err
 	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
 
 	// Get user ID from context (set by auth middleware)
 	userID := c.Get("user_id").(string)
@@ -48,14 +57,50 @@ func (h *BookingHandler) GetBooking(c echo.Context) error {
 }
 
 func (h *BookingHandler) UpdateBooking(c echo.Context) error {
-	bookingID := c.Param("id")
-
 	var updateReq model.UpdateBookingRequest
 	if err := common.ParseJSON(c, &updateReq); err != nil {
 		return err
 	}
+	if err := validate(c, &updateReq); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	booking, err := h.bookingService.UpdateBooking(c.Request().Context(), actingUser(c), c.Param("id"), &updateReq)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+func (h *BookingHandler) SelectSeats(c echo.Context) error {
+	var req model.SelectSeatsRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	booking, err := h.bookingService.SelectSeats(c.Request().Context(), actingUser(c), c.Param("id"), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// UpdatePassenger changes the details of one named passenger on a booking
+func (h *BookingHandler) UpdatePassenger(c echo.Context) error {
+	var req model.UpdatePassengerRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
 
-	booking, err := h.bookingService.UpdateBooking(c.Request().Context(), bookingID, &updateReq)
+	booking, err := h.bookingService.UpdatePassenger(c.Request().Context(), actingUser(c), c.Param("id"), c.Param("passenger_id"), &req)
 	if err != nil {
 		return common.RespondWithError(c, err)
 	}
@@ -63,16 +108,72 @@ func (h *BookingHandler) UpdateBooking(c echo.Context) error {
 	return common.RespondWithSuccess(c, booking)
 }
 
+// RemovePassenger takes a named passenger off a booking and refunds their fare
+func (h *BookingHandler) RemovePassenger(c echo.Context) error {
+	booking, err := h.bookingService.RemovePassenger(c.Request().Context(), actingUser(c), c.Param("id"), c.Param("passenger_id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// QuoteRemoval shows the refund for removing a passenger before the customer confirms
+func (h *BookingHandler) QuoteRemoval(c echo.Context) error {
+	quote, err := h.bookingService.QuoteRemoval(c.Request().Context(), actingUser(c), c.Param("id"), c.Param("passenger_id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, quote)
+}
+
+// TransitionStatus returns a handler moving a booking to the given status
+func (h *BookingHandler) TransitionStatus(status model.BookingStatus) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		booking, err := h.bookingService.TransitionStatus(c.Request().Context(), c.Param("id"), status)
+		if err != nil {
+			return common.RespondWithError(c, err)
+		}
+		return common.RespondWithSuccess(c, booking)
+	}
+}
+
 func (h *BookingHandler) CancelBooking(c echo.Context) error {
-	bookingID := c.Param("id")
+	quote, err := h.bookingService.CancelBooking(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, quote)
+}
+
+// QuoteCancellation shows the refund for cancelling before the customer confirms
+func (h *BookingHandler) QuoteCancellation(c echo.Context) error {
+	quote, err := h.bookingService.QuoteCancellation(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, quote)
+}
 
-	if err := h.bookingService.CancelBooking(c.Request().Context(), bookingID); err != nil {
+// QuoteChange shows the fees for an update before the customer confirms
+func (h *BookingHandler) QuoteChange(c echo.Context) error {
+	var updateReq model.UpdateBookingRequest
+	if err := common.ParseJSON(c, &updateReq); err != nil {
+		return err
+	}
+	if err := validate(c, &updateReq); err != nil {
 		return common.RespondWithError(c, err)
 	}
 
-	return common.RespondWithSuccess(c, map[string]string{
-		"message": "Booking successfully cancelled",
-	})
+	quote, err := h.bookingService.QuoteChange(c.Request().Context(), actingUser(c), c.Param("id"), &updateReq)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, quote)
 }
 
 func (h *BookingHandler) SearchBookings(c echo.Context) error {
@@ -94,3 +195,264 @@ func (h *BookingHandler) SearchBookings(c echo.Context) error {
 
 	return common.RespondWithSuccess(c, bookings)
 }
+
+// AcceptRebooking moves a disrupted booking to the alternative flight on offer
+func (h *BookingHandler) AcceptRebooking(c echo.Context) error {
+	booking, err := h.bookingService.AcceptRebooking(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// AcceptRefund cancels a disrupted booking in exchange for a refund
+func (h *BookingHandler) AcceptRefund(c echo.Context) error {
+	booking, err := h.bookingService.AcceptRefund(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// DisruptFlight re-runs disruption handling for the bookings on a flight
+func (h *BookingHandler) DisruptFlight(c echo.Context) error {
+	report, err := h.bookingService.DisruptFlight(c.Request().Context(), c.Param("flight_id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, report)
+}
+
+// HoldSeats reserves seats on a flight while the customer pays
+func (h *BookingHandler) HoldSeats(c echo.Context) error {
+	var req flightmodel.SeatHoldRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	userID := c.Get("user_id").(string)
+
+	hold, err := h.bookingService.HoldSeats(c.Request().Context(), userID, &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, hold)
+}
+
+func (h *BookingHandler) GetHold(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	hold, err := h.bookingService.GetHold(c.Request().Context(), userID, c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, hold)
+}
+
+// ReleaseHold gives held seats back to inventory
+func (h *BookingHandler) ReleaseHold(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	hold, err := h.bookingService.ReleaseHold(c.Request().Context(), userID, c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, hold)
+}
+
+func (h *BookingHandler) CheckIn(c echo.Context) error {
+	booking, err := h.bookingService.CheckIn(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// DeniedBoarding reports who would be bumped from an oversold flight
+func (h *BookingHandler) DeniedBoarding(c echo.Context) error {
+	showUps, _ := strconv.Atoi(c.QueryParam("show_ups"))
+
+	report, err := h.bookingService.DeniedBoarding(c.Request().Context(), c.Param("flight_id"), showUps, c.QueryParam("order"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, report)
+}
+
+// ManageBooking retrieves a booking by record locator and passenger last name, without signing in
+func (h *BookingHandler) ManageBooking(c echo.Context) error {
+	var req model.ManageBookingRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	booking, err := h.bookingService.ManageBooking(c.Request().Context(), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// AddGuestContact adds a passenger's contact details to a booking retrieved by record locator
+func (h *BookingHandler) AddGuestContact(c echo.Context) error {
+	var req model.GuestContactRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	booking, err := h.bookingService.AddGuestContact(c.Request().Context(), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, booking)
+}
+
+// GuestCancel cancels a booking retrieved by record locator and returns the refund breakdown
+func (h *BookingHandler) GuestCancel(c echo.Context) error {
+	var req model.ManageBookingRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	quote, err := h.bookingService.GuestCancel(c.Request().Context(), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, quote)
+}
+
+// GuestRefunds shows the refunds on a booking retrieved by record locator
+func (h *BookingHandler) GuestRefunds(c echo.Context) error {
+	var req model.ManageBookingRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	refunds, err := h.bookingService.GuestRefunds(c.Request().Context(), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, refunds)
+}
+
+// PayBooking pays for a pending booking with a tokenized payment method
+func (h *BookingHandler) PayBooking(c echo.Context) error {
+	var req model.PayBookingRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	if err := validate(c, &req); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	response, err := h.bookingService.PayBooking(c.Request().Context(), actingUser(c), c.Param("id"), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, response)
+}
+
+func (h *BookingHandler) ListPayments(c echo.Context) error {
+	payments, err := h.bookingService.ListPayments(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, payments)
+}
+
+// ListRefunds shows the refunds owed or paid on a booking and where each stands
+func (h *BookingHandler) ListRefunds(c echo.Context) error {
+	refunds, err := h.bookingService.ListRefunds(c.Request().Context(), actingUser(c), c.Param("id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, refunds)
+}
+
+// CompleteRefund pays out a refund still owed on a booking
+func (h *BookingHandler) CompleteRefund(c echo.Context) error {
+	refund, err := h.bookingService.CompleteRefund(c.Request().Context(), c.Param("id"), c.Param("refund_id"))
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, refund)
+}
+
+// PaymentWebhook receives events from the payment processor, which signs the raw body
+func (h *BookingHandler) PaymentWebhook(c echo.Context) error {
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return common.RespondWithError(c, common.NewAppError(common.ErrInvalidInput, "Invalid request body", http.StatusBadRequest))
+	}
+
+	signature := c.Request().Header.Get(model.PaymentSignatureHeader)
+	if err := h.bookingService.HandlePaymentWebhook(c.Request().Context(), payload, signature); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, nil)
+}
+
+// CompleteChallenge passes or fails the 3-D Secure challenge of a payment on
+// one of the caller's bookings, on a processor that simulates them
+func (h *BookingHandler) CompleteChallenge(c echo.Context) error {
+	var req model.ChallengeRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+
+	response, err := h.bookingService.SimulateChallenge(c.Request().Context(), actingUser(c), c.Param("reference"), &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, response)
+}
+
+// actingUser returns the user a booking must be held by for the caller to act
+// on it, or an empty ID for admins, who may act on any booking
+func actingUser(c echo.Context) string {
+	if claims, ok := c.Get("claims").(*authmodel.TokenClaims); ok && claims.Role == common.RoleAdmin {
+		return ""
+	}
+	return c.Get("user_id").(string)
+}
+
+// validate checks a request against its validation tags, naming the fields that fail
+func validate(c echo.Context, req interface{}) error {
+	if err := c.Validate(req); err != nil {
+		return common.NewAppError(common.ErrInvalidInput, err.Error(), http.StatusBadRequest)
+	}
+	return nil
+}
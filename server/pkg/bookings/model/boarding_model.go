@@ -0,0 +1,44 @@
+// pkg/bookings/model/boarding_model.go
+
+package model
+
+import (
+	"time"
+)
+
+// Orders in which passengers are denied boarding when more show up than there are seats.
+const (
+	// DeniedBoardingByCheckIn bumps the last passengers to check in first
+	DeniedBoardingByCheckIn = "check_in"
+	// DeniedBoardingByFare bumps the lowest cabin and cheapest fare first
+	DeniedBoardingByFare = "fare"
+)
+
+// DeniedPassenger is a booking whose passengers would be denied boarding.
+// Parties are bumped together.
+type DeniedPassenger struct {
+	BookingID   string     `json:"booking_id"`
+	UserID      string     `json:"user_id"`
+	Passengers  int        `json:"passengers"`
+	Cabin       string     `json:"cabin,omitempty"`
+	FareCode    string     `json:"fare_code,omitempty"`
+	FarePrice   float64    `json:"fare_price"`
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+}
+
+// DeniedBoardingReport lists who gets bumped from an oversold flight.
+type DeniedBoardingReport struct {
+	FlightID     string `json:"flight_id"`
+	FlightNumber string `json:"flight_number"`
+	// Capacity is the physical seats; SellableCapacity adds the overbooking allowance
+	Capacity         int `json:"capacity"`
+	SellableCapacity int `json:"sellable_capacity"`
+	Booked           int `json:"booked"`
+	CheckedIn        int `json:"checked_in"`
+	ShowUps          int `json:"show_ups"`
+	// Excess is how many show-ups have no seat
+	Excess           int               `json:"excess"`
+	Order            string            `json:"order"`
+	Denied           []DeniedPassenger `json:"denied"`
+	DeniedPassengers int               `json:"denied_passengers"`
+}
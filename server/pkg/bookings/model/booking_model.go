@@ -4,6 +4,8 @@ package model
 
 import (
 	"time"
+
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
 )
 
 type BookingStatus string
@@ -11,44 +13,117 @@ type BookingStatus string
 const (
 	BookingStatusPending   BookingStatus = "pending"
 	BookingStatusConfirmed BookingStatus = "confirmed"
+	BookingStatusTicketed  BookingStatus = "ticketed"
 	BookingStatusCancelled BookingStatus = "cancelled"
 	BookingStatusCompleted BookingStatus = "completed"
+	BookingStatusRefunded  BookingStatus = "refunded"
+	BookingStatusExpired   BookingStatus = "expired"
 )
 
 type Booking struct {
-	ID            string        `json:"id" bson:"_id,omitempty"`
-	UserID        string        `json:"user_id" bson:"user_id" validate:"required"`
-	FlightID      string        `json:"flight_id" bson:"flight_id" validate:"required"`
-	Status        BookingStatus `json:"status" bson:"status"`
-	Passengers    int           `json:"passengers" bson:"passengers" validate:"required,min=1"`
-	TotalPrice    float64       `json:"total_price" bson:"total_price"`
-	PaymentStatus string        `json:"payment_status" bson:"payment_status"`
-	BookingDate   time.Time     `json:"booking_date" bson:"booking_date"`
-	CreatedAt     time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at" bson:"updated_at"`
+	ID            string         `json:"id" bson:"_id,omitempty"`
+	Locator       string         `json:"locator,omitempty" bson:"locator,omitempty"`
+	UserID        string         `json:"user_id" bson:"user_id" validate:"required"`
+	FlightID      string         `json:"flight_id" bson:"flight_id" validate:"required"`
+	Status        BookingStatus  `json:"status" bson:"status"`
+	Passengers    int            `json:"passengers" bson:"passengers" validate:"required,min=1"`
+	Seats         []string       `json:"seats,omitempty" bson:"seats,omitempty"`
+	Cabin         string         `json:"cabin,omitempty" bson:"cabin,omitempty"`
+	FareCode      string         `json:"fare_code,omitempty" bson:"fare_code,omitempty"`
+	FarePrice     float64        `json:"fare_price" bson:"fare_price"`
+	TotalPrice    float64        `json:"total_price" bson:"total_price"`
+	PaymentStatus string         `json:"payment_status" bson:"payment_status"`
+	PaymentID     string         `json:"payment_id,omitempty" bson:"payment_id,omitempty"`
+	BookingDate   time.Time      `json:"booking_date" bson:"booking_date"`
+	TicketedAt    *time.Time     `json:"ticketed_at,omitempty" bson:"ticketed_at,omitempty"`
+	CheckedInAt   *time.Time     `json:"checked_in_at,omitempty" bson:"checked_in_at,omitempty"`
+	Disruption    *Disruption    `json:"disruption,omitempty" bson:"disruption,omitempty"`
+	History       []BookingEvent `json:"history,omitempty" bson:"history,omitempty"`
+	CreatedAt     time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" bson:"updated_at"`
+	// FareRules are the rules of the fare when it was sold; bookings made
+	// before fares had rules follow the default rules
+	FareRules *flightmodel.FareRules `json:"fare_rules,omitempty" bson:"fare_rules,omitempty"`
+	// Fees are the change fees, and the fares kept for removed passengers,
+	// included in TotalPrice, which are never refunded
+	Fees   float64 `json:"fees,omitempty" bson:"fees,omitempty"`
+	Refund float64 `json:"refund,omitempty" bson:"refund,omitempty"`
+	// RefundID is the record of the refund owed for cancelling the booking
+	RefundID string `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
+	// Manifest names the passengers; Passengers counts those taking a seat,
+	// which leaves out infants
+	Manifest []Passenger `json:"manifest,omitempty" bson:"manifest,omitempty"`
+}
+
+// Rules returns the fare rules the booking was sold under.
+func (b *Booking) Rules() flightmodel.FareRules {
+	if b.FareRules == nil {
+		return flightmodel.DefaultFareRules()
+	}
+	return *b.FareRules
+}
+
+// Record appends an action to the booking's history.
+func (b *Booking) Record(action, flightID, note string) {
+	b.History = append(b.History, BookingEvent{
+		Action:    action,
+		FlightID:  flightID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	})
 }
 
 type CreateBookingRequest struct {
 	FlightID   string `json:"flight_id" validate:"required"`
-	Passengers int    `json:"passengers" validate:"required,min=1"`
+	Passengers int    `json:"passengers" validate:"required_without=Manifest,omitempty,min=1"`
+	Cabin      string `json:"cabin,omitempty" validate:"omitempty,oneof=economy premium business first"`
+	FareCode   string `json:"fare_code,omitempty"`
+	QuoteID    string `json:"quote_id,omitempty"`
+	// Manifest names the passengers; passengers may then be left out
+	Manifest []PassengerDetails `json:"manifest,omitempty" validate:"omitempty,dive"`
+	// HoldID books the seats of a seat hold; cabin and fare_code are then taken from the hold
+	HoldID string `json:"hold_id,omitempty"`
 }
 
 type UpdateBookingRequest struct {
 	Status     *BookingStatus `json:"status,omitempty"`
 	Passengers *int           `json:"passengers,omitempty" validate:"omitempty,min=1"`
+	// PaymentMethod pays what the change costs on a booking already paid for
+	PaymentMethod string `json:"payment_method,omitempty"`
+}
+
+// SelectSeatsRequest replaces the seats held by a booking
+type SelectSeatsRequest struct {
+	Seats []string `json:"seats" validate:"required,dive,required"`
 }
 
 type BookingResponse struct {
-	ID            string        `json:"id"`
-	UserID        string        `json:"user_id"`
-	FlightID      string        `json:"flight_id"`
-	Status        BookingStatus `json:"status"`
-	Passengers    int           `json:"passengers"`
-	TotalPrice    float64       `json:"total_price"`
-	PaymentStatus string        `json:"payment_status"`
-	BookingDate   time.Time     `json:"booking_date"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID            string         `json:"id"`
+	Locator       string         `json:"locator,omitempty"`
+	UserID        string         `json:"user_id"`
+	FlightID      string         `json:"flight_id"`
+	Status        BookingStatus  `json:"status"`
+	Passengers    int            `json:"passengers"`
+	Seats         []string       `json:"seats,omitempty"`
+	Cabin         string         `json:"cabin,omitempty"`
+	FareCode      string         `json:"fare_code,omitempty"`
+	FarePrice     float64        `json:"fare_price"`
+	TotalPrice    float64        `json:"total_price"`
+	PaymentStatus string         `json:"payment_status"`
+	PaymentID     string         `json:"payment_id,omitempty"`
+	BookingDate   time.Time      `json:"booking_date"`
+	TicketedAt    *time.Time     `json:"ticketed_at,omitempty"`
+	CheckedInAt   *time.Time     `json:"checked_in_at,omitempty"`
+	Disruption    *Disruption    `json:"disruption,omitempty"`
+	History       []BookingEvent `json:"history,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+
+	FareRules *flightmodel.FareRules `json:"fare_rules,omitempty"`
+	Fees      float64                `json:"fees,omitempty"`
+	Refund    float64                `json:"refund,omitempty"`
+	RefundID  string                 `json:"refund_id,omitempty"`
+	Manifest  []Passenger            `json:"manifest,omitempty"`
 }
 
 type SearchBookingRequest struct {
@@ -62,14 +137,30 @@ type SearchBookingRequest struct {
 func (b *Booking) ToResponse() *BookingResponse {
 	return &BookingResponse{
 		ID:            b.ID,
+		Locator:       b.Locator,
 		UserID:        b.UserID,
 		FlightID:      b.FlightID,
 		Status:        b.Status,
 		Passengers:    b.Passengers,
+		Seats:         b.Seats,
+		Cabin:         b.Cabin,
+		FareCode:      b.FareCode,
+		FarePrice:     b.FarePrice,
 		TotalPrice:    b.TotalPrice,
 		PaymentStatus: b.PaymentStatus,
+		PaymentID:     b.PaymentID,
 		BookingDate:   b.BookingDate,
+		TicketedAt:    b.TicketedAt,
+		CheckedInAt:   b.CheckedInAt,
+		Disruption:    b.Disruption,
+		History:       b.History,
 		CreatedAt:     b.CreatedAt,
 		UpdatedAt:     b.UpdatedAt,
+
+		FareRules: b.FareRules,
+		Fees:      b.Fees,
+		Refund:    b.Refund,
+		RefundID:  b.RefundID,
+		Manifest:  b.Manifest,
 	}
 }
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to BookingStatus
+		allowed  bool
+	}{
+		{BookingStatusPending, BookingStatusConfirmed, true},
+		{BookingStatusPending, BookingStatusCancelled, true},
+		{BookingStatusPending, BookingStatusExpired, true},
+		{BookingStatusPending, BookingStatusTicketed, false},
+		{BookingStatusConfirmed, BookingStatusTicketed, true},
+		{BookingStatusConfirmed, BookingStatusCancelled, true},
+		{BookingStatusConfirmed, BookingStatusExpired, false},
+		{BookingStatusTicketed, BookingStatusCompleted, true},
+		{BookingStatusTicketed, BookingStatusCancelled, true},
+		{BookingStatusCancelled, BookingStatusRefunded, true},
+		{BookingStatusCancelled, BookingStatusConfirmed, false},
+		{BookingStatusCompleted, BookingStatusCancelled, false},
+		{BookingStatusRefunded, BookingStatusCancelled, false},
+		{BookingStatusExpired, BookingStatusPending, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.allowed)
+		}
+	}
+}
+
+func TestRefundPolicyShare(t *testing.T) {
+	policy := RefundPolicy{Tiers: []RefundTier{
+		{HoursBeforeDeparture: 24, Share: 0.5},
+		{HoursBeforeDeparture: 168, Share: 1},
+		{HoursBeforeDeparture: 2, Share: 0.25},
+	}}
+	departure := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy RefundPolicy
+		before time.Duration
+		share  float64
+	}{
+		{"a week or more ahead", policy, 200 * time.Hour, 1},
+		{"exactly a week ahead", policy, 168 * time.Hour, 1},
+		{"within the week", policy, 100 * time.Hour, 0.5},
+		{"within the day", policy, 5 * time.Hour, 0.25},
+		{"before the earliest tier", policy, time.Hour, 0},
+		{"after departure", policy, -time.Hour, 0},
+		{"default policy up to departure", DefaultRefundPolicy(), time.Minute, 1},
+		{"default policy after departure", DefaultRefundPolicy(), 0, 0},
+		{"no tiers", RefundPolicy{}, 200 * time.Hour, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Share(departure, departure.Add(-tt.before)); got != tt.share {
+			t.Errorf("%s: got share %v, want %v", tt.name, got, tt.share)
+		}
+	}
+
+	if !policy.IsValid() || (RefundPolicy{Tiers: []RefundTier{{Share: 1.5}}}).IsValid() {
+		t.Fatal("expected shares outside 0 to 1 to be invalid")
+	}
+}
+
+func TestQuoteCancellation(t *testing.T) {
+	rules := func(refundable bool, fee float64) *flightmodel.FareRules {
+		return &flightmodel.FareRules{Refundable: refundable, CancellationFee: fee}
+	}
+	tests := []struct {
+		name      string
+		booking   Booking
+		share     float64
+		refund    float64
+		fee       float64
+		forfeited float64
+	}{
+		{
+			name:    "unpaid",
+			booking: Booking{Status: BookingStatusPending, PaymentStatus: PaymentStatusPending, Passengers: 2, TotalPrice: 200},
+			share:   1,
+		},
+		{
+			name:    "paid without fare rules",
+			booking: Booking{Status: BookingStatusConfirmed, PaymentStatus: PaymentStatusPaid, Passengers: 2, TotalPrice: 200},
+			share:   1, refund: 200,
+		},
+		{
+			name: "fee per passenger and fees kept",
+			booking: Booking{Status: BookingStatusConfirmed, PaymentStatus: PaymentStatusPaid, Passengers: 2, TotalPrice: 220,
+				Fees: 20, FareRules: rules(true, 10)},
+			share: 1, refund: 180, fee: 20,
+		},
+		{
+			name: "half share close to departure",
+			booking: Booking{Status: BookingStatusConfirmed, PaymentStatus: PaymentStatusPaid, Passengers: 2, TotalPrice: 200,
+				FareRules: rules(true, 10)},
+			share: 0.5, refund: 90, fee: 20, forfeited: 90,
+		},
+		{
+			name: "fee larger than the fare",
+			booking: Booking{Status: BookingStatusConfirmed, PaymentStatus: PaymentStatusPaid, Passengers: 1, TotalPrice: 50,
+				FareRules: rules(true, 80)},
+			share: 1, fee: 50,
+		},
+		{
+			name: "non-refundable",
+			booking: Booking{Status: BookingStatusConfirmed, PaymentStatus: PaymentStatusPaid, Passengers: 1, TotalPrice: 100,
+				FareRules: rules(false, 0)},
+			share: 1,
+		},
+		{
+			name: "already cancelled reports the refund given",
+			booking: Booking{Status: BookingStatusCancelled, PaymentStatus: PaymentStatusRefundPending, Passengers: 2, TotalPrice: 200,
+				Refund: 90, FareRules: rules(true, 10)},
+			share: 1, refund: 90, fee: 20, forfeited: 90,
+		},
+	}
+	for _, tt := range tests {
+		quote := tt.booking.QuoteCancellation(tt.share)
+		if quote.Refund != tt.refund || quote.CancellationFee != tt.fee || quote.Forfeited != tt.forfeited {
+			t.Errorf("%s: got refund %.2f, fee %.2f, forfeited %.2f, want %.2f, %.2f, %.2f", tt.name,
+				quote.Refund, quote.CancellationFee, quote.Forfeited, tt.refund, tt.fee, tt.forfeited)
+		}
+	}
+}
+
+func TestQuoteRemoval(t *testing.T) {
+	deadline := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+	adult := &Passenger{ID: "p1", PassengerDetails: PassengerDetails{Type: PassengerAdult}, FarePrice: 100}
+	infant := &Passenger{ID: "p2", PassengerDetails: PassengerDetails{Type: PassengerInfant}, FarePrice: 10}
+
+	tests := []struct {
+		name       string
+		rules      *flightmodel.FareRules
+		passenger  *Passenger
+		share      float64
+		passengers int
+		changeFee  float64
+		refund     float64
+		retained   float64
+		total      float64
+	}{
+		{"refundable adult", nil, adult, 1, 2, 0, 100, 0, 200},
+		{"fees and half share", &flightmodel.FareRules{Refundable: true, CancellationFee: 20, ChangeFee: 5}, adult, 0.5, 2, 10, 40, 60, 270},
+		{"non-refundable", &flightmodel.FareRules{ChangeFee: 5}, adult, 1, 2, 10, 0, 100, 310},
+		{"infant frees no seat", &flightmodel.FareRules{Refundable: true, ChangeFee: 5}, infant, 1, 3, 15, 10, 0, 305},
+	}
+	for _, tt := range tests {
+		booking := &Booking{Passengers: 3, TotalPrice: 300, FareRules: tt.rules}
+		quote := booking.QuoteRemoval(tt.passenger, deadline, tt.share)
+		if quote.Passengers != tt.passengers || quote.ChangeFee != tt.changeFee || quote.Refund != tt.refund ||
+			quote.Retained != tt.retained || quote.TotalPrice != tt.total {
+			t.Errorf("%s: got %+v", tt.name, quote)
+		}
+		if quote.AmountDue != quote.ChangeFee || !quote.ChangeDeadline.Equal(deadline) {
+			t.Errorf("%s: expected the change fee due by the deadline, got %+v", tt.name, quote)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// pkg/bookings/model/disruption_model.go
+
+package model
+
+import (
+	"time"
+)
+
+// Actions recorded in a booking's history.
+const (
+	ActionDisrupted        = "disrupted"
+	ActionRebooked         = "rebooked"
+	ActionRebookingOffered = "rebooking_offered"
+	ActionRefundOffered    = "refund_offered"
+	ActionRebookAccepted   = "rebooking_accepted"
+	ActionRefundAccepted   = "refund_accepted"
+	ActionNotified         = "notified"
+	ActionCheckedIn        = "checked_in"
+	ActionChanged          = "changed"
+	ActionCancelled        = "cancelled"
+	ActionPassengerUpdated = "passenger_updated"
+	ActionPassengerRemoved = "passenger_removed"
+	ActionConfirmed        = "confirmed"
+	ActionTicketed         = "ticketed"
+	ActionCompleted        = "completed"
+	ActionRefunded         = "refunded"
+	ActionExpired          = "expired"
+	ActionPaymentDeclined  = "payment_declined"
+)
+
+// PaymentStatusRefundPending marks a booking whose payment is owed back to the passenger.
+const PaymentStatusRefundPending = "refund_pending"
+
+type DisruptionStatus string
+
+const (
+	// DisruptionOffered waits for the passenger to take the rebooking or refund on offer
+	DisruptionOffered  DisruptionStatus = "offered"
+	DisruptionRebooked DisruptionStatus = "rebooked"
+	DisruptionRefunded DisruptionStatus = "refunded"
+)
+
+// BookingEvent is one entry in the history of a booking.
+type BookingEvent struct {
+	Action    string    `json:"action" bson:"action"`
+	FlightID  string    `json:"flight_id,omitempty" bson:"flight_id,omitempty"`
+	Note      string    `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Disruption describes a cancellation or heavy delay of a booked flight and
+// what has been done or offered about it. A refund stays on offer until the
+// passenger takes one of the options.
+type Disruption struct {
+	FlightID            string           `json:"flight_id" bson:"flight_id"`
+	Reason              string           `json:"reason" bson:"reason"`
+	Status              DisruptionStatus `json:"status" bson:"status"`
+	AlternativeFlightID string           `json:"alternative_flight_id,omitempty" bson:"alternative_flight_id,omitempty"`
+	CreatedAt           time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at" bson:"updated_at"`
+}
+
+// DisruptionPolicy decides how disrupted bookings are handled. Delays shorter
+// than DelayThreshold are left alone, and alternatives are looked for up to
+// SearchWindow after the disrupted departure.
+type DisruptionPolicy struct {
+	AutoRebook     bool          `yaml:"autoRebook"`
+	DelayThreshold time.Duration `yaml:"delayThreshold"`
+	SearchWindow   time.Duration `yaml:"searchWindow"`
+}
+
+// DefaultDisruptionPolicy offers rebooking rather than applying it, for delays of three hours or more.
+func DefaultDisruptionPolicy() DisruptionPolicy {
+	return DisruptionPolicy{
+		DelayThreshold: 3 * time.Hour,
+		SearchWindow:   48 * time.Hour,
+	}
+}
+
+// Notification is a message to the passenger holding a booking.
+type Notification struct {
+	UserID    string
+	BookingID string
+	Subject   string
+	Message   string
+}
+
+// DisruptionReport summarizes how the bookings on a disrupted flight were handled.
+type DisruptionReport struct {
+	FlightID string `json:"flight_id"`
+	Reason   string `json:"reason"`
+	Bookings int    `json:"bookings"`
+	Rebooked int    `json:"rebooked"`
+	Offered  int    `json:"offered"`
+	// RefundOnly counts bookings with no alternative flight to offer
+	RefundOnly int `json:"refund_only"`
+	Failed     int `json:"failed"`
+}
@@ -0,0 +1,128 @@
+// pkg/bookings/model/fare_rules_model.go
+
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// CancellationQuote breaks down what a passenger gets back for cancelling a booking.
+type CancellationQuote struct {
+	BookingID  string  `json:"booking_id"`
+	Refundable bool    `json:"refundable"`
+	Paid       float64 `json:"paid"`
+	// Fees are change fees already paid, which are kept
+	Fees            float64 `json:"fees"`
+	CancellationFee float64 `json:"cancellation_fee"`
+	// Forfeited is the part of the refund lost to cancelling close to departure
+	Forfeited float64 `json:"forfeited"`
+	Refund    float64 `json:"refund"`
+}
+
+// ChangeQuote breaks down what a change to the passengers on a booking costs.
+// Added passengers pay the booking's fare; removed passengers are refunded as
+// if cancelled, less the cancellation fee.
+type ChangeQuote struct {
+	BookingID      string    `json:"booking_id"`
+	Passengers     int       `json:"passengers"`
+	ChangeDeadline time.Time `json:"change_deadline"`
+	ChangeFee      float64   `json:"change_fee"`
+	FareDifference float64   `json:"fare_difference"`
+	Refund         float64   `json:"refund"`
+	// Retained is the part of removed passengers' fares kept under the fare
+	// rules, which is never refunded
+	Retained  float64 `json:"retained"`
+	AmountDue float64 `json:"amount_due"`
+	// TotalPrice is the booking's total price after the change
+	TotalPrice float64 `json:"total_price"`
+}
+
+// QuoteCancellation works out the refund for cancelling the booking under its
+// fare rules, giving back share of what the rules refund. Nothing is refunded
+// when nothing was paid. For a booking no longer active it reports the refund
+// given.
+func (b *Booking) QuoteCancellation(share float64) *CancellationQuote {
+	rules := b.Rules()
+	quote := &CancellationQuote{
+		BookingID:  b.ID,
+		Refundable: rules.Refundable,
+	}
+	if !b.PaymentTaken() {
+		return quote
+	}
+	quote.Paid = b.TotalPrice
+	quote.Fees = b.Fees
+	refundable := math.Max(0, b.TotalPrice-b.Fees)
+	if !IsActive(b.Status) {
+		quote.Refund = b.Refund
+		if rules.Refundable && (b.Status == BookingStatusCancelled || b.Status == BookingStatusRefunded) {
+			kept := math.Max(0, refundable-b.Refund)
+			quote.CancellationFee = math.Min(kept, rules.CancellationFee*float64(b.Passengers))
+			quote.Forfeited = cents(kept - quote.CancellationFee)
+		}
+		return quote
+	}
+	if rules.Refundable {
+		quote.CancellationFee = math.Min(refundable, rules.CancellationFee*float64(b.Passengers))
+		quote.Refund = cents((refundable - quote.CancellationFee) * share)
+		quote.Forfeited = cents(refundable - quote.CancellationFee - quote.Refund)
+	}
+	return quote
+}
+
+// QuoteChange works out the cost of changing the booking to the given number
+// of passengers at farePrice each, giving back share of what the rules refund
+// for removed passengers. The change fee is charged per passenger travelling
+// after the change.
+func (b *Booking) QuoteChange(passengers int, farePrice float64, deadline time.Time, share float64) *ChangeQuote {
+	rules := b.Rules()
+	quote := &ChangeQuote{
+		BookingID:      b.ID,
+		Passengers:     passengers,
+		ChangeDeadline: deadline,
+		TotalPrice:     b.TotalPrice,
+	}
+	diff := passengers - b.Passengers
+	if diff == 0 {
+		return quote
+	}
+
+	quote.ChangeFee = rules.ChangeFee * float64(passengers)
+	if diff > 0 {
+		quote.FareDifference = float64(diff) * farePrice
+	} else {
+		if rules.Refundable {
+			quote.Refund = cents(float64(-diff) * math.Max(0, farePrice-rules.CancellationFee) * share)
+		}
+		quote.Retained = cents(float64(-diff)*farePrice - quote.Refund)
+	}
+	quote.AmountDue = quote.ChangeFee + quote.FareDifference
+	quote.TotalPrice = b.TotalPrice + quote.AmountDue - quote.Refund
+	return quote
+}
+
+// QuoteRemoval works out the cost of taking a named passenger off the booking,
+// who is refunded their fare as if cancelled, less the cancellation fee, and
+// share of that as QuoteChange does. Infants free no seat, so the change fee
+// is still charged for every seated passenger.
+func (b *Booking) QuoteRemoval(passenger *Passenger, deadline time.Time, share float64) *ChangeQuote {
+	rules := b.Rules()
+	passengers := b.Passengers
+	if passenger.Seated() {
+		passengers--
+	}
+	quote := &ChangeQuote{
+		BookingID:      b.ID,
+		Passengers:     passengers,
+		ChangeDeadline: deadline,
+		ChangeFee:      rules.ChangeFee * float64(passengers),
+	}
+	if rules.Refundable {
+		quote.Refund = cents(math.Max(0, passenger.FarePrice-rules.CancellationFee) * share)
+	}
+	quote.Retained = cents(passenger.FarePrice - quote.Refund)
+	quote.AmountDue = quote.ChangeFee
+	quote.TotalPrice = b.TotalPrice + quote.AmountDue - quote.Refund
+	return quote
+}
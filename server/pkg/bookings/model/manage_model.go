@@ -0,0 +1,89 @@
+// pkg/bookings/model/manage_model.go
+
+package model
+
+import (
+	"time"
+
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	// LocatorLength is the number of characters in a record locator
+	LocatorLength = 6
+	// LocatorAlphabet leaves out 0, 1, I and O, which are easily confused when read out
+	LocatorAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// ManageBookingRequest identifies a booking to a traveller without an
+// account by its record locator and the last name of a passenger on it.
+type ManageBookingRequest struct {
+	Locator  string `json:"locator" validate:"required,len=6,alphanum"`
+	LastName string `json:"last_name" validate:"required,person_name"`
+}
+
+// GuestContactRequest adds contact details to a passenger found by record
+// locator. Without a passenger ID they go to the passenger whose last name
+// was given.
+type GuestContactRequest struct {
+	ManageBookingRequest
+	PassengerID string         `json:"passenger_id,omitempty"`
+	Contact     ContactDetails `json:"contact"`
+}
+
+// GuestPassenger is a passenger as shown to a traveller managing a booking
+// by record locator, without their travel document or date of birth.
+type GuestPassenger struct {
+	ID         string        `json:"id"`
+	Type       PassengerType `json:"type"`
+	FirstName  string        `json:"first_name"`
+	LastName   string        `json:"last_name"`
+	HasContact bool          `json:"has_contact"`
+}
+
+// GuestBooking is the part of a booking shown to a traveller managing it by
+// record locator.
+type GuestBooking struct {
+	Locator       string                 `json:"locator"`
+	FlightID      string                 `json:"flight_id"`
+	Status        BookingStatus          `json:"status"`
+	Passengers    int                    `json:"passengers"`
+	Manifest      []GuestPassenger       `json:"manifest"`
+	Seats         []string               `json:"seats,omitempty"`
+	Cabin         string                 `json:"cabin,omitempty"`
+	TotalPrice    float64                `json:"total_price"`
+	PaymentStatus string                 `json:"payment_status"`
+	CheckedInAt   *time.Time             `json:"checked_in_at,omitempty"`
+	Disruption    *Disruption            `json:"disruption,omitempty"`
+	FareRules     *flightmodel.FareRules `json:"fare_rules,omitempty"`
+	Refund        float64                `json:"refund,omitempty"`
+}
+
+// ToGuest returns the part of the booking a traveller managing it by record locator may see.
+func (b *Booking) ToGuest() *GuestBooking {
+	manifest := make([]GuestPassenger, len(b.Manifest))
+	for i, passenger := range b.Manifest {
+		manifest[i] = GuestPassenger{
+			ID:         passenger.ID,
+			Type:       passenger.Type,
+			FirstName:  passenger.FirstName,
+			LastName:   passenger.LastName,
+			HasContact: passenger.Contact != nil,
+		}
+	}
+	return &GuestBooking{
+		Locator:       b.Locator,
+		FlightID:      b.FlightID,
+		Status:        b.Status,
+		Passengers:    b.Passengers,
+		Manifest:      manifest,
+		Seats:         b.Seats,
+		Cabin:         b.Cabin,
+		TotalPrice:    b.TotalPrice,
+		PaymentStatus: b.PaymentStatus,
+		CheckedInAt:   b.CheckedInAt,
+		Disruption:    b.Disruption,
+		FareRules:     b.FareRules,
+		Refund:        b.Refund,
+	}
+}
@@ -0,0 +1,115 @@
+// pkg/bookings/model/passenger_model.go
+
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type PassengerType string
+
+const (
+	PassengerAdult  PassengerType = "adult"
+	PassengerChild  PassengerType = "child"
+	PassengerInfant PassengerType = "infant"
+
+	// Ages on the day of departure at which passengers stop being infants and children
+	InfantMaxAge = 2
+	ChildMaxAge  = 12
+)
+
+// PassengerTypeForAge returns the type a passenger of the given age travels as.
+func PassengerTypeForAge(age int) PassengerType {
+	switch {
+	case age < InfantMaxAge:
+		return PassengerInfant
+	case age < ChildMaxAge:
+		return PassengerChild
+	}
+	return PassengerAdult
+}
+
+// TravelDocument is the passport or identity card a passenger travels on.
+type TravelDocument struct {
+	Type           string    `json:"type" bson:"type" validate:"required,oneof=passport national_id"`
+	Number         string    `json:"number" bson:"number" validate:"required,document_number"`
+	IssuingCountry string    `json:"issuing_country" bson:"issuing_country" validate:"required,iso3166_1_alpha2"`
+	ExpiresAt      time.Time `json:"expires_at" bson:"expires_at" validate:"required,future"`
+}
+
+// ContactDetails reach a passenger about their booking.
+type ContactDetails struct {
+	Email string `json:"email,omitempty" bson:"email,omitempty" validate:"omitempty,email"`
+	Phone string `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,phone"`
+}
+
+// PassengerDetails are the details a customer gives for each passenger.
+type PassengerDetails struct {
+	Type        PassengerType   `json:"type" bson:"type" validate:"required,oneof=adult child infant"`
+	FirstName   string          `json:"first_name" bson:"first_name" validate:"required,person_name"`
+	LastName    string          `json:"last_name" bson:"last_name" validate:"required,person_name"`
+	DateOfBirth time.Time       `json:"date_of_birth" bson:"date_of_birth" validate:"required,past"`
+	Nationality string          `json:"nationality" bson:"nationality" validate:"required,iso3166_1_alpha2"`
+	Document    TravelDocument  `json:"document" bson:"document"`
+	Contact     *ContactDetails `json:"contact,omitempty" bson:"contact,omitempty"`
+}
+
+// AgeAt returns the passenger's age in whole years on the given day.
+func (d *PassengerDetails) AgeAt(at time.Time) int {
+	born := d.DateOfBirth
+	age := at.Year() - born.Year()
+	if at.Month() < born.Month() || (at.Month() == born.Month() && at.Day() < born.Day()) {
+		age--
+	}
+	return age
+}
+
+// Seated reports whether the passenger takes a seat; infants travel on an adult's lap.
+func (d *PassengerDetails) Seated() bool {
+	return d.Type != PassengerInfant
+}
+
+// Passenger is a named passenger on a booking and the fare they paid.
+type Passenger struct {
+	ID               string `json:"id" bson:"id"`
+	PassengerDetails `bson:",inline"`
+	FarePrice        float64 `json:"fare_price" bson:"fare_price"`
+}
+
+// UpdatePassengerRequest changes the details of one passenger. The passenger
+// type follows from the date of birth and cannot be changed.
+type UpdatePassengerRequest struct {
+	FirstName   *string         `json:"first_name,omitempty" validate:"omitempty,person_name"`
+	LastName    *string         `json:"last_name,omitempty" validate:"omitempty,person_name"`
+	DateOfBirth *time.Time      `json:"date_of_birth,omitempty" validate:"omitempty,past"`
+	Nationality *string         `json:"nationality,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	Document    *TravelDocument `json:"document,omitempty"`
+	Contact     *ContactDetails `json:"contact,omitempty"`
+}
+
+// PassengerPricing sets the share of the adult fare children and infants pay.
+type PassengerPricing struct {
+	Child  float64 `yaml:"child"`
+	Infant float64 `yaml:"infant"`
+}
+
+// DefaultPassengerPricing charges children 75% and infants 10% of the adult fare.
+func DefaultPassengerPricing() PassengerPricing {
+	return PassengerPricing{
+		Child:  0.75,
+		Infant: 0.1,
+	}
+}
+
+// Price returns what a passenger of the given type pays for an adult fare.
+func (p PassengerPricing) Price(passengerType PassengerType, fare float64) float64 {
+	share := 1.0
+	switch passengerType {
+	case PassengerChild:
+		share = p.Child
+	case PassengerInfant:
+		share = p.Infant
+	}
+	return math.Round(fare*share*100) / 100
+}
@@ -0,0 +1,98 @@
+// pkg/bookings/model/payment_model.go
+
+package model
+
+import (
+	"time"
+)
+
+// Payment statuses of a booking while its payment is being taken.
+const (
+	// PaymentStatusProcessing bookings are being paid for and cannot be paid again meanwhile
+	PaymentStatusProcessing     = "processing"
+	PaymentStatusRequiresAction = "requires_action"
+	PaymentStatusDeclined       = "declined"
+	PaymentStatusVoided         = "voided"
+)
+
+// PaymentState is where a payment stands at the payment processor.
+type PaymentState string
+
+const (
+	// PaymentRequiresAction payments wait for the customer to pass a 3-D Secure challenge
+	PaymentRequiresAction PaymentState = "requires_action"
+	PaymentAuthorized     PaymentState = "authorized"
+	PaymentCaptured       PaymentState = "captured"
+	PaymentDeclined       PaymentState = "declined"
+	PaymentVoided         PaymentState = "voided"
+	PaymentRefunded       PaymentState = "refunded"
+)
+
+// PaymentSignatureHeader carries the signature of a payment processor's webhook.
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// Types of the events a payment processor sends to the webhook.
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventDeclined   = "payment.declined"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventVoided     = "payment.voided"
+	PaymentEventRefunded   = "payment.refunded"
+)
+
+// Payment is one attempt to pay for a booking.
+type Payment struct {
+	ID        string `json:"id" bson:"_id"`
+	BookingID string `json:"booking_id" bson:"booking_id"`
+	UserID    string `json:"user_id" bson:"user_id"`
+	// Reference identifies the payment at the payment processor
+	Reference   string       `json:"reference" bson:"reference"`
+	Amount      float64      `json:"amount" bson:"amount"`
+	Status      PaymentState `json:"status" bson:"status"`
+	DeclineCode string       `json:"decline_code,omitempty" bson:"decline_code,omitempty"`
+	// ActionURL is where the customer completes a 3-D Secure challenge
+	ActionURL string    `json:"action_url,omitempty" bson:"action_url,omitempty"`
+	Refunded  float64   `json:"refunded,omitempty" bson:"refunded,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// PayBookingRequest pays for a booking with a payment method tokenized by the processor.
+type PayBookingRequest struct {
+	PaymentMethod string `json:"payment_method" validate:"required"`
+}
+
+// PaymentResponse is a payment and the booking it paid for.
+type PaymentResponse struct {
+	Payment *Payment         `json:"payment"`
+	Booking *BookingResponse `json:"booking"`
+}
+
+// ChallengeRequest completes a simulated 3-D Secure challenge.
+type ChallengeRequest struct {
+	Approve bool `json:"approve"`
+}
+
+// AuthorizationRequest asks the payment processor to hold an amount on a payment method.
+type AuthorizationRequest struct {
+	PaymentID     string
+	Amount        float64
+	PaymentMethod string
+}
+
+// GatewayResult is the payment processor's answer to a request.
+type GatewayResult struct {
+	Reference   string
+	Status      PaymentState
+	DeclineCode string
+	ActionURL   string
+}
+
+// PaymentEvent is a change to a payment reported by the payment processor.
+type PaymentEvent struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	Reference   string  `json:"reference"`
+	Amount      float64 `json:"amount"`
+	DeclineCode string  `json:"decline_code,omitempty"`
+}
@@ -0,0 +1,99 @@
+// pkg/bookings/model/refund_model.go
+
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Reasons a refund is given.
+const (
+	RefundReasonCancellation     = "cancellation"
+	RefundReasonPassengerRemoved = "passenger_removed"
+	RefundReasonDisruption       = "disruption"
+)
+
+type RefundStatus string
+
+const (
+	// RefundPending refunds are owed but not yet paid out
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	// RefundFailed refunds were turned down by the payment processor and are still owed
+	RefundFailed RefundStatus = "failed"
+)
+
+// Refund is money owed back on a booking, paid back to the payment it was
+// taken with.
+type Refund struct {
+	ID        string `json:"id" bson:"_id"`
+	BookingID string `json:"booking_id" bson:"booking_id"`
+	UserID    string `json:"user_id" bson:"user_id"`
+	// PaymentID is the payment refunded; bookings paid outside the payment
+	// processor have none and are refunded by hand
+	PaymentID string `json:"payment_id,omitempty" bson:"payment_id,omitempty"`
+	// PassengerIDs are the passengers taken off the booking for a partial refund
+	PassengerIDs []string `json:"passenger_ids,omitempty" bson:"passenger_ids,omitempty"`
+	Reason       string   `json:"reason" bson:"reason"`
+	Amount       float64  `json:"amount" bson:"amount"`
+	// Paid is the part of the amount paid back so far, which falls short when
+	// the processor turns down one of the payments it is spread over
+	Paid       float64      `json:"paid,omitempty" bson:"paid,omitempty"`
+	Status     RefundStatus `json:"status" bson:"status"`
+	CreatedAt  time.Time    `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" bson:"updated_at"`
+	RefundedAt *time.Time   `json:"refunded_at,omitempty" bson:"refunded_at,omitempty"`
+}
+
+// RefundTier gives back Share of the refundable amount to bookings cancelled
+// at least HoursBeforeDeparture before the flight leaves.
+type RefundTier struct {
+	HoursBeforeDeparture int     `json:"hours_before_departure" yaml:"hoursBeforeDeparture"`
+	Share                float64 `json:"share" yaml:"share"`
+}
+
+// RefundPolicy scales refunds on refundable fares by how long before
+// departure a booking is cancelled or passengers are removed. The tier with
+// the most hours still ahead of departure applies; nothing is refunded once
+// the flight has left or before the earliest tier.
+type RefundPolicy struct {
+	Tiers []RefundTier `yaml:"tiers"`
+}
+
+// DefaultRefundPolicy refunds in full up to departure.
+func DefaultRefundPolicy() RefundPolicy {
+	return RefundPolicy{Tiers: []RefundTier{{HoursBeforeDeparture: 0, Share: 1}}}
+}
+
+// Share returns the share of the refundable amount given back at now for a
+// flight departing at departure.
+func (p RefundPolicy) Share(departure, now time.Time) float64 {
+	if !now.Before(departure) {
+		return 0
+	}
+	hours := departure.Sub(now).Hours()
+	share, best := 0.0, -1
+	for _, tier := range p.Tiers {
+		if hours >= float64(tier.HoursBeforeDeparture) && tier.HoursBeforeDeparture > best {
+			share, best = tier.Share, tier.HoursBeforeDeparture
+		}
+	}
+	return share
+}
+
+// IsValid reports whether every tier has non-negative hours and a share
+// between none and all.
+func (p RefundPolicy) IsValid() bool {
+	for _, tier := range p.Tiers {
+		if tier.HoursBeforeDeparture < 0 || tier.Share < 0 || tier.Share > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// cents rounds an amount to the cent
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
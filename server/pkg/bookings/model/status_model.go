@@ -0,0 +1,54 @@
+// pkg/bookings/model/status_model.go
+
+package model
+
+// Payment statuses of a booking, besides PaymentStatusRefundPending.
+const (
+	PaymentStatusPending  = "pending"
+	PaymentStatusPaid     = "paid"
+	PaymentStatusRefunded = "refunded"
+)
+
+// PaymentTaken reports whether money was taken for the booking, whether or
+// not it has since been given back.
+func (b *Booking) PaymentTaken() bool {
+	switch b.PaymentStatus {
+	case PaymentStatusPaid, PaymentStatusRefundPending, PaymentStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// bookingTransitions lists the statuses each booking status may move to.
+// Completed, refunded and expired are terminal; cancelled bookings only move
+// on once their refund is paid.
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingStatusPending:   {BookingStatusConfirmed, BookingStatusCancelled, BookingStatusExpired},
+	BookingStatusConfirmed: {BookingStatusTicketed, BookingStatusCancelled},
+	BookingStatusTicketed:  {BookingStatusCompleted, BookingStatusCancelled},
+	BookingStatusCancelled: {BookingStatusRefunded},
+}
+
+// ActiveStatuses are the statuses under which a booking still holds its seats
+// and can be changed.
+var ActiveStatuses = []BookingStatus{BookingStatusPending, BookingStatusConfirmed, BookingStatusTicketed}
+
+// IsActive reports whether a booking in the given status still holds its seats.
+func IsActive(status BookingStatus) bool {
+	for _, active := range ActiveStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition reports whether a booking may move from one status to another.
+func CanTransition(from, to BookingStatus) bool {
+	for _, next := range bookingTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -29,6 +30,21 @@ func (r *MongoBookingRepository) Create(ctx context.Context, booking *model.Book
 	booking.UpdatedAt = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, booking)
+	if mongo.IsDuplicateKeyError(err) {
+		return common.ErrDuplicateLocator
+	}
+	return err
+}
+
+// EnsureIndexes makes record locators unique. Bookings made before locators
+// were introduced have none and are left out of the index.
+func (r *MongoBookingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "locator", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"locator": bson.M{"$exists": true}}),
+	})
 	return err
 }
 
@@ -41,6 +57,15 @@ func (r *MongoBookingRepository) FindByID(ctx context.Context, id string) (*mode
 	return &booking, err
 }
 
+func (r *MongoBookingRepository) FindByLocator(ctx context.Context, locator string) (*model.Booking, error) {
+	var booking model.Booking
+	err := r.collection.FindOne(ctx, bson.M{"locator": locator}).Decode(&booking)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &booking, err
+}
+
 func (r *MongoBookingRepository) Update(ctx context.Context, booking *model.Booking) error {
 	booking.UpdatedAt = time.Now()
 
@@ -52,6 +77,35 @@ func (r *MongoBookingRepository) Update(ctx context.Context, booking *model.Book
 	return err
 }
 
+func (r *MongoBookingRepository) Replace(ctx context.Context, booking *model.Booking, from model.BookingStatus) (bool, error) {
+	booking.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": booking.ID, "status": from}, booking)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoBookingRepository) ClaimPayment(ctx context.Context, id, paymentID string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{
+			"_id":            id,
+			"status":         model.BookingStatusPending,
+			"payment_status": bson.M{"$nin": []string{model.PaymentStatusProcessing, model.PaymentStatusRequiresAction}},
+		},
+		bson.M{"$set": bson.M{
+			"payment_status": model.PaymentStatusProcessing,
+			"payment_id":     paymentID,
+			"updated_at":     time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
 func (r *MongoBookingRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
@@ -0,0 +1,64 @@
+// pkg/bookings/repository/mongodb/payment_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPaymentRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPaymentRepository(db *mongo.Database) *MongoPaymentRepository {
+	return &MongoPaymentRepository{
+		collection: db.Collection("payments"),
+	}
+}
+
+func (r *MongoPaymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	_, err := r.collection.InsertOne(ctx, payment)
+	return err
+}
+
+func (r *MongoPaymentRepository) FindByID(ctx context.Context, id string) (*model.Payment, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoPaymentRepository) FindByReference(ctx context.Context, reference string) (*model.Payment, error) {
+	return r.findOne(ctx, bson.M{"reference": reference})
+}
+
+func (r *MongoPaymentRepository) Update(ctx context.Context, payment *model.Payment) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": payment.ID}, payment)
+	return err
+}
+
+func (r *MongoPaymentRepository) ListByBooking(ctx context.Context, bookingID string) ([]*model.Payment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"booking_id": bookingID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []*model.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *MongoPaymentRepository) findOne(ctx context.Context, filter bson.M) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.collection.FindOne(ctx, filter).Decode(&payment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &payment, err
+}
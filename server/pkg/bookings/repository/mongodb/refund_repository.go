@@ -0,0 +1,60 @@
+// pkg/bookings/repository/mongodb/refund_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoRefundRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRefundRepository(db *mongo.Database) *MongoRefundRepository {
+	return &MongoRefundRepository{
+		collection: db.Collection("refunds"),
+	}
+}
+
+func (r *MongoRefundRepository) Create(ctx context.Context, refund *model.Refund) error {
+	_, err := r.collection.InsertOne(ctx, refund)
+	return err
+}
+
+func (r *MongoRefundRepository) FindByID(ctx context.Context, id string) (*model.Refund, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoRefundRepository) Update(ctx context.Context, refund *model.Refund) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": refund.ID}, refund)
+	return err
+}
+
+func (r *MongoRefundRepository) ListByBooking(ctx context.Context, bookingID string) ([]*model.Refund, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"booking_id": bookingID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	refunds := []*model.Refund{}
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *MongoRefundRepository) findOne(ctx context.Context, filter bson.M) (*model.Refund, error) {
+	var refund model.Refund
+	err := r.collection.FindOne(ctx, filter).Decode(&refund)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &refund, err
+}
@@ -0,0 +1,147 @@
+// pkg/bookings/service/boarding_service.go
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgInvalidBoardingOrder = "Denied boarding order must be check_in or fare"
+	errMsgFailedToListBookings = "Failed to fetch bookings for flight"
+)
+
+// cabinRank orders cabins from lowest to highest
+var cabinRank = map[flightmodel.CabinClass]int{
+	flightmodel.CabinEconomy:  0,
+	flightmodel.CabinPremium:  1,
+	flightmodel.CabinBusiness: 2,
+	flightmodel.CabinFirst:    3,
+}
+
+// CheckIn records that the passengers on a booking have checked in
+func (s *BookingService) CheckIn(ctx context.Context, userID, id string) (*model.BookingResponse, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !model.IsActive(booking.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+	if booking.CheckedInAt != nil {
+		return booking.ToResponse(), nil
+	}
+
+	now := time.Now()
+	booking.CheckedInAt = &now
+	booking.Record(model.ActionCheckedIn, booking.FlightID, "")
+	booking.UpdatedAt = now
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+	s.cache.Del(ctx, cacheKeyPrefix+id)
+	return booking.ToResponse(), nil
+}
+
+// DeniedBoarding lists the bookings that would be bumped from a flight when
+// more passengers show up than it has physical seats. Without a showUps
+// forecast the passengers checked in so far are taken to be the show-ups.
+func (s *BookingService) DeniedBoarding(ctx context.Context, flightID string, showUps int, order string) (*model.DeniedBoardingReport, error) {
+	if order == "" {
+		order = model.DeniedBoardingByCheckIn
+	}
+	if order != model.DeniedBoardingByCheckIn && order != model.DeniedBoardingByFare {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidBoardingOrder, http.StatusBadRequest)
+	}
+	flight, err := s.flightService.GetFlight(ctx, flightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	bookings, err := s.repo.GetFlightBookings(ctx, flightID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToListBookings, http.StatusInternalServerError)
+	}
+
+	report := &model.DeniedBoardingReport{
+		FlightID:         flight.ID,
+		FlightNumber:     flight.FlightNumber,
+		Capacity:         flight.Capacity,
+		SellableCapacity: flight.SellableCapacity(),
+		Order:            order,
+		Denied:           []model.DeniedPassenger{},
+	}
+
+	var active, checkedIn []*model.Booking
+	for _, booking := range bookings {
+		if !model.IsActive(booking.Status) {
+			continue
+		}
+		active = append(active, booking)
+		report.Booked += booking.Passengers
+		if booking.CheckedInAt != nil {
+			checkedIn = append(checkedIn, booking)
+			report.CheckedIn += booking.Passengers
+		}
+	}
+
+	// Only passengers who checked in are at the gate unless a forecast says otherwise
+	showing := active
+	if showUps <= 0 {
+		showing = checkedIn
+		showUps = report.CheckedIn
+	}
+	report.ShowUps = showUps
+	report.Excess = showUps - flight.Capacity
+	if report.Excess <= 0 {
+		report.Excess = 0
+		return report, nil
+	}
+
+	// Sort so the passengers who keep their seats come first
+	sort.SliceStable(showing, func(i, j int) bool {
+		a, b := showing[i], showing[j]
+		if order == model.DeniedBoardingByFare {
+			if ra, rb := cabinRank[flightmodel.CabinClass(a.Cabin)], cabinRank[flightmodel.CabinClass(b.Cabin)]; ra != rb {
+				return ra > rb
+			}
+			if a.FarePrice != b.FarePrice {
+				return a.FarePrice > b.FarePrice
+			}
+		}
+		return checkedInBefore(a, b)
+	})
+
+	for i := len(showing) - 1; i >= 0 && report.DeniedPassengers < report.Excess; i-- {
+		booking := showing[i]
+		report.Denied = append(report.Denied, model.DeniedPassenger{
+			BookingID:   booking.ID,
+			UserID:      booking.UserID,
+			Passengers:  booking.Passengers,
+			Cabin:       booking.Cabin,
+			FareCode:    booking.FareCode,
+			FarePrice:   booking.FarePrice,
+			CheckedInAt: booking.CheckedInAt,
+		})
+		report.DeniedPassengers += booking.Passengers
+	}
+	return report, nil
+}
+
+// checkedInBefore orders bookings by check-in time, with bookings that have
+// not checked in last in the order they were made
+func checkedInBefore(a, b *model.Booking) bool {
+	switch {
+	case a.CheckedInAt != nil && b.CheckedInAt != nil:
+		return a.CheckedInAt.Before(*b.CheckedInAt)
+	case a.CheckedInAt != nil || b.CheckedInAt != nil:
+		return a.CheckedInAt != nil
+	}
+	return a.BookingDate.Before(b.BookingDate)
+}
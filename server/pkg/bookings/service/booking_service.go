@@ -4,11 +4,13 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Siya360/take-flight/server/pkg/bookings/model"
 	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
 	"github.com/Siya360/take-flight/server/pkg/flights/service"
 	"github.com/google/uuid"
 )
@@ -24,14 +26,32 @@ const (
 	errMsgFailedToSave      = "Failed to save booking"
 	errMsgFailedToDelete    = "Failed to delete booking"
 	errMsgInsufficientSeats = "Insufficient available seats"
+	errMsgTooManySeats      = "More seats selected than passengers on the booking"
+	errMsgDuplicateSeat     = "A seat may only be selected once"
+	errMsgBookingInactive   = "Booking is no longer active"
+	errMsgSeatsExceedPax    = "Release selected seats before reducing passengers"
+	errMsgFareUnavailable   = "Requested cabin or fare is not available for this many passengers"
+	errMsgFlightNotSellable = "Flight is no longer open for booking"
+	errMsgChangesClosed     = "The change deadline for this fare has passed"
+	errMsgNoPassengers      = "A booking needs at least one passenger"
 )
 
 type BookingRepository interface {
+	// Create returns common.ErrDuplicateLocator when another booking has the record locator
 	Create(ctx context.Context, booking *model.Booking) error
 	FindByID(ctx context.Context, id string) (*model.Booking, error)
+	// FindByLocator returns nil when no booking has the record locator
+	FindByLocator(ctx context.Context, locator string) (*model.Booking, error)
 	Update(ctx context.Context, booking *model.Booking) error
+	// Replace saves the whole booking only if it is still in status from, so
+	// two requests cannot both move it on, and reports whether it did
+	Replace(ctx context.Context, booking *model.Booking, from model.BookingStatus) (bool, error)
+	// ClaimPayment marks a pending booking as being paid with the payment,
+	// unless a payment for it is already being taken, and reports whether it did
+	ClaimPayment(ctx context.Context, id, paymentID string) (bool, error)
 	Delete(ctx context.Context, id string) error
 	Search(ctx context.Context, criteria model.SearchBookingRequest) ([]*model.Booking, error)
+	GetFlightBookings(ctx context.Context, flightID string) ([]*model.Booking, error)
 }
 
 type RedisCache interface {
@@ -44,6 +64,14 @@ type BookingService struct {
 	repo          BookingRepository
 	flightService *service.FlightService
 	cache         RedisCache
+
+	disruptionPolicy model.DisruptionPolicy
+	notifier         Notifier
+	passengerPricing model.PassengerPricing
+	payments         PaymentRepository
+	gateway          PaymentGateway
+	refunds          RefundRepository
+	refundPolicy     model.RefundPolicy
 }
 
 func NewBookingService(repo BookingRepository, flightService *service.FlightService, cache RedisCache) *BookingService {
@@ -51,18 +79,66 @@ func NewBookingService(repo BookingRepository, flightService *service.FlightServ
 		repo:          repo,
 		flightService: flightService,
 		cache:         cache,
+
+		disruptionPolicy: model.DefaultDisruptionPolicy(),
+		passengerPricing: model.DefaultPassengerPricing(),
+		refundPolicy:     model.DefaultRefundPolicy(),
 	}
 }
 
 func (s *BookingService) CreateBooking(ctx context.Context, userID string, req *model.CreateBookingRequest) (*model.BookingResponse, error) {
 	// Get flight details and verify availability
 	flight, err := s.flightService.GetFlight(ctx, req.FlightID)
-	if err != nil {
+	if err != nil || flight == nil {
 		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
 	}
+	if !flightmodel.IsSellable(flight.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgFlightNotSellable, http.StatusBadRequest)
+	}
+
+	// Infants on a manifest travel on a lap, so only the others need seats
+	if len(req.Manifest) > 0 {
+		seated := seatedPassengers(req.Manifest)
+		if req.Passengers != 0 && req.Passengers != seated {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgPassengerCount, http.StatusBadRequest)
+		}
+		req.Passengers = seated
+	}
+
+	// Pick the fare bucket the passengers will be sold from, or the one their seats are held in
+	var hold *flightmodel.SeatHold
+	var fare flightmodel.FareBucket
+	if req.HoldID != "" {
+		if hold, err = s.bookableHold(ctx, userID, req); err != nil {
+			return nil, err
+		}
+		fare = heldFare(flight, hold)
+	} else {
+		var ok bool
+		if fare, ok = flight.SelectFare(flightmodel.CabinClass(req.Cabin), req.FareCode, req.Passengers); !ok {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgFareUnavailable, http.StatusBadRequest)
+		}
+	}
 
-	// Calculate total price
-	totalPrice := float64(req.Passengers) * flight.Price
+	// Charge the quoted price if the passenger was shown one, otherwise price now
+	farePrice, err := s.flightService.FarePrice(ctx, flight, fare, req.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate total price, with each named passenger paying for their type
+	totalPrice := float64(req.Passengers) * farePrice
+	var manifest []model.Passenger
+	if len(req.Manifest) > 0 {
+		if manifest, err = s.buildManifest(req.Manifest, flight.DepartureTime, farePrice); err != nil {
+			return nil, err
+		}
+		totalPrice = 0
+		for _, passenger := range manifest {
+			totalPrice += passenger.FarePrice
+		}
+	}
+	rules := fare.FareRules()
 
 	booking := &model.Booking{
 		ID:            uuid.New().String(),
@@ -70,25 +146,51 @@ func (s *BookingService) CreateBooking(ctx context.Context, userID string, req *
 		FlightID:      req.FlightID,
 		Status:        model.BookingStatusPending,
 		Passengers:    req.Passengers,
+		Cabin:         string(fare.Cabin),
+		FareCode:      fare.Code,
+		FarePrice:     farePrice,
 		TotalPrice:    totalPrice,
-		PaymentStatus: "pending",
+		PaymentStatus: model.PaymentStatusPending,
 		BookingDate:   time.Now(),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
+		FareRules:     &rules,
+		Manifest:      manifest,
 	}
 
-	// Update flight seats
-	if err := s.flightService.UpdateSeats(ctx, req.FlightID, req.Passengers); err != nil {
+	// Update flight seats; held seats are already out of inventory and only change hands
+	if hold != nil {
+		if _, err := s.flightService.ConvertHold(ctx, hold.ID, booking.ID); err != nil {
+			return nil, err
+		}
+	} else if err := s.flightService.UpdateSeats(ctx, req.FlightID, fare.Code, req.Passengers); err != nil {
 		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInsufficientSeats, http.StatusBadRequest)
 	}
 
-	if err := s.repo.Create(ctx, booking); err != nil {
+	if err := s.createWithLocator(ctx, booking); err != nil {
+		// Held seats go back to their hold, which returns them when it expires
+		if hold != nil {
+			s.flightService.RevertHold(ctx, hold.ID, booking.ID)
+		} else {
+			s.flightService.UpdateSeats(ctx, req.FlightID, fare.Code, -req.Passengers)
+		}
 		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
 	}
 
 	return booking.ToResponse(), nil
 }
 
+// findOwnedBooking loads a booking on behalf of a user, who must hold it. An
+// empty userID acts for an admin, who may load any booking; bookings held by
+// other users are reported as not found.
+func (s *BookingService) findOwnedBooking(ctx context.Context, userID, id string) (*model.Booking, error) {
+	booking, err := s.repo.FindByID(ctx, id)
+	if err != nil || booking == nil || (userID != "" && booking.UserID != userID) {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+	}
+	return booking, nil
+}
+
 func (s *BookingService) GetBooking(ctx context.Context, id string) (*model.BookingResponse, error) {
 	booking, err := s.repo.FindByID(ctx, id)
 	if err != nil {
@@ -98,70 +200,223 @@ func (s *BookingService) GetBooking(ctx context.Context, id string) (*model.Book
 	return booking.ToResponse(), nil
 }
 
-func (s *BookingService) UpdateBooking(ctx context.Context, id string, updates *model.UpdateBookingRequest) (*model.BookingResponse, error) {
-	booking, err := s.repo.FindByID(ctx, id)
+// UpdateBooking changes a booking under its fare rules. A booking already paid
+// for pays what the change costs before it is saved.
+func (s *BookingService) UpdateBooking(ctx context.Context, userID, id string, updates *model.UpdateBookingRequest) (*model.BookingResponse, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
 	if err != nil {
-		return nil, common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+		return nil, err
 	}
 
-	if updates.Status != nil {
-		booking.Status = *updates.Status
+	if updates.Status != nil && *updates.Status != booking.Status {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgStatusViaEndpoint, http.StatusConflict)
 	}
 
-	if updates.Passengers != nil {
-		passengerDiff := *updates.Passengers - booking.Passengers
-		if passengerDiff != 0 {
-			if err := s.flightService.UpdateSeats(ctx, booking.FlightID, passengerDiff); err != nil {
+	var refundDue *model.ChangeQuote
+	var charged *model.Payment
+	passengerDiff := 0
+	if updates.Passengers != nil && *updates.Passengers != booking.Passengers {
+		quote, err := s.quoteChange(ctx, booking, *updates.Passengers)
+		if err != nil {
+			return nil, err
+		}
+
+		// Added passengers take their seats before the booking is saved; removed
+		// ones give theirs back only once it has been
+		passengerDiff = quote.Passengers - booking.Passengers
+		if passengerDiff > 0 {
+			if err := s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, passengerDiff); err != nil {
 				return nil, common.NewAppError(common.ErrInvalidInput, errMsgInsufficientSeats, http.StatusBadRequest)
 			}
-			booking.Passengers = *updates.Passengers
-
-			// Recalculate total price
-			flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
-			if err == nil {
-				booking.TotalPrice = float64(booking.Passengers) * flight.Price
+		}
+		if due := quote.AmountDue - quote.Refund; due > 0 && booking.PaymentStatus == model.PaymentStatusPaid {
+			if charged, err = s.chargeChange(ctx, booking, due, updates.PaymentMethod); err != nil {
+				if passengerDiff > 0 {
+					s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, -passengerDiff)
+				}
+				return nil, err
 			}
 		}
+		booking.Record(model.ActionChanged, "", fmt.Sprintf("Passengers changed from %d to %d", booking.Passengers, quote.Passengers))
+		booking.Passengers = quote.Passengers
+		booking.Fees += quote.ChangeFee + quote.Retained
+		booking.TotalPrice = quote.TotalPrice
+		refundDue = quote
 	}
 
 	booking.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(ctx, booking); err != nil {
+		if passengerDiff > 0 {
+			s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, -passengerDiff)
+		}
+		if charged != nil {
+			s.returnPayment(ctx, charged)
+		}
 		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
 	}
 
 	// Invalidate cache
 	s.cache.Del(ctx, cacheKeyPrefix+id)
 
+	if passengerDiff < 0 {
+		if err := s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, passengerDiff); err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToRelease, http.StatusInternalServerError)
+		}
+	}
+
+	if refundDue != nil {
+		if err := s.refundRemoval(ctx, booking, refundDue, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	return booking.ToResponse(), nil
 }
 
-func (s *BookingService) CancelBooking(ctx context.Context, id string) error {
-	booking, err := s.repo.FindByID(ctx, id)
+// QuoteChange prices a change to a booking under its fare rules without making it
+func (s *BookingService) QuoteChange(ctx context.Context, userID, id string, updates *model.UpdateBookingRequest) (*model.ChangeQuote, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	passengers := booking.Passengers
+	if updates.Passengers != nil {
+		passengers = *updates.Passengers
+	}
+	return s.quoteChange(ctx, booking, passengers)
+}
+
+// quoteChange checks a change of passengers is allowed and prices it
+func (s *BookingService) quoteChange(ctx context.Context, booking *model.Booking, passengers int) (*model.ChangeQuote, error) {
+	if !model.IsActive(booking.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+	if passengers < 1 {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNoPassengers, http.StatusBadRequest)
+	}
+	if passengers < len(booking.Seats) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgSeatsExceedPax, http.StatusBadRequest)
+	}
+	if passengers != booking.Passengers && len(booking.Manifest) > 0 {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgManifestFixed, http.StatusBadRequest)
+	}
+
+	flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	deadline := booking.Rules().ChangeDeadline(flight.DepartureTime)
+	if passengers != booking.Passengers && time.Now().After(deadline) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgChangesClosed, http.StatusBadRequest)
+	}
+
+	// Price added or removed passengers at the fare the booking was sold at
+	farePrice := booking.FarePrice
+	if farePrice <= 0 {
+		farePrice = flight.Price
+		if fare, ok := flight.FindFare(booking.FareCode); ok {
+			farePrice = fare.Price
+		}
+	}
+	share := s.refundPolicy.Share(flight.DepartureTime, time.Now())
+	return booking.QuoteChange(passengers, farePrice, deadline, share), nil
+}
+
+// CancelBooking cancels a booking and returns the refund due under its fare
+// rules and the refund policy. Bookings paid through the payment processor
+// are refunded straight away.
+func (s *BookingService) CancelBooking(ctx context.Context, userID, id string) (*model.CancellationQuote, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.Status == model.BookingStatusCancelled || booking.Status == model.BookingStatusRefunded {
+		return booking.QuoteCancellation(0), nil
+	}
+
+	// Cancelling releases the seats back to the flight and works out the refund
+	change, err := s.transition(ctx, booking, model.BookingStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commit(ctx, booking, change); err != nil {
+		return nil, err
+	}
+
+	if err := s.refundCancellation(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	return booking.QuoteCancellation(0), nil
+}
+
+// QuoteCancellation returns what cancelling a booking now would refund without cancelling it
+func (s *BookingService) QuoteCancellation(ctx context.Context, userID, id string) (*model.CancellationQuote, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.quoteCancellation(ctx, booking)
+}
+
+// SelectSeats replaces the seats held by a booking. New seats are assigned
+// before the old ones are released so a failed change leaves the booking as it was.
+func (s *BookingService) SelectSeats(ctx context.Context, userID, id string, req *model.SelectSeatsRequest) (*model.BookingResponse, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
 	if err != nil {
-		return common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+		return nil, err
+	}
+	if !model.IsActive(booking.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+	if len(req.Seats) > booking.Passengers {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgTooManySeats, http.StatusBadRequest)
 	}
 
-	if booking.Status == model.BookingStatusCancelled {
-		return nil
+	selected := make(map[string]bool, len(req.Seats))
+	for _, seat := range req.Seats {
+		if selected[seat] {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgDuplicateSeat, http.StatusBadRequest)
+		}
+		selected[seat] = true
 	}
 
-	// Release the seats back to the flight
-	if err := s.flightService.UpdateSeats(ctx, booking.FlightID, -booking.Passengers); err != nil {
-		return common.NewAppError(common.ErrInternalServer, "Failed to update flight seats", http.StatusInternalServerError)
+	held := make(map[string]bool, len(booking.Seats))
+	var released []string
+	for _, seat := range booking.Seats {
+		held[seat] = true
+		if !selected[seat] {
+			released = append(released, seat)
+		}
+	}
+	var added []string
+	for _, seat := range req.Seats {
+		if !held[seat] {
+			added = append(added, seat)
+		}
 	}
 
-	booking.Status = model.BookingStatusCancelled
+	if err := s.flightService.AssignSeats(ctx, booking.FlightID, booking.ID, added); err != nil {
+		return nil, err
+	}
+	if err := s.flightService.ReleaseSeats(ctx, booking.FlightID, booking.ID, released); err != nil {
+		return nil, err
+	}
+
+	booking.Seats = req.Seats
 	booking.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(ctx, booking); err != nil {
-		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
 	}
 
 	// Invalidate cache
 	s.cache.Del(ctx, cacheKeyPrefix+id)
 
-	return nil
+	return booking.ToResponse(), nil
 }
 
 func (s *BookingService) SearchBookings(ctx context.Context, criteria model.SearchBookingRequest) ([]*model.BookingResponse, error) {
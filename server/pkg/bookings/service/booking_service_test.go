@@ -0,0 +1,468 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Siya360/take-flight/server/internal/cache"
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+	flightservice "github.com/Siya360/take-flight/server/pkg/flights/service"
+)
+
+// events records the order in which the fakes below are written to
+type events []string
+
+func (e *events) add(event string) {
+	*e = append(*e, event)
+}
+
+type mockBookingRepo struct {
+	log      *events
+	bookings map[string]*model.Booking
+	// stale is handed out instead of the stored booking, as if read before
+	// another request saved it
+	stale *model.Booking
+}
+
+func (m *mockBookingRepo) Create(ctx context.Context, booking *model.Booking) error {
+	copied := *booking
+	m.bookings[booking.ID] = &copied
+	return nil
+}
+
+func (m *mockBookingRepo) FindByID(ctx context.Context, id string) (*model.Booking, error) {
+	if m.stale != nil {
+		copied := *m.stale
+		return &copied, nil
+	}
+	if booking, ok := m.bookings[id]; ok {
+		copied := *booking
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockBookingRepo) FindByLocator(ctx context.Context, locator string) (*model.Booking, error) {
+	return nil, nil
+}
+
+func (m *mockBookingRepo) Update(ctx context.Context, booking *model.Booking) error {
+	m.log.add("save booking " + string(booking.Status))
+	copied := *booking
+	m.bookings[booking.ID] = &copied
+	return nil
+}
+
+func (m *mockBookingRepo) Replace(ctx context.Context, booking *model.Booking, from model.BookingStatus) (bool, error) {
+	if stored, ok := m.bookings[booking.ID]; !ok || stored.Status != from {
+		return false, nil
+	}
+	return true, m.Update(ctx, booking)
+}
+
+func (m *mockBookingRepo) ClaimPayment(ctx context.Context, id, paymentID string) (bool, error) {
+	stored, ok := m.bookings[id]
+	if !ok || stored.Status != model.BookingStatusPending ||
+		stored.PaymentStatus == model.PaymentStatusProcessing || stored.PaymentStatus == model.PaymentStatusRequiresAction {
+		return false, nil
+	}
+	m.log.add("claim payment")
+	stored.PaymentStatus = model.PaymentStatusProcessing
+	stored.PaymentID = paymentID
+	return true, nil
+}
+
+func (m *mockBookingRepo) Delete(ctx context.Context, id string) error {
+	delete(m.bookings, id)
+	return nil
+}
+
+func (m *mockBookingRepo) Search(ctx context.Context, criteria model.SearchBookingRequest) ([]*model.Booking, error) {
+	return nil, nil
+}
+
+func (m *mockBookingRepo) GetFlightBookings(ctx context.Context, flightID string) ([]*model.Booking, error) {
+	return nil, nil
+}
+
+type mockPaymentRepo struct {
+	payments map[string]*model.Payment
+}
+
+func (m *mockPaymentRepo) Create(ctx context.Context, payment *model.Payment) error {
+	copied := *payment
+	m.payments[payment.ID] = &copied
+	return nil
+}
+
+func (m *mockPaymentRepo) FindByID(ctx context.Context, id string) (*model.Payment, error) {
+	if payment, ok := m.payments[id]; ok {
+		copied := *payment
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockPaymentRepo) FindByReference(ctx context.Context, reference string) (*model.Payment, error) {
+	for _, payment := range m.payments {
+		if payment.Reference == reference {
+			copied := *payment
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockPaymentRepo) Update(ctx context.Context, payment *model.Payment) error {
+	copied := *payment
+	m.payments[payment.ID] = &copied
+	return nil
+}
+
+func (m *mockPaymentRepo) ListByBooking(ctx context.Context, bookingID string) ([]*model.Payment, error) {
+	var payments []*model.Payment
+	for _, payment := range m.payments {
+		if payment.BookingID == bookingID {
+			copied := *payment
+			payments = append(payments, &copied)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].CreatedAt.Before(payments[j].CreatedAt) })
+	return payments, nil
+}
+
+type mockRefundRepo struct {
+	log     *events
+	refunds map[string]*model.Refund
+}
+
+func (m *mockRefundRepo) Create(ctx context.Context, refund *model.Refund) error {
+	m.log.add("record refund")
+	copied := *refund
+	m.refunds[refund.ID] = &copied
+	return nil
+}
+
+func (m *mockRefundRepo) FindByID(ctx context.Context, id string) (*model.Refund, error) {
+	if refund, ok := m.refunds[id]; ok {
+		copied := *refund
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockRefundRepo) Update(ctx context.Context, refund *model.Refund) error {
+	m.log.add("refund " + string(refund.Status))
+	copied := *refund
+	m.refunds[refund.ID] = &copied
+	return nil
+}
+
+func (m *mockRefundRepo) ListByBooking(ctx context.Context, bookingID string) ([]*model.Refund, error) {
+	var refunds []*model.Refund
+	for _, refund := range m.refunds {
+		if refund.BookingID == bookingID {
+			refunds = append(refunds, refund)
+		}
+	}
+	return refunds, nil
+}
+
+type mockGateway struct {
+	log          *events
+	refuseRefund bool
+	// captured runs once a payment is captured
+	captured func()
+}
+
+func (m *mockGateway) Authorize(ctx context.Context, req *model.AuthorizationRequest) (*model.GatewayResult, error) {
+	if req.PaymentMethod == "tok_decline" {
+		return &model.GatewayResult{Reference: "ref_" + req.PaymentID, Status: model.PaymentDeclined, DeclineCode: "card_declined"}, nil
+	}
+	return &model.GatewayResult{Reference: "ref_" + req.PaymentID, Status: model.PaymentAuthorized}, nil
+}
+
+func (m *mockGateway) Capture(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error) {
+	m.log.add("capture payment")
+	if m.captured != nil {
+		m.captured()
+	}
+	return &model.GatewayResult{Reference: reference, Status: model.PaymentCaptured}, nil
+}
+
+func (m *mockGateway) Void(ctx context.Context, reference string) (*model.GatewayResult, error) {
+	m.log.add("void payment")
+	return &model.GatewayResult{Reference: reference, Status: model.PaymentVoided}, nil
+}
+
+func (m *mockGateway) Refund(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error) {
+	m.log.add("refund payment")
+	if m.refuseRefund {
+		return &model.GatewayResult{Reference: reference, Status: model.PaymentCaptured}, nil
+	}
+	return &model.GatewayResult{Reference: reference, Status: model.PaymentRefunded}, nil
+}
+
+func (m *mockGateway) ParseWebhook(payload []byte, signature string) (*model.PaymentEvent, error) {
+	return nil, errors.New("not supported")
+}
+
+// mockFlightRepo serves a single flight; the flight service only reads it and
+// returns seats to it while bookings are cancelled
+type mockFlightRepo struct {
+	flightservice.FlightRepository
+	log          *events
+	flight       *flightmodel.Flight
+	seatsRefused bool
+}
+
+func (m *mockFlightRepo) FindByID(ctx context.Context, id string) (*flightmodel.Flight, error) {
+	copied := *m.flight
+	return &copied, nil
+}
+
+func (m *mockFlightRepo) UpdateSeats(ctx context.Context, flightID string, change int) (bool, error) {
+	m.log.add("release seats")
+	if m.seatsRefused {
+		return false, nil
+	}
+	m.flight.AvailableSeats -= change
+	return true, nil
+}
+
+func TestCancelBookingSavesBeforeReleasingAndRefunding(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       model.BookingStatus
+		payment      model.PaymentState
+		refuseRefund bool
+		seatsRefused bool
+		wantErr      bool
+		wantStatus   model.BookingStatus
+		wantPayment  string
+		want         events
+	}{
+		{
+			name:   "captured payment is refunded once the booking is saved",
+			status: model.BookingStatusConfirmed, payment: model.PaymentCaptured,
+			wantStatus: model.BookingStatusRefunded, wantPayment: model.PaymentStatusRefunded,
+			want: events{"save booking cancelled", "release seats", "record refund", "refund payment", "refund succeeded", "save booking refunded"},
+		},
+		{
+			name:   "authorized payment is voided and nothing is refunded",
+			status: model.BookingStatusPending, payment: model.PaymentAuthorized,
+			wantStatus: model.BookingStatusCancelled, wantPayment: model.PaymentStatusVoided,
+			want: events{"save booking cancelled", "void payment", "release seats"},
+		},
+		{
+			name:   "refused refund stays owed on the cancelled booking",
+			status: model.BookingStatusConfirmed, payment: model.PaymentCaptured, refuseRefund: true, wantErr: true,
+			wantStatus: model.BookingStatusCancelled, wantPayment: model.PaymentStatusRefundPending,
+			want: events{"save booking cancelled", "release seats", "record refund", "refund payment", "refund failed"},
+		},
+		{
+			name:   "failed release still records the refund owed",
+			status: model.BookingStatusConfirmed, payment: model.PaymentCaptured, seatsRefused: true, wantErr: true,
+			wantStatus: model.BookingStatusCancelled, wantPayment: model.PaymentStatusRefundPending,
+			want: events{"save booking cancelled", "release seats", "record refund"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			log := &events{}
+			paymentStatus := model.PaymentStatusPending
+			if tt.payment == model.PaymentCaptured {
+				paymentStatus = model.PaymentStatusPaid
+			}
+			bookings := &mockBookingRepo{log: log, bookings: map[string]*model.Booking{
+				"b1": {ID: "b1", UserID: "u1", FlightID: "f1", Status: tt.status, Passengers: 2, TotalPrice: 200,
+					PaymentStatus: paymentStatus, PaymentID: "pay1"},
+			}}
+			payments := &mockPaymentRepo{payments: map[string]*model.Payment{
+				"pay1": {ID: "pay1", BookingID: "b1", Reference: "ref1", Amount: 200, Status: tt.payment},
+			}}
+			refunds := &mockRefundRepo{log: log, refunds: map[string]*model.Refund{}}
+			flights := &mockFlightRepo{log: log, seatsRefused: tt.seatsRefused, flight: &flightmodel.Flight{
+				ID: "f1", Status: flightmodel.FlightStatusScheduled, DepartureTime: time.Now().Add(30 * 24 * time.Hour), AvailableSeats: 10,
+			}}
+			svc := NewBookingService(bookings, flightservice.NewFlightService(flights), cache.NewMockCacheClient()).
+				WithPayments(payments, &mockGateway{log: log, refuseRefund: tt.refuseRefund}).
+				WithRefunds(refunds)
+
+			_, err := svc.CancelBooking(ctx, "u1", "b1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*log, tt.want) {
+				t.Fatalf("got events %q, want %q", *log, tt.want)
+			}
+			stored := bookings.bookings["b1"]
+			if stored.Status != tt.wantStatus || stored.PaymentStatus != tt.wantPayment {
+				t.Fatalf("got booking %s with payment %s, want %s with %s", stored.Status, stored.PaymentStatus, tt.wantStatus, tt.wantPayment)
+			}
+			if stored.Refund > 0 && refunds.refunds[stored.RefundID] == nil {
+				t.Fatalf("expected the refund of %.2f to be recorded", stored.Refund)
+			}
+		})
+	}
+}
+
+func TestCancelBookingIsScopedToTheOwner(t *testing.T) {
+	ctx := context.Background()
+	log := &events{}
+	bookings := &mockBookingRepo{log: log, bookings: map[string]*model.Booking{
+		"b1": {ID: "b1", UserID: "u1", FlightID: "f1", Status: model.BookingStatusConfirmed, Passengers: 1, TotalPrice: 100,
+			PaymentStatus: model.PaymentStatusPaid},
+	}}
+	svc := NewBookingService(bookings, flightservice.NewFlightService(&mockFlightRepo{log: log}), cache.NewMockCacheClient())
+
+	if _, err := svc.QuoteCancellation(ctx, "u2", "b1"); err == nil {
+		t.Fatal("expected another user's booking not to be quoted")
+	}
+	if _, err := svc.CancelBooking(ctx, "u2", "b1"); err == nil {
+		t.Fatal("expected another user's booking not to be cancelled")
+	}
+	if len(*log) != 0 || bookings.bookings["b1"].Status != model.BookingStatusConfirmed {
+		t.Fatalf("expected the booking to be left alone, got events %q", *log)
+	}
+}
+
+func TestUpdateBookingChargesAddedPassengersOnAPaidBooking(t *testing.T) {
+	ctx := context.Background()
+	log := &events{}
+	bookings := &mockBookingRepo{log: log, bookings: map[string]*model.Booking{
+		"b1": {ID: "b1", UserID: "u1", FlightID: "f1", Status: model.BookingStatusConfirmed, Passengers: 1, FarePrice: 100,
+			TotalPrice: 100, PaymentStatus: model.PaymentStatusPaid, PaymentID: "pay1"},
+	}}
+	payments := &mockPaymentRepo{payments: map[string]*model.Payment{
+		"pay1": {ID: "pay1", BookingID: "b1", Reference: "ref1", Amount: 100, Status: model.PaymentCaptured},
+	}}
+	flights := &mockFlightRepo{log: log, flight: &flightmodel.Flight{
+		ID: "f1", Status: flightmodel.FlightStatusScheduled, DepartureTime: time.Now().Add(30 * 24 * time.Hour), AvailableSeats: 10,
+	}}
+	svc := NewBookingService(bookings, flightservice.NewFlightService(flights), cache.NewMockCacheClient()).
+		WithPayments(payments, &mockGateway{log: log}).
+		WithRefunds(&mockRefundRepo{log: log, refunds: map[string]*model.Refund{}})
+	two := 2
+
+	if _, err := svc.UpdateBooking(ctx, "u2", "b1", &model.UpdateBookingRequest{Passengers: &two, PaymentMethod: "tok_ok"}); err == nil {
+		t.Fatal("expected another user's booking not to be changed")
+	}
+	for _, method := range []string{"", "tok_decline"} {
+		if _, err := svc.UpdateBooking(ctx, "u1", "b1", &model.UpdateBookingRequest{Passengers: &two, PaymentMethod: method}); err == nil {
+			t.Fatalf("expected the change not to go through unpaid with payment method %q", method)
+		}
+		if flights.flight.AvailableSeats != 10 || bookings.bookings["b1"].Passengers != 1 {
+			t.Fatalf("expected an unpaid change to leave the booking and seats alone, got %d seats left", flights.flight.AvailableSeats)
+		}
+	}
+
+	booking, err := svc.UpdateBooking(ctx, "u1", "b1", &model.UpdateBookingRequest{Passengers: &two, PaymentMethod: "tok_ok"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if booking.Passengers != 2 || booking.TotalPrice != 200 || booking.PaymentStatus != model.PaymentStatusPaid || flights.flight.AvailableSeats != 9 {
+		t.Fatalf("expected the added passenger paid for, got %+v with %d seats left", booking, flights.flight.AvailableSeats)
+	}
+	captured := 0.0
+	for _, payment := range payments.payments {
+		if payment.Status == model.PaymentCaptured {
+			captured += payment.Amount
+		}
+	}
+	if captured != 200 {
+		t.Fatalf("expected 200 captured over both payments, got %.2f", captured)
+	}
+
+	// The cancellation refund is spread over both payments
+	if _, err := svc.CancelBooking(ctx, "u1", "b1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, payment := range payments.payments {
+		if payment.Status == model.PaymentRefunded && payment.Refunded != payment.Amount {
+			t.Fatalf("expected payment %s refunded in full, got %.2f of %.2f", payment.ID, payment.Refunded, payment.Amount)
+		}
+	}
+	if stored := bookings.bookings["b1"]; stored.Status != model.BookingStatusRefunded || stored.Refund != 200 {
+		t.Fatalf("expected the booking refunded 200, got %s with %.2f", stored.Status, stored.Refund)
+	}
+}
+
+func TestCancelBookingOnlyOnce(t *testing.T) {
+	ctx := context.Background()
+	log := &events{}
+	booking := &model.Booking{ID: "b1", UserID: "u1", FlightID: "f1", Status: model.BookingStatusConfirmed, Passengers: 2,
+		TotalPrice: 200, PaymentStatus: model.PaymentStatusPaid}
+	bookings := &mockBookingRepo{log: log, bookings: map[string]*model.Booking{"b1": booking}}
+	refunds := &mockRefundRepo{log: log, refunds: map[string]*model.Refund{}}
+	flights := &mockFlightRepo{log: log, flight: &flightmodel.Flight{
+		ID: "f1", Status: flightmodel.FlightStatusScheduled, DepartureTime: time.Now().Add(30 * 24 * time.Hour), AvailableSeats: 10,
+	}}
+	svc := NewBookingService(bookings, flightservice.NewFlightService(flights), cache.NewMockCacheClient()).WithRefunds(refunds)
+
+	// Both requests read the booking while it was still confirmed
+	stale := *booking
+	if _, err := svc.CancelBooking(ctx, "u1", "b1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bookings.stale = &stale
+	if _, err := svc.CancelBooking(ctx, "u1", "b1"); err == nil {
+		t.Fatal("expected the second cancellation to lose the race")
+	}
+
+	want := events{"save booking cancelled", "release seats", "record refund"}
+	if !reflect.DeepEqual(*log, want) || flights.flight.AvailableSeats != 12 || len(refunds.refunds) != 1 {
+		t.Fatalf("expected seats and the refund given back once, got events %q, %d seats and %d refunds",
+			*log, flights.flight.AvailableSeats, len(refunds.refunds))
+	}
+}
+
+func TestPayBookingTakesOnePaymentAndRefundsOneItCannotUse(t *testing.T) {
+	ctx := context.Background()
+	log := &events{}
+	pending := &model.Booking{ID: "b1", UserID: "u1", FlightID: "f1", Status: model.BookingStatusPending, Passengers: 1,
+		TotalPrice: 100, PaymentStatus: model.PaymentStatusPending}
+	bookings := &mockBookingRepo{log: log, bookings: map[string]*model.Booking{"b1": pending}}
+	payments := &mockPaymentRepo{payments: map[string]*model.Payment{}}
+	gateway := &mockGateway{log: log}
+	svc := NewBookingService(bookings, flightservice.NewFlightService(&mockFlightRepo{log: log}), cache.NewMockCacheClient()).
+		WithPayments(payments, gateway)
+	stale := *pending
+
+	if _, err := svc.PayBooking(ctx, "u1", "b1", &model.PayBookingRequest{PaymentMethod: "tok_ok"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := events{"claim payment", "capture payment", "save booking confirmed"}
+	if !reflect.DeepEqual(*log, want) {
+		t.Fatalf("got events %q, want %q", *log, want)
+	}
+
+	// A second request that read the booking while it was still pending is turned away before the processor is asked
+	bookings.stale = &stale
+	if _, err := svc.PayBooking(ctx, "u1", "b1", &model.PayBookingRequest{PaymentMethod: "tok_ok"}); err == nil {
+		t.Fatal("expected the booking not to be paid twice")
+	}
+	if len(payments.payments) != 1 {
+		t.Fatalf("expected one payment, got %d", len(payments.payments))
+	}
+
+	// A booking cancelled while its payment was captured has the payment refunded
+	bookings.stale = nil
+	bookings.bookings["b2"] = &model.Booking{ID: "b2", UserID: "u1", FlightID: "f1", Status: model.BookingStatusPending,
+		Passengers: 1, TotalPrice: 100, PaymentStatus: model.PaymentStatusPending}
+	gateway.captured = func() { bookings.bookings["b2"].Status = model.BookingStatusCancelled }
+	if _, err := svc.PayBooking(ctx, "u1", "b2", &model.PayBookingRequest{PaymentMethod: "tok_ok"}); err == nil {
+		t.Fatal("expected a cancelled booking not to be confirmed")
+	}
+	for _, payment := range payments.payments {
+		if payment.BookingID == "b2" && (payment.Status != model.PaymentRefunded || payment.Refunded != 100) {
+			t.Fatalf("expected the unused payment refunded, got %s with %.2f refunded", payment.Status, payment.Refunded)
+		}
+	}
+}
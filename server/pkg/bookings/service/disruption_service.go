@@ -0,0 +1,298 @@
+// pkg/bookings/service/disruption_service.go
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgFlightNotDisrupted = "Flight is neither cancelled nor delayed beyond the disruption threshold"
+	errMsgNoDisruption       = "Booking has no disruption awaiting a decision"
+	errMsgNoAlternative      = "No alternative flight is on offer for this booking"
+	errMsgAlternativeGone    = "The offered flight no longer has room; a refund is still available"
+	errMsgFailedToDisrupt    = "Failed to handle flight disruption"
+)
+
+// Notifier delivers messages to passengers
+type Notifier interface {
+	Notify(ctx context.Context, notification *model.Notification) error
+}
+
+// WithNotifier sets how passengers are told about changes to their bookings
+func (s *BookingService) WithNotifier(notifier Notifier) *BookingService {
+	s.notifier = notifier
+	return s
+}
+
+// WithDisruptionPolicy sets how bookings on disrupted flights are handled
+func (s *BookingService) WithDisruptionPolicy(policy model.DisruptionPolicy) *BookingService {
+	s.disruptionPolicy = policy
+	return s
+}
+
+// HandleDisruption is called by the flight service when a flight is cancelled
+// or delayed. Delays under the policy threshold are ignored.
+func (s *BookingService) HandleDisruption(ctx context.Context, flight *flightmodel.Flight, event *flightmodel.FlightStatusEvent) {
+	if !s.isDisrupted(flight) {
+		return
+	}
+	s.handleDisruption(ctx, flight)
+}
+
+// DisruptFlight runs disruption handling for a cancelled or heavily delayed
+// flight. It is safe to run again: bookings already moved off the flight are
+// not seen, and open offers are refreshed.
+func (s *BookingService) DisruptFlight(ctx context.Context, flightID string) (*model.DisruptionReport, error) {
+	flight, err := s.flightService.GetFlight(ctx, flightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	if !s.isDisrupted(flight) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgFlightNotDisrupted, http.StatusBadRequest)
+	}
+	return s.handleDisruption(ctx, flight)
+}
+
+// isDisrupted reports whether a flight is cancelled or delayed by at least the policy threshold
+func (s *BookingService) isDisrupted(flight *flightmodel.Flight) bool {
+	switch flight.Status {
+	case flightmodel.FlightStatusCancelled:
+		return true
+	case flightmodel.FlightStatusDelayed:
+		return flight.EstimatedDepartureTime != nil &&
+			flight.EstimatedDepartureTime.Sub(flight.DepartureTime) >= s.disruptionPolicy.DelayThreshold
+	}
+	return false
+}
+
+// handleDisruption rebooks or makes offers to every active booking on the flight
+func (s *BookingService) handleDisruption(ctx context.Context, flight *flightmodel.Flight) (*model.DisruptionReport, error) {
+	bookings, err := s.repo.GetFlightBookings(ctx, flight.ID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToDisrupt, http.StatusInternalServerError)
+	}
+
+	report := &model.DisruptionReport{FlightID: flight.ID, Reason: flight.Status}
+	for _, booking := range bookings {
+		if !model.IsActive(booking.Status) {
+			continue
+		}
+		report.Bookings++
+
+		alternative, err := s.findAlternative(ctx, flight, booking)
+		if err != nil {
+			report.Failed++
+			continue
+		}
+
+		now := time.Now()
+		first := booking.Disruption == nil || booking.Disruption.FlightID != flight.ID
+		if first {
+			booking.Disruption = &model.Disruption{FlightID: flight.ID, CreatedAt: now}
+			booking.Record(model.ActionDisrupted, flight.ID, "Flight "+flight.Status)
+		}
+		booking.Disruption.Reason = flight.Status
+		booking.Disruption.Status = model.DisruptionOffered
+		booking.Disruption.UpdatedAt = now
+
+		var message string
+		switch {
+		case alternative != nil && s.disruptionPolicy.AutoRebook:
+			if err := s.moveBooking(ctx, booking, flight, alternative); err != nil {
+				report.Failed++
+				continue
+			}
+			booking.Disruption.Status = model.DisruptionRebooked
+			booking.Disruption.AlternativeFlightID = alternative.ID
+			booking.Record(model.ActionRebooked, alternative.ID, "Rebooked from flight "+flight.FlightNumber)
+			booking.Record(model.ActionRefundOffered, flight.ID, "")
+			message = fmt.Sprintf("Flight %s is %s. You have been moved to flight %s departing %s. If it does not suit you, you can ask for a refund instead.",
+				flight.FlightNumber, flight.Status, alternative.FlightNumber, alternative.DepartureTime.Format(time.RFC1123))
+			report.Rebooked++
+		case alternative != nil:
+			if booking.Disruption.AlternativeFlightID != alternative.ID {
+				booking.Disruption.AlternativeFlightID = alternative.ID
+				booking.Record(model.ActionRebookingOffered, alternative.ID, "")
+			}
+			if first {
+				booking.Record(model.ActionRefundOffered, flight.ID, "")
+			}
+			message = fmt.Sprintf("Flight %s is %s. We can move you to flight %s departing %s, or refund your booking.",
+				flight.FlightNumber, flight.Status, alternative.FlightNumber, alternative.DepartureTime.Format(time.RFC1123))
+			report.Offered++
+		default:
+			booking.Disruption.AlternativeFlightID = ""
+			if first {
+				booking.Record(model.ActionRefundOffered, flight.ID, "No alternative flight available")
+			}
+			message = fmt.Sprintf("Flight %s is %s and we have no alternative flight with room for you. You can ask for a refund of your booking.",
+				flight.FlightNumber, flight.Status)
+			report.RefundOnly++
+		}
+
+		s.notify(ctx, booking, "Your flight "+flight.FlightNumber+" is "+flight.Status, message)
+		booking.UpdatedAt = now
+		if err := s.repo.Update(ctx, booking); err != nil {
+			report.Failed++
+			continue
+		}
+		s.cache.Del(ctx, cacheKeyPrefix+booking.ID)
+	}
+	return report, nil
+}
+
+// findAlternative returns the earliest flight on the same route with room in
+// the booking's cabin. Alternatives to a delayed flight must arrive before it
+// is now expected to. It returns nil when there is none.
+func (s *BookingService) findAlternative(ctx context.Context, flight *flightmodel.Flight, booking *model.Booking) (*flightmodel.Flight, error) {
+	from := flight.DepartureTime
+	if now := time.Now(); now.After(from) {
+		from = now
+	}
+	to := flight.DepartureTime.Add(s.disruptionPolicy.SearchWindow)
+
+	candidates, err := s.flightService.FindAlternativeFlights(ctx, flight, from, to, booking.Passengers)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if flight.Status == flightmodel.FlightStatusDelayed && flight.EstimatedArrivalTime != nil &&
+			!candidate.ArrivalTime.Before(*flight.EstimatedArrivalTime) {
+			continue
+		}
+		if _, ok := candidate.SelectFare(flightmodel.CabinClass(booking.Cabin), "", booking.Passengers); ok {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// moveBooking takes seats on the new flight in the booking's cabin and gives
+// back those on the disrupted one. The passenger keeps the price paid.
+func (s *BookingService) moveBooking(ctx context.Context, booking *model.Booking, from, to *flightmodel.Flight) error {
+	fare, ok := to.SelectFare(flightmodel.CabinClass(booking.Cabin), "", booking.Passengers)
+	if !ok {
+		return common.NewAppError(common.ErrInvalidInput, errMsgInsufficientSeats, http.StatusBadRequest)
+	}
+	if err := s.flightService.UpdateSeats(ctx, to.ID, fare.Code, booking.Passengers); err != nil {
+		return err
+	}
+	s.flightService.UpdateSeats(ctx, from.ID, booking.FareCode, -booking.Passengers)
+	s.flightService.ReleaseSeats(ctx, from.ID, booking.ID, booking.Seats)
+
+	booking.FlightID = to.ID
+	booking.FareCode = fare.Code
+	booking.Cabin = string(fare.Cabin)
+	booking.Seats = nil
+	return nil
+}
+
+// AcceptRebooking moves a disrupted booking to the flight on offer
+func (s *BookingService) AcceptRebooking(ctx context.Context, userID, id string) (*model.BookingResponse, error) {
+	booking, err := s.openDisruption(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Disruption.Status != model.DisruptionOffered || booking.Disruption.AlternativeFlightID == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNoAlternative, http.StatusBadRequest)
+	}
+
+	from, err := s.flightService.GetFlight(ctx, booking.FlightID)
+	if err != nil || from == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	to, err := s.flightService.GetFlight(ctx, booking.Disruption.AlternativeFlightID)
+	if err != nil || to == nil || !flightmodel.IsSellable(to.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgAlternativeGone, http.StatusConflict)
+	}
+	if err := s.moveBooking(ctx, booking, from, to); err != nil {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgAlternativeGone, http.StatusConflict)
+	}
+
+	now := time.Now()
+	booking.Disruption.Status = model.DisruptionRebooked
+	booking.Disruption.UpdatedAt = now
+	booking.Record(model.ActionRebookAccepted, to.ID, "Rebooked from flight "+from.FlightNumber)
+	booking.UpdatedAt = now
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+	s.cache.Del(ctx, cacheKeyPrefix+id)
+	return booking.ToResponse(), nil
+}
+
+// AcceptRefund cancels a disrupted booking and refunds everything paid for it,
+// whether or not it was already rebooked
+func (s *BookingService) AcceptRefund(ctx context.Context, userID, id string) (*model.BookingResponse, error) {
+	booking, err := s.openDisruption(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := booking.PaymentTaken()
+	change := &statusChange{from: booking.Status}
+	if err := s.giveBack(ctx, booking, change); err != nil {
+		return nil, err
+	}
+
+	// Fare rules and the refund policy do not apply when the airline disrupted the flight
+	if paid {
+		if s.refunds == nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgRefundsDisabled, http.StatusInternalServerError)
+		}
+		change.refund = newRefund(booking, booking.TotalPrice, model.RefundReasonDisruption, nil)
+		booking.RefundID = change.refund.ID
+		booking.Refund = booking.TotalPrice
+		booking.PaymentStatus = model.PaymentStatusRefundPending
+	}
+
+	now := time.Now()
+	booking.Status = model.BookingStatusCancelled
+	booking.Disruption.Status = model.DisruptionRefunded
+	booking.Disruption.UpdatedAt = now
+	booking.Record(model.ActionRefundAccepted, booking.Disruption.FlightID, "")
+	booking.UpdatedAt = now
+	if err := s.commit(ctx, booking, change); err != nil {
+		return nil, err
+	}
+	if err := s.refundCancellation(ctx, booking); err != nil {
+		return nil, err
+	}
+	return booking.ToResponse(), nil
+}
+
+// openDisruption loads a user's booking with a disruption the passenger has not yet settled
+func (s *BookingService) openDisruption(ctx context.Context, userID, id string) (*model.Booking, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !model.IsActive(booking.Status) || booking.Disruption == nil || booking.Disruption.Status == model.DisruptionRefunded {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNoDisruption, http.StatusBadRequest)
+	}
+	return booking, nil
+}
+
+// notify tells the passenger about a change and records that they were told
+func (s *BookingService) notify(ctx context.Context, booking *model.Booking, subject, message string) {
+	if s.notifier == nil {
+		return
+	}
+	notification := &model.Notification{
+		UserID:    booking.UserID,
+		BookingID: booking.ID,
+		Subject:   subject,
+		Message:   message,
+	}
+	if err := s.notifier.Notify(ctx, notification); err == nil {
+		booking.Record(model.ActionNotified, "", subject)
+	}
+}
@@ -0,0 +1,62 @@
+// pkg/bookings/service/hold_service.go
+
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgHoldNotFound = "Seat hold not found"
+	errMsgHoldMismatch = "Seat hold is for a different flight or number of passengers"
+)
+
+// HoldSeats reserves seats for the user while they pay
+func (s *BookingService) HoldSeats(ctx context.Context, userID string, req *flightmodel.SeatHoldRequest) (*flightmodel.SeatHold, error) {
+	return s.flightService.HoldSeats(ctx, userID, req)
+}
+
+// GetHold returns one of the user's seat holds
+func (s *BookingService) GetHold(ctx context.Context, userID, id string) (*flightmodel.SeatHold, error) {
+	hold, err := s.flightService.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != userID {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgHoldNotFound, http.StatusNotFound)
+	}
+	return hold, nil
+}
+
+// ReleaseHold gives the seats of one of the user's holds back before it expires
+func (s *BookingService) ReleaseHold(ctx context.Context, userID, id string) (*flightmodel.SeatHold, error) {
+	if _, err := s.GetHold(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.flightService.ReleaseHold(ctx, id)
+}
+
+// bookableHold checks that a hold belongs to the user and covers the booking requested
+func (s *BookingService) bookableHold(ctx context.Context, userID string, req *model.CreateBookingRequest) (*flightmodel.SeatHold, error) {
+	hold, err := s.GetHold(ctx, userID, req.HoldID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.FlightID != req.FlightID || hold.Passengers != req.Passengers {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldMismatch, http.StatusBadRequest)
+	}
+	return hold, nil
+}
+
+// heldFare returns the fare bucket a hold's seats were taken from
+func heldFare(flight *flightmodel.Flight, hold *flightmodel.SeatHold) flightmodel.FareBucket {
+	if fare, ok := flight.FindFare(hold.FareCode); ok {
+		return *fare
+	}
+	return flightmodel.FareBucket{Cabin: hold.Cabin, Price: flight.Price}
+}
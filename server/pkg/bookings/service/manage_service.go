@@ -0,0 +1,133 @@
+// pkg/bookings/service/manage_service.go
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+)
+
+const (
+	// maxLocatorAttempts is how many record locators are tried before a booking fails to save
+	maxLocatorAttempts = 5
+
+	errMsgNoBookingForLocator = "No booking matches this record locator and last name"
+	errMsgContactRequired     = "Give an email address or phone number"
+)
+
+// newLocator draws a random record locator
+func newLocator() (string, error) {
+	alphabet := big.NewInt(int64(len(model.LocatorAlphabet)))
+	locator := make([]byte, model.LocatorLength)
+	for i := range locator {
+		n, err := rand.Int(rand.Reader, alphabet)
+		if err != nil {
+			return "", err
+		}
+		locator[i] = model.LocatorAlphabet[n.Int64()]
+	}
+	return string(locator), nil
+}
+
+// createWithLocator saves a new booking under a record locator no other
+// booking has, drawing a new one whenever the repository reports it taken
+func (s *BookingService) createWithLocator(ctx context.Context, booking *model.Booking) error {
+	var err error
+	for attempt := 0; attempt < maxLocatorAttempts; attempt++ {
+		if booking.Locator, err = newLocator(); err != nil {
+			return err
+		}
+		if err = s.repo.Create(ctx, booking); !errors.Is(err, common.ErrDuplicateLocator) {
+			return err
+		}
+	}
+	return err
+}
+
+// ManageBooking retrieves a booking for a traveller by record locator and
+// the last name of one of its passengers
+func (s *BookingService) ManageBooking(ctx context.Context, req *model.ManageBookingRequest) (*model.GuestBooking, error) {
+	booking, _, err := s.findGuestBooking(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return booking.ToGuest(), nil
+}
+
+// AddGuestContact adds contact details to a passenger on a booking retrieved by record locator
+func (s *BookingService) AddGuestContact(ctx context.Context, req *model.GuestContactRequest) (*model.GuestBooking, error) {
+	booking, passenger, err := s.findGuestBooking(ctx, &req.ManageBookingRequest)
+	if err != nil {
+		return nil, err
+	}
+	if !model.IsActive(booking.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+	if req.Contact.Email == "" && req.Contact.Phone == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgContactRequired, http.StatusBadRequest)
+	}
+
+	if req.PassengerID != "" {
+		passenger = nil
+		for i := range booking.Manifest {
+			if booking.Manifest[i].ID == req.PassengerID {
+				passenger = &booking.Manifest[i]
+			}
+		}
+		if passenger == nil {
+			return nil, common.NewAppError(common.ErrNotFound, errMsgPassengerNotFound, http.StatusNotFound)
+		}
+	}
+
+	contact := req.Contact
+	passenger.Contact = &contact
+	booking.Record(model.ActionPassengerUpdated, "", "Contact details added for passenger "+passenger.ID)
+	booking.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+
+	// Invalidate cache
+	s.cache.Del(ctx, cacheKeyPrefix+booking.ID)
+
+	return booking.ToGuest(), nil
+}
+
+// GuestCancel cancels a booking retrieved by record locator under its fare rules
+func (s *BookingService) GuestCancel(ctx context.Context, req *model.ManageBookingRequest) (*model.CancellationQuote, error) {
+	booking, _, err := s.findGuestBooking(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.CancelBooking(ctx, booking.UserID, booking.ID)
+}
+
+// findGuestBooking finds the booking under a record locator and the passenger
+// on it with the given last name. A wrong locator and a wrong name fail the
+// same way, so neither can be guessed on its own. Bookings without a
+// manifest have no names to match and are only managed by their signed-in
+// owner.
+func (s *BookingService) findGuestBooking(ctx context.Context, req *model.ManageBookingRequest) (*model.Booking, *model.Passenger, error) {
+	notFound := common.NewAppError(common.ErrNotFound, errMsgNoBookingForLocator, http.StatusNotFound)
+
+	booking, err := s.repo.FindByLocator(ctx, strings.ToUpper(req.Locator))
+	if err != nil || booking == nil {
+		return nil, nil, notFound
+	}
+	lastName := strings.TrimSpace(req.LastName)
+	for i := range booking.Manifest {
+		if strings.EqualFold(booking.Manifest[i].LastName, lastName) {
+			return booking, &booking.Manifest[i], nil
+		}
+	}
+	return nil, nil, notFound
+}
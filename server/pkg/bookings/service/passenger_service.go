@@ -0,0 +1,264 @@
+// pkg/bookings/service/passenger_service.go
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgPassengerNotFound  = "Passenger not found on this booking"
+	errMsgPassengerCount     = "Passengers must match the seated passengers in the manifest"
+	errMsgPassengerAge       = "Passenger type does not match their age on the day of departure"
+	errMsgNoAdult            = "A booking needs at least one adult passenger"
+	errMsgTooManyInfants     = "Each infant must travel with their own adult"
+	errMsgLeadContact        = "The first passenger must give contact details"
+	errMsgDocumentExpires    = "Travel document expires before the flight departs"
+	errMsgNameChangesBlocked = "This fare does not allow name changes"
+	errMsgManifestFixed      = "Passengers cannot be added to a booking with a manifest, and are removed by name"
+)
+
+// WithPassengerPricing sets what children and infants pay
+func (s *BookingService) WithPassengerPricing(pricing model.PassengerPricing) *BookingService {
+	s.passengerPricing = pricing
+	return s
+}
+
+// buildManifest checks the passengers of a new booking against each other and
+// the departure, and prices each of them at their share of the fare
+func (s *BookingService) buildManifest(details []model.PassengerDetails, departure time.Time, farePrice float64) ([]model.Passenger, error) {
+	adults, infants := 0, 0
+	manifest := make([]model.Passenger, len(details))
+	for i, passenger := range details {
+		if err := checkPassenger(&passenger, departure); err != nil {
+			return nil, err
+		}
+		switch passenger.Type {
+		case model.PassengerAdult:
+			adults++
+		case model.PassengerInfant:
+			infants++
+		}
+		manifest[i] = model.Passenger{
+			ID:               uuid.New().String(),
+			PassengerDetails: passenger,
+			FarePrice:        s.passengerPricing.Price(passenger.Type, farePrice),
+		}
+	}
+
+	if adults == 0 {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNoAdult, http.StatusBadRequest)
+	}
+	if infants > adults {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgTooManyInfants, http.StatusBadRequest)
+	}
+	if details[0].Contact == nil || (details[0].Contact.Email == "" && details[0].Contact.Phone == "") {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgLeadContact, http.StatusBadRequest)
+	}
+	return manifest, nil
+}
+
+// checkPassenger checks a passenger travels as the type their age calls for,
+// on a document that is still valid when the flight departs
+func checkPassenger(passenger *model.PassengerDetails, departure time.Time) error {
+	if model.PassengerTypeForAge(passenger.AgeAt(departure)) != passenger.Type {
+		return common.NewAppError(common.ErrInvalidInput, errMsgPassengerAge, http.StatusBadRequest)
+	}
+	if !passenger.Document.ExpiresAt.After(departure) {
+		return common.NewAppError(common.ErrInvalidInput, errMsgDocumentExpires, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// seatedPassengers counts the passengers in a manifest who take a seat
+func seatedPassengers(details []model.PassengerDetails) int {
+	seated := 0
+	for _, passenger := range details {
+		if passenger.Seated() {
+			seated++
+		}
+	}
+	return seated
+}
+
+// UpdatePassenger changes the details of one passenger on a booking. Names can
+// only be changed when the fare allows it.
+func (s *BookingService) UpdatePassenger(ctx context.Context, userID, bookingID, passengerID string, req *model.UpdatePassengerRequest) (*model.BookingResponse, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !model.IsActive(booking.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+
+	var passenger *model.Passenger
+	for i := range booking.Manifest {
+		if booking.Manifest[i].ID == passengerID {
+			passenger = &booking.Manifest[i]
+		}
+	}
+	if passenger == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPassengerNotFound, http.StatusNotFound)
+	}
+
+	renamed := (req.FirstName != nil && *req.FirstName != passenger.FirstName) ||
+		(req.LastName != nil && *req.LastName != passenger.LastName)
+	if renamed && !booking.Rules().NameChanges {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNameChangesBlocked, http.StatusBadRequest)
+	}
+
+	flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+
+	details := passenger.PassengerDetails
+	if req.FirstName != nil {
+		details.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		details.LastName = *req.LastName
+	}
+	if req.DateOfBirth != nil {
+		details.DateOfBirth = *req.DateOfBirth
+	}
+	if req.Nationality != nil {
+		details.Nationality = *req.Nationality
+	}
+	if req.Document != nil {
+		details.Document = *req.Document
+	}
+	if req.Contact != nil {
+		details.Contact = req.Contact
+	}
+	if err := checkPassenger(&details, flight.DepartureTime); err != nil {
+		return nil, err
+	}
+
+	note := fmt.Sprintf("Passenger %s updated", passenger.ID)
+	if renamed {
+		note = fmt.Sprintf("Passenger %s renamed from %s %s to %s %s", passenger.ID,
+			passenger.FirstName, passenger.LastName, details.FirstName, details.LastName)
+	}
+	passenger.PassengerDetails = details
+	booking.Record(model.ActionPassengerUpdated, "", note)
+	booking.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+
+	// Invalidate cache
+	s.cache.Del(ctx, cacheKeyPrefix+bookingID)
+
+	return booking.ToResponse(), nil
+}
+
+// QuoteRemoval prices taking a named passenger off a booking without doing it
+func (s *BookingService) QuoteRemoval(ctx context.Context, userID, bookingID, passengerID string) (*model.ChangeQuote, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	quote, _, err := s.quoteRemoval(ctx, booking, passengerID)
+	return quote, err
+}
+
+// RemovePassenger takes a named passenger off a booking, returning their seat
+// and refunding their fare under the fare rules and the refund policy
+func (s *BookingService) RemovePassenger(ctx context.Context, userID, bookingID, passengerID string) (*model.BookingResponse, error) {
+	booking, err := s.findOwnedBooking(ctx, userID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	quote, index, err := s.quoteRemoval(ctx, booking, passengerID)
+	if err != nil {
+		return nil, err
+	}
+
+	passenger := booking.Manifest[index]
+	booking.Manifest = append(booking.Manifest[:index], booking.Manifest[index+1:]...)
+	booking.Passengers = quote.Passengers
+	booking.Fees += quote.ChangeFee + quote.Retained
+	booking.TotalPrice = quote.TotalPrice
+	booking.Record(model.ActionPassengerRemoved, "", fmt.Sprintf("Passenger %s %s %s removed, refund %.2f",
+		passenger.ID, passenger.FirstName, passenger.LastName, quote.Refund))
+	booking.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+
+	// Invalidate cache
+	s.cache.Del(ctx, cacheKeyPrefix+bookingID)
+
+	// The seat goes back to the flight only once the booking no longer holds it
+	if passenger.Seated() {
+		if err := s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, -1); err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToRelease, http.StatusInternalServerError)
+		}
+	}
+
+	if err := s.refundRemoval(ctx, booking, quote, []string{passenger.ID}); err != nil {
+		return nil, err
+	}
+
+	return booking.ToResponse(), nil
+}
+
+// quoteRemoval checks a passenger can be taken off a booking, leaving an adult
+// for every infant, and prices it. It returns the passenger's place in the
+// manifest.
+func (s *BookingService) quoteRemoval(ctx context.Context, booking *model.Booking, passengerID string) (*model.ChangeQuote, int, error) {
+	if !model.IsActive(booking.Status) {
+		return nil, 0, common.NewAppError(common.ErrInvalidInput, errMsgBookingInactive, http.StatusBadRequest)
+	}
+
+	index := -1
+	adults, infants := 0, 0
+	for i, passenger := range booking.Manifest {
+		if passenger.ID == passengerID {
+			index = i
+			continue
+		}
+		switch passenger.Type {
+		case model.PassengerAdult:
+			adults++
+		case model.PassengerInfant:
+			infants++
+		}
+	}
+	if index < 0 {
+		return nil, 0, common.NewAppError(common.ErrNotFound, errMsgPassengerNotFound, http.StatusNotFound)
+	}
+	if adults == 0 {
+		return nil, 0, common.NewAppError(common.ErrInvalidInput, errMsgNoAdult, http.StatusBadRequest)
+	}
+	if infants > adults {
+		return nil, 0, common.NewAppError(common.ErrInvalidInput, errMsgTooManyInfants, http.StatusBadRequest)
+	}
+	passenger := &booking.Manifest[index]
+	if passenger.Seated() && len(booking.Seats) >= booking.Passengers {
+		return nil, 0, common.NewAppError(common.ErrInvalidInput, errMsgSeatsExceedPax, http.StatusBadRequest)
+	}
+
+	flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
+	if err != nil || flight == nil {
+		return nil, 0, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	deadline := booking.Rules().ChangeDeadline(flight.DepartureTime)
+	if time.Now().After(deadline) {
+		return nil, 0, common.NewAppError(common.ErrInvalidInput, errMsgChangesClosed, http.StatusBadRequest)
+	}
+
+	share := s.refundPolicy.Share(flight.DepartureTime, time.Now())
+	return booking.QuoteRemoval(passenger, deadline, share), index, nil
+}
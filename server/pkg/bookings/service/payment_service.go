@@ -0,0 +1,409 @@
+// pkg/bookings/service/payment_service.go
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgPaymentsDisabled    = "Payments are not configured"
+	errMsgNotPayable          = "Only pending bookings can be paid for"
+	errMsgPaymentInProgress   = "A payment for this booking is already in progress"
+	errMsgPaymentNotFound     = "Payment not found"
+	errMsgInvalidWebhook      = "Invalid webhook signature"
+	errMsgGatewayFailed       = "The payment processor could not be reached"
+	errMsgFailedToSavePayment = "Failed to save payment"
+	errMsgNoChallenge         = "The payment processor does not simulate 3-D Secure challenges"
+	errMsgRefundFailed        = "The payment processor did not refund the payment"
+	errMsgChangeNeedsPayment  = "Give a payment_method to pay for this change"
+	errMsgChangeNotPaid       = "The payment for this change was not taken"
+)
+
+// PaymentGateway takes payments through a payment processor. Authorized
+// amounts are held on the payment method until captured or voided.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, req *model.AuthorizationRequest) (*model.GatewayResult, error)
+	Capture(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error)
+	Void(ctx context.Context, reference string) (*model.GatewayResult, error)
+	Refund(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error)
+	// ParseWebhook checks the signature of a webhook and returns its event
+	ParseWebhook(payload []byte, signature string) (*model.PaymentEvent, error)
+}
+
+// ChallengeSimulator is implemented by gateways that can play the customer's
+// part in a 3-D Secure challenge, so payments can be tested offline. It
+// returns the signed webhook the processor sends once the challenge ends.
+type ChallengeSimulator interface {
+	CompleteChallenge(reference string, approve bool) (payload []byte, signature string, err error)
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *model.Payment) error
+	FindByID(ctx context.Context, id string) (*model.Payment, error)
+	// FindByReference returns nil when no payment has the processor's reference
+	FindByReference(ctx context.Context, reference string) (*model.Payment, error)
+	Update(ctx context.Context, payment *model.Payment) error
+	ListByBooking(ctx context.Context, bookingID string) ([]*model.Payment, error)
+}
+
+// WithPayments takes payments for bookings through the gateway
+func (s *BookingService) WithPayments(repo PaymentRepository, gateway PaymentGateway) *BookingService {
+	s.payments = repo
+	s.gateway = gateway
+	return s
+}
+
+// PayBooking pays for a pending booking. The booking is claimed for the
+// payment first, so it cannot be paid twice at once. An authorized payment is
+// captured straight away, which confirms the booking; a declined one expires
+// the booking and returns its seats. Payments needing a 3-D Secure challenge
+// carry on when the processor reports the outcome to the webhook.
+func (s *BookingService) PayBooking(ctx context.Context, userID, id string, req *model.PayBookingRequest) (*model.PaymentResponse, error) {
+	if s.gateway == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentsDisabled, http.StatusNotFound)
+	}
+	booking, err := s.findOwnedBooking(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != model.BookingStatusPending {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgNotPayable, http.StatusConflict)
+	}
+	if booking.PaymentStatus == model.PaymentStatusRequiresAction || booking.PaymentStatus == model.PaymentStatusProcessing {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgPaymentInProgress, http.StatusConflict)
+	}
+
+	now := time.Now()
+	payment := &model.Payment{
+		ID:        uuid.New().String(),
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		Amount:    booking.TotalPrice,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	claimed, err := s.repo.ClaimPayment(ctx, booking.ID, payment.ID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+	if !claimed {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgPaymentInProgress, http.StatusConflict)
+	}
+	unclaimed := *booking
+	booking.PaymentStatus = model.PaymentStatusProcessing
+	booking.PaymentID = payment.ID
+
+	result, err := s.gateway.Authorize(ctx, &model.AuthorizationRequest{
+		PaymentID:     payment.ID,
+		Amount:        payment.Amount,
+		PaymentMethod: req.PaymentMethod,
+	})
+	if err != nil {
+		s.unclaimPayment(ctx, &unclaimed)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgGatewayFailed, http.StatusBadGateway)
+	}
+	payment.Reference = result.Reference
+	payment.Status = result.Status
+	payment.DeclineCode = result.DeclineCode
+	payment.ActionURL = result.ActionURL
+	if err := s.payments.Create(ctx, payment); err != nil {
+		s.gateway.Void(ctx, payment.Reference)
+		s.unclaimPayment(ctx, &unclaimed)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+	}
+
+	if err := s.settleAuthorization(ctx, booking, payment); err != nil {
+		return nil, err
+	}
+	return &model.PaymentResponse{Payment: payment, Booking: booking.ToResponse()}, nil
+}
+
+// unclaimPayment puts back a booking claimed for a payment that was never
+// taken, so it can be paid again
+func (s *BookingService) unclaimPayment(ctx context.Context, booking *model.Booking) {
+	s.repo.Update(ctx, booking)
+	s.cache.Del(ctx, cacheKeyPrefix+booking.ID)
+}
+
+// ListPayments lists the payments made for a booking
+func (s *BookingService) ListPayments(ctx context.Context, userID, id string) ([]*model.Payment, error) {
+	if s.payments == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentsDisabled, http.StatusNotFound)
+	}
+	if _, err := s.findOwnedBooking(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	payments, err := s.payments.ListByBooking(ctx, id)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgPaymentNotFound, http.StatusInternalServerError)
+	}
+	return payments, nil
+}
+
+// HandlePaymentWebhook applies an event signed by the payment processor.
+// Events that no longer apply to a payment, such as repeats, are ignored.
+func (s *BookingService) HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error {
+	if s.gateway == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgPaymentsDisabled, http.StatusNotFound)
+	}
+	event, err := s.gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		return common.NewAppError(common.ErrUnauthorized, errMsgInvalidWebhook, http.StatusUnauthorized)
+	}
+
+	payment, err := s.payments.FindByReference(ctx, event.Reference)
+	if err != nil || payment == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+	booking, err := s.repo.FindByID(ctx, payment.BookingID)
+	if err != nil || booking == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+	}
+
+	switch event.Type {
+	case model.PaymentEventAuthorized:
+		if payment.Status != model.PaymentRequiresAction {
+			return nil
+		}
+		payment.Status = model.PaymentAuthorized
+	case model.PaymentEventDeclined:
+		if payment.Status != model.PaymentRequiresAction && payment.Status != model.PaymentAuthorized {
+			return nil
+		}
+		payment.Status = model.PaymentDeclined
+		payment.DeclineCode = event.DeclineCode
+	default:
+		return nil
+	}
+	payment.ActionURL = ""
+	return s.settleAuthorization(ctx, booking, payment)
+}
+
+// SimulateChallenge ends the 3-D Secure challenge of a payment on one of a
+// user's bookings through a gateway that can simulate one, and applies the
+// webhook it sends
+func (s *BookingService) SimulateChallenge(ctx context.Context, userID, reference string, req *model.ChallengeRequest) (*model.PaymentResponse, error) {
+	simulator, ok := s.gateway.(ChallengeSimulator)
+	if !ok {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgNoChallenge, http.StatusNotFound)
+	}
+	payment, err := s.payments.FindByReference(ctx, reference)
+	if err != nil || payment == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+	if _, err := s.findOwnedBooking(ctx, userID, payment.BookingID); err != nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+
+	payload, signature, err := simulator.CompleteChallenge(reference, req.Approve)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+	if err := s.HandlePaymentWebhook(ctx, payload, signature); err != nil {
+		return nil, err
+	}
+
+	payment, err = s.payments.FindByReference(ctx, reference)
+	if err != nil || payment == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+	booking, err := s.repo.FindByID(ctx, payment.BookingID)
+	if err != nil || booking == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+	}
+	return &model.PaymentResponse{Payment: payment, Booking: booking.ToResponse()}, nil
+}
+
+// settleAuthorization moves a booking on from where its payment's
+// authorization stands: authorized payments are captured and confirm the
+// booking, declined ones expire it, and challenged ones wait. The payment
+// and booking are saved. A payment that cannot be captured is voided, and a
+// captured one that cannot confirm the booking is refunded.
+func (s *BookingService) settleAuthorization(ctx context.Context, booking *model.Booking, payment *model.Payment) error {
+	if payment.Status == model.PaymentAuthorized {
+		result, err := s.gateway.Capture(ctx, payment.Reference, payment.Amount)
+		if err != nil {
+			s.returnPayment(ctx, payment)
+			booking.PaymentStatus = model.PaymentStatusVoided
+			s.unclaimPayment(ctx, booking)
+			return common.NewAppError(common.ErrInternalServer, errMsgGatewayFailed, http.StatusBadGateway)
+		}
+		payment.Status = result.Status
+		payment.DeclineCode = result.DeclineCode
+	}
+	payment.UpdatedAt = time.Now()
+	if err := s.payments.Update(ctx, payment); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+	}
+
+	var change *statusChange
+	var err error
+	switch payment.Status {
+	case model.PaymentCaptured:
+		if change, err = s.transition(ctx, booking, model.BookingStatusConfirmed); err != nil {
+			s.returnUnbooked(ctx, booking.ID, payment)
+			return err
+		}
+		booking.PaymentStatus = model.PaymentStatusPaid
+	case model.PaymentDeclined:
+		booking.Record(model.ActionPaymentDeclined, "", payment.DeclineCode)
+		if change, err = s.transition(ctx, booking, model.BookingStatusExpired); err != nil {
+			return err
+		}
+		booking.PaymentStatus = model.PaymentStatusDeclined
+	case model.PaymentRequiresAction:
+		booking.PaymentStatus = model.PaymentStatusRequiresAction
+	}
+	booking.UpdatedAt = time.Now()
+
+	if change != nil {
+		if err := s.commit(ctx, booking, change); err != nil {
+			if payment.Status == model.PaymentCaptured {
+				s.returnUnbooked(ctx, booking.ID, payment)
+			}
+			return err
+		}
+		return nil
+	}
+	if err := s.repo.Update(ctx, booking); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+
+	// Invalidate cache
+	s.cache.Del(ctx, cacheKeyPrefix+booking.ID)
+
+	return nil
+}
+
+// returnUnbooked refunds a captured payment that could not confirm its
+// booking, unless the booking was confirmed with it all the same, as when
+// the processor reports the same payment twice
+func (s *BookingService) returnUnbooked(ctx context.Context, bookingID string, payment *model.Payment) {
+	booking, err := s.repo.FindByID(ctx, bookingID)
+	if err != nil {
+		return
+	}
+	if booking != nil && booking.PaymentID == payment.ID && booking.PaymentTaken() {
+		return
+	}
+	s.returnPayment(ctx, payment)
+}
+
+// voidablePayment returns the payment of a booking leaving an active status
+// if it was only authorized or is still challenged, and must be voided so no
+// money is taken. Bookings paid outside the gateway have none.
+func (s *BookingService) voidablePayment(ctx context.Context, booking *model.Booking) (*model.Payment, error) {
+	if s.gateway == nil || booking.PaymentID == "" {
+		return nil, nil
+	}
+	payment, err := s.payments.FindByID(ctx, booking.PaymentID)
+	if err != nil || payment == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentNotFound, http.StatusNotFound)
+	}
+	if payment.Status != model.PaymentAuthorized && payment.Status != model.PaymentRequiresAction {
+		return nil, nil
+	}
+	return payment, nil
+}
+
+// voidPayment voids a payment at the payment processor and saves it
+func (s *BookingService) voidPayment(ctx context.Context, payment *model.Payment) error {
+	result, err := s.gateway.Void(ctx, payment.Reference)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgGatewayFailed, http.StatusBadGateway)
+	}
+
+	payment.Status = result.Status
+	payment.UpdatedAt = time.Now()
+	if err := s.payments.Update(ctx, payment); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// chargeChange takes what a change costs on a booking already paid for, as a
+// payment of its own. A payment that is not captured straight away, such as
+// one needing a 3-D Secure challenge, is voided and fails the change.
+func (s *BookingService) chargeChange(ctx context.Context, booking *model.Booking, amount float64, method string) (*model.Payment, error) {
+	if s.gateway == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPaymentsDisabled, http.StatusNotFound)
+	}
+	if method == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgChangeNeedsPayment, http.StatusBadRequest)
+	}
+
+	now := time.Now()
+	payment := &model.Payment{
+		ID:        uuid.New().String(),
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		Amount:    amount,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	result, err := s.gateway.Authorize(ctx, &model.AuthorizationRequest{
+		PaymentID:     payment.ID,
+		Amount:        amount,
+		PaymentMethod: method,
+	})
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgGatewayFailed, http.StatusBadGateway)
+	}
+	payment.Reference = result.Reference
+	payment.Status = result.Status
+	payment.DeclineCode = result.DeclineCode
+	if err := s.payments.Create(ctx, payment); err != nil {
+		s.gateway.Void(ctx, payment.Reference)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+	}
+
+	if payment.Status == model.PaymentAuthorized {
+		if result, err := s.gateway.Capture(ctx, payment.Reference, amount); err == nil {
+			payment.Status = result.Status
+			payment.DeclineCode = result.DeclineCode
+		}
+	}
+	if payment.Status != model.PaymentCaptured {
+		s.returnPayment(ctx, payment)
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgChangeNotPaid, http.StatusPaymentRequired)
+	}
+	payment.UpdatedAt = time.Now()
+	if err := s.payments.Update(ctx, payment); err != nil {
+		s.returnPayment(ctx, payment)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+	}
+	return payment, nil
+}
+
+// returnPayment gives back a payment for something that did not go through,
+// voiding it while it is only authorized or challenged and refunding it once
+// captured, and saves it. It does its best; what it cannot give back is left
+// on the payment's record.
+func (s *BookingService) returnPayment(ctx context.Context, payment *model.Payment) {
+	var result *model.GatewayResult
+	var err error
+	switch payment.Status {
+	case model.PaymentAuthorized, model.PaymentRequiresAction:
+		result, err = s.gateway.Void(ctx, payment.Reference)
+	case model.PaymentCaptured:
+		if result, err = s.gateway.Refund(ctx, payment.Reference, payment.Amount); err == nil && result.Status == model.PaymentRefunded {
+			payment.Refunded = payment.Amount
+		}
+	default:
+		return
+	}
+	if err != nil {
+		return
+	}
+	payment.Status = result.Status
+	payment.ActionURL = ""
+	payment.UpdatedAt = time.Now()
+	s.payments.Update(ctx, payment)
+}
@@ -0,0 +1,278 @@
+// pkg/bookings/service/refund_service.go
+
+package service
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgRefundsDisabled    = "Refunds are not recorded"
+	errMsgRefundNotFound     = "Refund not found"
+	errMsgFailedToSaveRefund = "Failed to save refund"
+)
+
+type RefundRepository interface {
+	Create(ctx context.Context, refund *model.Refund) error
+	// FindByID returns nil when no refund has the ID
+	FindByID(ctx context.Context, id string) (*model.Refund, error)
+	Update(ctx context.Context, refund *model.Refund) error
+	ListByBooking(ctx context.Context, bookingID string) ([]*model.Refund, error)
+}
+
+// WithRefunds keeps a record of every refund owed or paid on a booking
+func (s *BookingService) WithRefunds(repo RefundRepository) *BookingService {
+	s.refunds = repo
+	return s
+}
+
+// WithRefundPolicy sets how much of a refund is given back as departure nears
+func (s *BookingService) WithRefundPolicy(policy model.RefundPolicy) *BookingService {
+	s.refundPolicy = policy
+	return s
+}
+
+// ListRefunds lists the refunds owed or paid on one of a user's bookings, oldest first
+func (s *BookingService) ListRefunds(ctx context.Context, userID, id string) ([]*model.Refund, error) {
+	if _, err := s.findOwnedBooking(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.listRefunds(ctx, id)
+}
+
+// GuestRefunds lists the refunds on a booking retrieved by record locator
+func (s *BookingService) GuestRefunds(ctx context.Context, req *model.ManageBookingRequest) ([]*model.Refund, error) {
+	booking, _, err := s.findGuestBooking(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.listRefunds(ctx, booking.ID)
+}
+
+func (s *BookingService) listRefunds(ctx context.Context, id string) ([]*model.Refund, error) {
+	if s.refunds == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgRefundsDisabled, http.StatusNotFound)
+	}
+	refunds, err := s.refunds.ListByBooking(ctx, id)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgRefundNotFound, http.StatusInternalServerError)
+	}
+	return refunds, nil
+}
+
+// CompleteRefund pays out a refund still owed, retrying the payment processor
+// for bookings paid through it and recording the refund as paid by hand
+// otherwise. Completing the refund for a cancellation moves the booking to
+// refunded.
+func (s *BookingService) CompleteRefund(ctx context.Context, bookingID, refundID string) (*model.Refund, error) {
+	if s.refunds == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgRefundsDisabled, http.StatusNotFound)
+	}
+	refund, err := s.refunds.FindByID(ctx, refundID)
+	if err != nil || refund == nil || refund.BookingID != bookingID {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgRefundNotFound, http.StatusNotFound)
+	}
+	if refund.Status == model.RefundSucceeded {
+		return refund, nil
+	}
+
+	booking, err := s.repo.FindByID(ctx, bookingID)
+	if err != nil || booking == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+	}
+	if booking.RefundID != refund.ID {
+		if err := s.payRefund(ctx, refund); err != nil {
+			return nil, err
+		}
+		return refund, nil
+	}
+
+	if _, err := s.TransitionStatus(ctx, bookingID, model.BookingStatusRefunded); err != nil {
+		return nil, err
+	}
+	return s.refunds.FindByID(ctx, refundID)
+}
+
+// quoteCancellation works out the refund for cancelling a booking now, with
+// the share the refund policy gives back this close to departure
+func (s *BookingService) quoteCancellation(ctx context.Context, booking *model.Booking) (*model.CancellationQuote, error) {
+	if !model.IsActive(booking.Status) {
+		return booking.QuoteCancellation(0), nil
+	}
+	flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+	}
+	return booking.QuoteCancellation(s.refundPolicy.Share(flight.DepartureTime, time.Now())), nil
+}
+
+// recordRefund records a refund owed on a booking against the payment the
+// booking was paid with. Money is never owed without a record of it, so it
+// fails when refunds are not recorded.
+func (s *BookingService) recordRefund(ctx context.Context, booking *model.Booking, amount float64, reason string, passengerIDs []string) (*model.Refund, error) {
+	if s.refunds == nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgRefundsDisabled, http.StatusInternalServerError)
+	}
+	refund := newRefund(booking, amount, reason, passengerIDs)
+	if err := s.refunds.Create(ctx, refund); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveRefund, http.StatusInternalServerError)
+	}
+	return refund, nil
+}
+
+// newRefund makes the record of a refund owed on a booking, against the
+// payment the booking was paid with
+func newRefund(booking *model.Booking, amount float64, reason string, passengerIDs []string) *model.Refund {
+	now := time.Now()
+	return &model.Refund{
+		ID:           uuid.New().String(),
+		BookingID:    booking.ID,
+		UserID:       booking.UserID,
+		PaymentID:    booking.PaymentID,
+		PassengerIDs: passengerIDs,
+		Reason:       reason,
+		Amount:       amount,
+		Status:       model.RefundPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// cancellationRefund returns the record of the refund owed for cancelling a
+// booking, recording one for bookings cancelled before refunds were recorded
+func (s *BookingService) cancellationRefund(ctx context.Context, booking *model.Booking) (*model.Refund, error) {
+	if s.refunds != nil && booking.RefundID != "" {
+		if refund, err := s.refunds.FindByID(ctx, booking.RefundID); err == nil && refund != nil {
+			return refund, nil
+		}
+	}
+	refund, err := s.recordRefund(ctx, booking, booking.Refund, model.RefundReasonCancellation, nil)
+	if err != nil {
+		return nil, err
+	}
+	booking.RefundID = refund.ID
+	return refund, nil
+}
+
+// refundCancellation pays the refund owed on a saved, cancelled booking
+// straight away when the booking was paid through the payment processor, and
+// saves it as refunded. A refund the processor turns down is marked failed
+// and stays owed. Refunds on bookings paid outside the processor wait for an
+// admin to complete them.
+func (s *BookingService) refundCancellation(ctx context.Context, booking *model.Booking) error {
+	if booking.PaymentStatus != model.PaymentStatusRefundPending || booking.PaymentID == "" || s.gateway == nil {
+		return nil
+	}
+	change, err := s.transition(ctx, booking, model.BookingStatusRefunded)
+	if err != nil {
+		return err
+	}
+	return s.commit(ctx, booking, change)
+}
+
+// refundRemoval records the refund for passengers taken off a saved, paid
+// booking, less the change fee, and pays it straight away when the booking
+// was paid through the payment processor. A refund the processor turns down
+// is marked failed and stays owed.
+func (s *BookingService) refundRemoval(ctx context.Context, booking *model.Booking, quote *model.ChangeQuote, passengerIDs []string) error {
+	amount := quote.Refund - quote.AmountDue
+	if booking.PaymentStatus != model.PaymentStatusPaid || amount <= 0 {
+		return nil
+	}
+	refund, err := s.recordRefund(ctx, booking, amount, model.RefundReasonPassengerRemoved, passengerIDs)
+	if err != nil {
+		return err
+	}
+	if refund.PaymentID == "" || s.gateway == nil {
+		return nil
+	}
+	return s.payRefund(ctx, refund)
+}
+
+// payRefund pays a refund back to the payments the booking was paid with,
+// starting with the one the refund names, or records it as paid by hand when
+// the booking was paid outside the payment processor. A refund the processor
+// turns down is marked failed; what was paid back before then is kept, so a
+// retry pays only the rest.
+func (s *BookingService) payRefund(ctx context.Context, refund *model.Refund) error {
+	now := time.Now()
+	refund.UpdatedAt = now
+	if refund.PaymentID != "" && s.gateway != nil {
+		payments, err := s.refundablePayments(ctx, refund)
+		if err != nil {
+			return err
+		}
+		for _, payment := range payments {
+			left := refund.Amount - refund.Paid
+			if left < 0.005 {
+				break
+			}
+			amount := math.Min(left, payment.Amount-payment.Refunded)
+			result, err := s.gateway.Refund(ctx, payment.Reference, amount)
+			if err != nil || result.Status != model.PaymentRefunded {
+				refund.Status = model.RefundFailed
+				s.saveRefund(ctx, refund)
+				return common.NewAppError(common.ErrInternalServer, errMsgRefundFailed, http.StatusBadGateway)
+			}
+			refund.Paid += amount
+
+			payment.Status = result.Status
+			payment.Refunded += amount
+			payment.UpdatedAt = now
+			if err := s.payments.Update(ctx, payment); err != nil {
+				s.saveRefund(ctx, refund)
+				return common.NewAppError(common.ErrInternalServer, errMsgFailedToSavePayment, http.StatusInternalServerError)
+			}
+		}
+		if refund.Amount-refund.Paid >= 0.005 {
+			refund.Status = model.RefundFailed
+			s.saveRefund(ctx, refund)
+			return common.NewAppError(common.ErrInternalServer, errMsgRefundFailed, http.StatusBadGateway)
+		}
+	}
+
+	refund.Status = model.RefundSucceeded
+	refund.RefundedAt = &now
+	return s.saveRefund(ctx, refund)
+}
+
+// refundablePayments lists the captured payments of a booking with money left
+// to refund, the payment a refund names first and the rest oldest first
+func (s *BookingService) refundablePayments(ctx context.Context, refund *model.Refund) ([]*model.Payment, error) {
+	all, err := s.payments.ListByBooking(ctx, refund.BookingID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgPaymentNotFound, http.StatusInternalServerError)
+	}
+	var payments []*model.Payment
+	for _, payment := range all {
+		if payment.Status != model.PaymentCaptured && payment.Status != model.PaymentRefunded {
+			continue
+		}
+		if payment.Amount-payment.Refunded < 0.005 {
+			continue
+		}
+		if payment.ID == refund.PaymentID {
+			payments = append([]*model.Payment{payment}, payments...)
+		} else {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (s *BookingService) saveRefund(ctx context.Context, refund *model.Refund) error {
+	if s.refunds == nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgRefundsDisabled, http.StatusInternalServerError)
+	}
+	if err := s.refunds.Update(ctx, refund); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveRefund, http.StatusInternalServerError)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// pkg/bookings/service/status_service.go
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+	flightmodel "github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgIllegalTransition = "Booking cannot move from %s to %s"
+	errMsgStatusViaEndpoint = "Booking status can only be changed through its status endpoints"
+	errMsgTicketNeedsNames  = "Name every passenger on the booking before it is ticketed"
+	errMsgFlightNotFlown    = "The flight has not departed yet"
+	errMsgNoRefundDue       = "No refund is due on this booking"
+	errMsgFailedToRelease   = "Failed to update flight seats"
+	errMsgStatusChanged     = "Booking was changed by another request; reload it and try again"
+)
+
+// TransitionStatus moves a booking to a new status if its lifecycle allows
+// it, applying the seat and payment changes that come with the move.
+func (s *BookingService) TransitionStatus(ctx context.Context, id string, status model.BookingStatus) (*model.BookingResponse, error) {
+	booking, err := s.repo.FindByID(ctx, id)
+	if err != nil || booking == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgBookingNotFound, http.StatusNotFound)
+	}
+
+	change, err := s.transition(ctx, booking, status)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commit(ctx, booking, change); err != nil {
+		return nil, err
+	}
+
+	return booking.ToResponse(), nil
+}
+
+// statusChange is what moving a booking to a new status does beyond the
+// booking itself, which is only done once the move is saved
+type statusChange struct {
+	from model.BookingStatus
+	// void is a payment authorized but not captured, to be voided
+	void *model.Payment
+	// release returns the booking's seats, and the seat numbers it held, to the flight
+	release bool
+	seats   []string
+	// refund is recorded as owed
+	refund *model.Refund
+}
+
+// transition checks a move is allowed and applies it to the booking, leaving
+// the caller to save it with commit:
+//   - confirming leaves the payment status alone; only a captured payment
+//     marks the booking paid
+//   - ticketing needs every passenger named
+//   - completing needs the flight to have departed
+//   - cancelling and expiring void a payment not yet captured and return the
+//     seats; cancelling works out the refund and records it as owed
+//   - refunding pays out the refund owed
+func (s *BookingService) transition(ctx context.Context, booking *model.Booking, status model.BookingStatus) (*statusChange, error) {
+	change := &statusChange{from: booking.Status}
+	from := booking.Status
+	if from == "" {
+		from = model.BookingStatusPending
+	}
+	if !model.CanTransition(from, status) {
+		return nil, common.NewAppError(common.ErrInvalidTransition, fmt.Sprintf(errMsgIllegalTransition, from, status), http.StatusConflict)
+	}
+
+	now := time.Now()
+	var action, note string
+	switch status {
+	case model.BookingStatusConfirmed:
+		action = model.ActionConfirmed
+	case model.BookingStatusTicketed:
+		if len(booking.Manifest) == 0 {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgTicketNeedsNames, http.StatusBadRequest)
+		}
+		booking.TicketedAt = &now
+		action = model.ActionTicketed
+	case model.BookingStatusCompleted:
+		flight, err := s.flightService.GetFlight(ctx, booking.FlightID)
+		if err != nil || flight == nil {
+			return nil, common.NewAppError(common.ErrNotFound, errMsgFlightNotFound, http.StatusNotFound)
+		}
+		if !flown(flight.Status) {
+			return nil, common.NewAppError(common.ErrInvalidTransition, errMsgFlightNotFlown, http.StatusConflict)
+		}
+		action = model.ActionCompleted
+	case model.BookingStatusCancelled:
+		quote, err := s.quoteCancellation(ctx, booking)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.giveBack(ctx, booking, change); err != nil {
+			return nil, err
+		}
+		booking.Refund = quote.Refund
+		if quote.Refund > 0 {
+			if s.refunds == nil {
+				return nil, common.NewAppError(common.ErrInternalServer, errMsgRefundsDisabled, http.StatusInternalServerError)
+			}
+			change.refund = newRefund(booking, quote.Refund, model.RefundReasonCancellation, nil)
+			booking.RefundID = change.refund.ID
+			booking.PaymentStatus = model.PaymentStatusRefundPending
+		}
+		action = model.ActionCancelled
+		note = fmt.Sprintf("Refund %.2f after a cancellation fee of %.2f", quote.Refund, quote.CancellationFee)
+		if quote.Forfeited > 0 {
+			note += fmt.Sprintf(" and %.2f kept for cancelling close to departure", quote.Forfeited)
+		}
+	case model.BookingStatusExpired:
+		if err := s.giveBack(ctx, booking, change); err != nil {
+			return nil, err
+		}
+		action = model.ActionExpired
+	case model.BookingStatusRefunded:
+		if booking.PaymentStatus != model.PaymentStatusRefundPending {
+			return nil, common.NewAppError(common.ErrInvalidTransition, errMsgNoRefundDue, http.StatusConflict)
+		}
+		refund, err := s.cancellationRefund(ctx, booking)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.payRefund(ctx, refund); err != nil {
+			return nil, err
+		}
+		booking.PaymentStatus = model.PaymentStatusRefunded
+		action = model.ActionRefunded
+		note = fmt.Sprintf("Refunded %.2f", booking.Refund)
+	}
+
+	booking.Status = status
+	booking.Record(action, "", note)
+	booking.UpdatedAt = now
+	return change, nil
+}
+
+// giveBack marks a booking that stops holding its seats as having given them
+// back, and its payment as voided if it was not captured. Both happen once
+// the booking is saved.
+func (s *BookingService) giveBack(ctx context.Context, booking *model.Booking, change *statusChange) error {
+	payment, err := s.voidablePayment(ctx, booking)
+	if err != nil {
+		return err
+	}
+	if payment != nil {
+		change.void = payment
+		booking.PaymentStatus = model.PaymentStatusVoided
+	}
+	change.release = true
+	change.seats = booking.Seats
+	booking.Seats = nil
+	return nil
+}
+
+// commit saves a booking moved to a new status as long as no other request
+// moved it first, which fails with a conflict and changes nothing. The move's
+// effects follow: the payment is voided, the seats go back to the flight and
+// the refund is recorded as owed. Each is tried even if one before it fails.
+func (s *BookingService) commit(ctx context.Context, booking *model.Booking, change *statusChange) error {
+	saved, err := s.repo.Replace(ctx, booking, change.from)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSave, http.StatusInternalServerError)
+	}
+	if !saved {
+		return common.NewAppError(common.ErrInvalidTransition, errMsgStatusChanged, http.StatusConflict)
+	}
+
+	// Invalidate cache
+	s.cache.Del(ctx, cacheKeyPrefix+booking.ID)
+
+	var errs []error
+	if change.void != nil {
+		errs = append(errs, s.voidPayment(ctx, change.void))
+	}
+	if change.release {
+		errs = append(errs, s.releaseSeats(ctx, booking, change.seats))
+	}
+	if change.refund != nil {
+		if err := s.refunds.Create(ctx, change.refund); err != nil {
+			errs = append(errs, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveRefund, http.StatusInternalServerError))
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseSeats returns the seats of a booking to its flight, with the seat
+// numbers it held
+func (s *BookingService) releaseSeats(ctx context.Context, booking *model.Booking, seats []string) error {
+	if err := s.flightService.UpdateSeats(ctx, booking.FlightID, booking.FareCode, -booking.Passengers); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToRelease, http.StatusInternalServerError)
+	}
+	return s.flightService.ReleaseSeats(ctx, booking.FlightID, booking.ID, seats)
+}
+
+// flown reports whether a flight in the given status has left
+func flown(status string) bool {
+	return status == flightmodel.FlightStatusDeparted ||
+		status == flightmodel.FlightStatusLanded ||
+		status == flightmodel.FlightStatusDiverted
+}
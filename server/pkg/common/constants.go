@@ -18,6 +18,10 @@ const (
 	// Flight statuses
 	FlightStatusScheduled = "scheduled"
 	FlightStatusDelayed   = "delayed"
+	FlightStatusBoarding  = "boarding"
+	FlightStatusDeparted  = "departed"
+	FlightStatusLanded    = "landed"
+	FlightStatusDiverted  = "diverted"
 	FlightStatusCancelled = "cancelled"
 	FlightStatusCompleted = "completed"
 
@@ -25,4 +29,7 @@ const (
 	CacheKeyFlight  = "flight:%s"
 	CacheKeyBooking = "booking:%s"
 	CacheKeyUser    = "user:%s"
+
+	CacheKeyFareCalendar = "fare_calendar:%s:%s:%s:%d"
+	CacheKeyFareQuote    = "fare_quote:%s"
 )
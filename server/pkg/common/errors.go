@@ -20,8 +20,41 @@ var (
 	ErrFlightNotAvailable = errors.New("flight not available")
 	ErrBookingNotFound    = errors.New("booking not found")
 	ErrInsufficientSeats  = errors.New("insufficient seats available")
+	ErrSeatUnavailable    = errors.New("seat not available")
+	ErrInvalidTransition  = errors.New("invalid status transition")
+	ErrDuplicateLocator   = errors.New("record locator already in use")
 )
 
+// errorCodes are the codes error responses carry for each kind of error
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidInput, "invalid_input"},
+	{ErrNotFound, "not_found"},
+	{ErrUnauthorized, "unauthorized"},
+	{ErrForbidden, "forbidden"},
+	{ErrInternalServer, "internal_error"},
+	{ErrInvalidCredentials, "invalid_credentials"},
+	{ErrTokenExpired, "token_expired"},
+	{ErrInvalidToken, "invalid_token"},
+	{ErrFlightNotAvailable, "flight_not_available"},
+	{ErrBookingNotFound, "booking_not_found"},
+	{ErrInsufficientSeats, "insufficient_seats"},
+	{ErrSeatUnavailable, "seat_unavailable"},
+	{ErrInvalidTransition, "invalid_transition"},
+}
+
+// ErrorCode returns the code for the kind of error, or an empty string for unknown errors
+func ErrorCode(err error) string {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+	return ""
+}
+
 // AppError represents an application error
 type AppError struct {
 	Err     error
@@ -49,4 +49,6 @@ type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	// Code names the kind of error for clients to act on
+	Code string `json:"code,omitempty"`
 }
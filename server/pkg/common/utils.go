@@ -15,6 +15,7 @@ func RespondWithError(c echo.Context, err error) error {
 		return c.JSON(appErr.Code, Response{
 			Success: false,
 			Error:   appErr.Error(),
+			Code:    ErrorCode(appErr.Err),
 		})
 	}
 
@@ -24,16 +25,19 @@ func RespondWithError(c echo.Context, err error) error {
 		return c.JSON(http.StatusNotFound, Response{
 			Success: false,
 			Error:   err.Error(),
+			Code:    ErrorCode(err),
 		})
 	case errors.Is(err, ErrUnauthorized):
 		return c.JSON(http.StatusUnauthorized, Response{
 			Success: false,
 			Error:   err.Error(),
+			Code:    ErrorCode(err),
 		})
 	default:
 		return c.JSON(http.StatusInternalServerError, Response{
 			Success: false,
 			Error:   "Internal server error",
+			Code:    ErrorCode(ErrInternalServer),
 		})
 	}
 }
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Siya360/take-flight/server/pkg/common"
 	"github.com/Siya360/take-flight/server/pkg/flights/model"
@@ -25,12 +30,57 @@ func (h *FlightHandler) SearchFlights(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request"})
 	}
 
-	flights, err := h.flightService.SearchFlights(c.Request().Context(), criteria)
+	result, err := h.flightService.SearchFlights(c.Request().Context(), criteria)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return common.RespondWithError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *FlightHandler) SearchItineraries(c echo.Context) error {
+	var criteria model.ItinerarySearchRequest
+	if err := common.ParseJSON(c, &criteria); err != nil {
+		return err
+	}
+
+	itineraries, err := h.flightService.SearchItineraries(c.Request().Context(), criteria)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, itineraries)
+}
+
+func (h *FlightHandler) SearchTrips(c echo.Context) error {
+	var req model.TripSearchRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+
+	options, err := h.flightService.SearchTrips(c.Request().Context(), req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	return common.RespondWithSuccess(c, options)
+}
+
+func (h *FlightHandler) GetFareCalendar(c echo.Context) error {
+	passengers, _ := strconv.Atoi(c.QueryParam("passengers"))
+	req := model.FareCalendarRequest{
+		DepartureCity: c.QueryParam("departure_city"),
+		ArrivalCity:   c.QueryParam("arrival_city"),
+		Month:         c.QueryParam("month"),
+		Passengers:    passengers,
+	}
+
+	calendar, err := h.flightService.GetFareCalendar(c.Request().Context(), req)
+	if err != nil {
+		return common.RespondWithError(c, err)
 	}
 
-	return c.JSON(http.StatusOK, flights)
+	return common.RespondWithSuccess(c, calendar)
 }
 
 func (h *FlightHandler) GetFlight(c echo.Context) error {
@@ -74,3 +124,284 @@ func (h *FlightHandler) DeleteFlight(c echo.Context) error {
 	}
 	return common.RespondWithSuccess(c, map[string]string{"message": "Flight successfully deleted"})
 }
+
+func (h *FlightHandler) GetSeatMap(c echo.Context) error {
+	id := c.Param("id")
+	seatMap, err := h.flightService.GetSeatMap(c.Request().Context(), id)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, seatMap)
+}
+
+func (h *FlightHandler) ConfigureSeatMap(c echo.Context) error {
+	id := c.Param("id")
+	var req model.ConfigureSeatMapRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	seatMap, err := h.flightService.ConfigureSeatMap(c.Request().Context(), id, &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, seatMap)
+}
+
+func (h *FlightHandler) GetPricingRules(c echo.Context) error {
+	rules, err := h.flightService.GetPricingRules(c.Request().Context())
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, rules)
+}
+
+func (h *FlightHandler) UpdatePricingRules(c echo.Context) error {
+	var rules model.PricingRules
+	if err := common.ParseJSON(c, &rules); err != nil {
+		return err
+	}
+
+	adminID, _ := c.Get("user_id").(string)
+
+	updated, err := h.flightService.UpdatePricingRules(c.Request().Context(), adminID, &rules)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, updated)
+}
+
+func (h *FlightHandler) GetOverbookingPolicy(c echo.Context) error {
+	policy, err := h.flightService.GetOverbookingPolicy(c.Request().Context())
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, policy)
+}
+
+func (h *FlightHandler) UpdateOverbookingPolicy(c echo.Context) error {
+	var policy model.OverbookingPolicy
+	if err := common.ParseJSON(c, &policy); err != nil {
+		return err
+	}
+
+	adminID, _ := c.Get("user_id").(string)
+
+	updated, err := h.flightService.UpdateOverbookingPolicy(c.Request().Context(), adminID, &policy)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, updated)
+}
+
+func (h *FlightHandler) GetCacheStats(c echo.Context) error {
+	stats, err := h.flightService.GetCacheStats(c.Request().Context())
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, stats)
+}
+
+// TransitionStatus returns a handler moving a flight to the given status
+func (h *FlightHandler) TransitionStatus(status string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Param("id")
+		var req model.FlightStatusRequest
+		if err := common.ParseJSON(c, &req); err != nil {
+			return err
+		}
+
+		actorID, _ := c.Get("user_id").(string)
+
+		flight, err := h.flightService.TransitionStatus(c.Request().Context(), id, status, actorID, &req)
+		if err != nil {
+			return common.RespondWithError(c, err)
+		}
+		return common.RespondWithSuccess(c, flight)
+	}
+}
+
+func (h *FlightHandler) GetStatusHistory(c echo.Context) error {
+	id := c.Param("id")
+	events, err := h.flightService.GetStatusHistory(c.Request().Context(), id)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, events)
+}
+
+func (h *FlightHandler) GetPriceHistory(c echo.Context) error {
+	id := c.Param("id")
+	points, err := h.flightService.GetPriceHistory(c.Request().Context(), id)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, points)
+}
+
+func (h *FlightHandler) CreatePriceWatch(c echo.Context) error {
+	var req model.PriceWatchRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+
+	userID, _ := c.Get("user_id").(string)
+
+	watch, err := h.flightService.CreatePriceWatch(c.Request().Context(), userID, &req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, watch)
+}
+
+func (h *FlightHandler) ListPriceWatches(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+
+	watches, err := h.flightService.ListPriceWatches(c.Request().Context(), userID)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, watches)
+}
+
+func (h *FlightHandler) DeletePriceWatch(c echo.Context) error {
+	userID, _ := c.Get("user_id").(string)
+
+	if err := h.flightService.DeletePriceWatch(c.Request().Context(), userID, c.Param("id")); err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, map[string]string{"message": "Price watch successfully deleted"})
+}
+
+func (h *FlightHandler) ListSchedules(c echo.Context) error {
+	schedules, err := h.flightService.ListSchedules(c.Request().Context())
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, schedules)
+}
+
+func (h *FlightHandler) GetSchedule(c echo.Context) error {
+	id := c.Param("id")
+	schedule, err := h.flightService.GetSchedule(c.Request().Context(), id)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, schedule)
+}
+
+func (h *FlightHandler) CreateSchedule(c echo.Context) error {
+	var schedule model.ScheduleTemplate
+	if err := common.ParseJSON(c, &schedule); err != nil {
+		return err
+	}
+	created, err := h.flightService.CreateSchedule(c.Request().Context(), &schedule)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, created)
+}
+
+func (h *FlightHandler) UpdateSchedule(c echo.Context) error {
+	id := c.Param("id")
+	var schedule model.ScheduleTemplate
+	if err := common.ParseJSON(c, &schedule); err != nil {
+		return err
+	}
+	updated, err := h.flightService.UpdateSchedule(c.Request().Context(), id, &schedule)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, updated)
+}
+
+func (h *FlightHandler) GenerateSchedules(c echo.Context) error {
+	var req model.GenerateScheduleRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	reports, err := h.flightService.GenerateSchedules(c.Request().Context(), req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, reports)
+}
+
+func (h *FlightHandler) GenerateSchedule(c echo.Context) error {
+	id := c.Param("id")
+	var req model.GenerateScheduleRequest
+	if err := common.ParseJSON(c, &req); err != nil {
+		return err
+	}
+	report, err := h.flightService.GenerateSchedule(c.Request().Context(), id, req)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	return common.RespondWithSuccess(c, report)
+}
+
+// maxImportBytes bounds the size of an uploaded flight file
+const maxImportBytes = 32 << 20
+
+// ImportFlights reads a CSV or SSIM file from the request body. A real import
+// with line errors writes nothing and answers 422 with the report.
+func (h *FlightHandler) ImportFlights(c echo.Context) error {
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	price, _ := strconv.ParseFloat(c.QueryParam("price"), 64)
+	req := model.ImportRequest{
+		Format: c.QueryParam("format"),
+		DryRun: dryRun,
+		Price:  price,
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxImportBytes)
+	report, err := h.flightService.ImportFlights(c.Request().Context(), req, body)
+	if err != nil {
+		return common.RespondWithError(c, err)
+	}
+	if !report.DryRun && len(report.Errors) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, common.Response{
+			Success: false,
+			Data:    report,
+			Error:   "Import has invalid lines; nothing was saved",
+		})
+	}
+	return common.RespondWithSuccess(c, report)
+}
+
+// ExportFlights downloads the flights departing between the from and to
+// dates (YYYY-MM-DD, both inclusive), by default the coming schedule horizon.
+func (h *FlightHandler) ExportFlights(c echo.Context) error {
+	invalid := common.NewAppError(common.ErrInvalidInput, "from and to must be dates as YYYY-MM-DD", http.StatusBadRequest)
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, model.DefaultScheduleHorizonDays)
+	var err error
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return common.RespondWithError(c, invalid)
+		}
+	}
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return common.RespondWithError(c, invalid)
+		}
+	}
+
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = model.FlightFileCSV
+	}
+	req := model.ExportRequest{Format: format, From: from, To: to.AddDate(0, 0, 1)}
+
+	var buf bytes.Buffer
+	if err := h.flightService.ExportFlights(c.Request().Context(), req, &buf); err != nil {
+		return common.RespondWithError(c, err)
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == model.FlightFileSSIM {
+		contentType = echo.MIMETextPlainCharsetUTF8
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=flights.%s", format))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
@@ -25,13 +25,50 @@ func (m *mockFlightRepo) FindByID(ctx context.Context, id string) (*model.Flight
 	return nil, nil
 }
 func (m *mockFlightRepo) Update(ctx context.Context, flight *model.Flight) error { return nil }
-func (m *mockFlightRepo) Delete(ctx context.Context, id string) error            { return nil }
+func (m *mockFlightRepo) Replace(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error) {
+	return true, nil
+}
+func (m *mockFlightRepo) Delete(ctx context.Context, id string) error { return nil }
 func (m *mockFlightRepo) Search(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
 	m.searchCalled = true
 	m.searchArg = criteria
 	return []*model.Flight{{ID: "1", DepartureCity: criteria.DepartureCity}}, nil
 }
-func (m *mockFlightRepo) UpdateSeats(ctx context.Context, id string, seats int) error { return nil }
+func (m *mockFlightRepo) FindDepartures(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	return nil, nil
+}
+func (m *mockFlightRepo) FindByRoute(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	return nil, nil
+}
+func (m *mockFlightRepo) UpdateSeats(ctx context.Context, id string, change int) (bool, error) {
+	return true, nil
+}
+func (m *mockFlightRepo) UpdateFareSeats(ctx context.Context, id, fareCode string, change int) (bool, error) {
+	return true, nil
+}
+func (m *mockFlightRepo) UpdateStatus(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+	return true, nil
+}
+
+func (m *mockFlightRepo) FindBySchedule(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error) {
+	return nil, nil
+}
+
+func (m *mockFlightRepo) FindByFlightNumber(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error) {
+	return nil, nil
+}
+
+func (m *mockFlightRepo) FindDepartingBetween(ctx context.Context, from, to time.Time) ([]*model.Flight, error) {
+	return nil, nil
+}
+
+func (m *mockFlightRepo) UpdateOverbooking(ctx context.Context, id, fareCode string, change int) (bool, error) {
+	return true, nil
+}
+
+func (m *mockFlightRepo) SaveBatch(ctx context.Context, created, updated []*model.Flight) error {
+	return nil
+}
 
 func TestSearchFlights(t *testing.T) {
 	repo := &mockFlightRepo{}
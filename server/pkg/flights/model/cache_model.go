@@ -0,0 +1,11 @@
+package model
+
+// CacheStats counts flight cache hits and misses since the service started.
+// Invalidations counts flights moved to a new cache generation by a write.
+type CacheStats struct {
+	FlightHits    int64 `json:"flight_hits"`
+	FlightMisses  int64 `json:"flight_misses"`
+	SearchHits    int64 `json:"search_hits"`
+	SearchMisses  int64 `json:"search_misses"`
+	Invalidations int64 `json:"invalidations"`
+}
@@ -0,0 +1,28 @@
+package model
+
+// CalendarMonthLayout is the format of the month parameter on calendar requests.
+const CalendarMonthLayout = "2006-01"
+
+// FareCalendarRequest asks for the lowest fare per day on a route for one month.
+type FareCalendarRequest struct {
+	DepartureCity string `json:"departure_city" validate:"required"`
+	ArrivalCity   string `json:"arrival_city" validate:"required"`
+	Month         string `json:"month" validate:"required"`
+	Passengers    int    `json:"passengers" validate:"min=1"`
+}
+
+// DailyFare is the cheapest available fare departing on a given day.
+type DailyFare struct {
+	Date        string  `json:"date"`
+	LowestPrice float64 `json:"lowest_price"`
+	Flights     int     `json:"flights"`
+	Available   bool    `json:"available"`
+}
+
+// FareCalendar lists the cheapest fare for every day of the requested month.
+type FareCalendar struct {
+	DepartureCity string      `json:"departure_city"`
+	ArrivalCity   string      `json:"arrival_city"`
+	Month         string      `json:"month"`
+	Days          []DailyFare `json:"days"`
+}
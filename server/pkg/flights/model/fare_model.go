@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+)
+
+type CabinClass string
+
+const (
+	CabinEconomy  CabinClass = "economy"
+	CabinPremium  CabinClass = "premium"
+	CabinBusiness CabinClass = "business"
+	CabinFirst    CabinClass = "first"
+)
+
+// IsValid reports whether the cabin is one of the cabins we sell.
+func (c CabinClass) IsValid() bool {
+	switch c {
+	case CabinEconomy, CabinPremium, CabinBusiness, CabinFirst:
+		return true
+	}
+	return false
+}
+
+// FareBucket is a booking class within a cabin with its own price and seat count.
+type FareBucket struct {
+	Code  string     `json:"code" bson:"code" validate:"required"`
+	Cabin CabinClass `json:"cabin" bson:"cabin" validate:"required,oneof=economy premium business first"`
+	Price float64    `json:"price" bson:"price" validate:"min=0"`
+	Seats int        `json:"seats" bson:"seats" validate:"min=0"`
+	Rules *FareRules `json:"rules,omitempty" bson:"rules,omitempty"`
+}
+
+// FareRules are the conditions a fare is sold under. Fees are charged per
+// passenger, and changes close ChangeDeadlineHours before departure.
+type FareRules struct {
+	Refundable          bool    `json:"refundable" bson:"refundable"`
+	CancellationFee     float64 `json:"cancellation_fee" bson:"cancellation_fee" validate:"min=0"`
+	ChangeFee           float64 `json:"change_fee" bson:"change_fee" validate:"min=0"`
+	ChangeDeadlineHours int     `json:"change_deadline_hours" bson:"change_deadline_hours" validate:"min=0"`
+	NameChanges         bool    `json:"name_changes" bson:"name_changes"`
+}
+
+// DefaultFareRules apply to fares sold without rules: fully refundable and
+// changeable up to departure for free.
+func DefaultFareRules() FareRules {
+	return FareRules{Refundable: true, NameChanges: true}
+}
+
+// IsValid reports whether the fees and deadline are non-negative.
+func (r FareRules) IsValid() bool {
+	return r.CancellationFee >= 0 && r.ChangeFee >= 0 && r.ChangeDeadlineHours >= 0
+}
+
+// ChangeDeadline returns the last moment a booking on a flight departing at
+// the given time may be changed.
+func (r FareRules) ChangeDeadline(departure time.Time) time.Time {
+	return departure.Add(-time.Duration(r.ChangeDeadlineHours) * time.Hour)
+}
+
+// FareRules returns the rules of the fare, or the default rules when it has none.
+func (b FareBucket) FareRules() FareRules {
+	if b.Rules == nil {
+		return DefaultFareRules()
+	}
+	return *b.Rules
+}
+
+// FindFare returns the fare bucket with the given code.
+func (f *Flight) FindFare(code string) (*FareBucket, bool) {
+	for i := range f.Fares {
+		if f.Fares[i].Code == code {
+			return &f.Fares[i], true
+		}
+	}
+	return nil, false
+}
+
+// HeadlinePrice returns the price a flight is advertised at: the cheapest fare
+// with seats left, or the cheapest fare once every bucket is sold out. Flights
+// without fare buckets keep their Price.
+func (f *Flight) HeadlinePrice() float64 {
+	if len(f.Fares) == 0 {
+		return f.Price
+	}
+	lowest, open := f.Fares[0].Price, f.Fares[0].Seats > 0
+	for _, fare := range f.Fares[1:] {
+		if fare.Seats > 0 && (!open || fare.Price < lowest) {
+			lowest, open = fare.Price, true
+		} else if !open && fare.Price < lowest {
+			lowest = fare.Price
+		}
+	}
+	return lowest
+}
+
+// SelectFare picks the fare to sell for a booking. A fare code selects that
+// bucket; otherwise the cheapest bucket in the cabin with room for every
+// passenger is used. Flights without fare buckets sell all seats as economy
+// at Price from a single pool.
+func (f *Flight) SelectFare(cabin CabinClass, fareCode string, passengers int) (FareBucket, bool) {
+	if len(f.Fares) == 0 {
+		if fareCode != "" || (cabin != "" && cabin != CabinEconomy) || f.AvailableSeats < passengers {
+			return FareBucket{}, false
+		}
+		return FareBucket{Cabin: CabinEconomy, Price: f.Price, Seats: f.AvailableSeats}, true
+	}
+
+	if fareCode != "" {
+		fare, ok := f.FindFare(fareCode)
+		if !ok || fare.Seats < passengers || (cabin != "" && fare.Cabin != cabin) {
+			return FareBucket{}, false
+		}
+		return *fare, true
+	}
+
+	if cabin == "" {
+		cabin = CabinEconomy
+	}
+	var best *FareBucket
+	for i := range f.Fares {
+		fare := &f.Fares[i]
+		if fare.Cabin != cabin || fare.Seats < passengers {
+			continue
+		}
+		if best == nil || fare.Price < best.Price {
+			best = fare
+		}
+	}
+	if best == nil {
+		return FareBucket{}, false
+	}
+	return *best, true
+}
@@ -1,21 +1,70 @@
 package model
 
 import (
+	"strings"
 	"time"
 )
 
 type Flight struct {
-	ID             string    `json:"id" bson:"_id"`
-	FlightNumber   string    `json:"flight_number" bson:"flight_number"`
-	DepartureCity  string    `json:"departure_city" bson:"departure_city"`
-	ArrivalCity    string    `json:"arrival_city" bson:"arrival_city"`
-	DepartureTime  time.Time `json:"departure_time" bson:"departure_time"`
-	ArrivalTime    time.Time `json:"arrival_time" bson:"arrival_time"`
-	AvailableSeats int       `json:"available_seats" bson:"available_seats"`
-	Price          float64   `json:"price" bson:"price"`
-	Status         string    `json:"status" bson:"status"`
-	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+	ID             string       `json:"id" bson:"_id"`
+	FlightNumber   string       `json:"flight_number" bson:"flight_number"`
+	DepartureCity  string       `json:"departure_city" bson:"departure_city"`
+	ArrivalCity    string       `json:"arrival_city" bson:"arrival_city"`
+	DepartureTime  time.Time    `json:"departure_time" bson:"departure_time"`
+	ArrivalTime    time.Time    `json:"arrival_time" bson:"arrival_time"`
+	Capacity       int          `json:"capacity" bson:"capacity"`
+	AvailableSeats int          `json:"available_seats" bson:"available_seats"`
+	Price          float64      `json:"price" bson:"price"`
+	Fares          []FareBucket `json:"fares,omitempty" bson:"fares,omitempty"`
+	Status         string       `json:"status" bson:"status"`
+	ScheduleID     string       `json:"schedule_id,omitempty" bson:"schedule_id,omitempty"`
+	// Overbooking is how many seats beyond Capacity, the physical seats, are
+	// sold from the OverbookingFare bucket. AvailableSeats includes them.
+	Overbooking     int    `json:"overbooking,omitempty" bson:"overbooking,omitempty"`
+	OverbookingFare string `json:"overbooking_fare,omitempty" bson:"overbooking_fare,omitempty"`
+	// Operational times recorded by status transitions
+	EstimatedDepartureTime *time.Time `json:"estimated_departure_time,omitempty" bson:"estimated_departure_time,omitempty"`
+	EstimatedArrivalTime   *time.Time `json:"estimated_arrival_time,omitempty" bson:"estimated_arrival_time,omitempty"`
+	ActualDepartureTime    *time.Time `json:"actual_departure_time,omitempty" bson:"actual_departure_time,omitempty"`
+	ActualArrivalTime      *time.Time `json:"actual_arrival_time,omitempty" bson:"actual_arrival_time,omitempty"`
+	DivertedTo             string     `json:"diverted_to,omitempty" bson:"diverted_to,omitempty"`
+	CreatedAt              time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" bson:"updated_at"`
+	// Quote fields are set on search results priced by the pricing engine
+	QuoteID        string     `json:"quote_id,omitempty" bson:"-"`
+	QuoteExpiresAt *time.Time `json:"quote_expires_at,omitempty" bson:"-"`
+}
+
+// SellableCapacity is the physical capacity plus the overbooking allowance.
+func (f *Flight) SellableCapacity() int {
+	return f.Capacity + f.Overbooking
+}
+
+// Sold returns the number of seats sold, which may exceed Capacity on an overbooked flight.
+func (f *Flight) Sold() int {
+	return f.SellableCapacity() - f.AvailableSeats
+}
+
+// LoadFactor returns the share of capacity already sold.
+func (f *Flight) LoadFactor() float64 {
+	if f.Capacity <= 0 {
+		return 0
+	}
+	sold := f.Sold()
+	if sold < 0 {
+		sold = 0
+	}
+	return float64(sold) / float64(f.Capacity)
+}
+
+// Carrier returns the airline designator of the flight: the first two
+// characters of its flight number.
+func (f *Flight) Carrier() string {
+	number := strings.ToUpper(strings.ReplaceAll(f.FlightNumber, " ", ""))
+	if len(number) < 2 {
+		return number
+	}
+	return number[:2]
 }
 
 type SearchFlightRequest struct {
@@ -23,10 +72,35 @@ type SearchFlightRequest struct {
 	ArrivalCity   string    `json:"arrival_city"`
 	DepartureDate time.Time `json:"departure_date"`
 	Passengers    int       `json:"passengers"`
+	FlexDays      int       `json:"flex_days"`
+	SearchOptions
+}
+
+// MaxFlexDays is the widest ±N day window a flexible search may request.
+const MaxFlexDays = 3
+
+// DepartureWindow returns the departure range to search: the calendar day of
+// DepartureDate in its own location, widened by FlexDays on either side.
+func (r SearchFlightRequest) DepartureWindow() (time.Time, time.Time) {
+	flex := r.FlexDays
+	if flex < 0 {
+		flex = 0
+	}
+	if flex > MaxFlexDays {
+		flex = MaxFlexDays
+	}
+	date := r.DepartureDate
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return day.AddDate(0, 0, -flex), day.AddDate(0, 0, 1+flex)
 }
 
 const (
 	FlightStatusScheduled = "scheduled"
+	FlightStatusDelayed   = "delayed"
+	FlightStatusBoarding  = "boarding"
+	FlightStatusDeparted  = "departed"
+	FlightStatusLanded    = "landed"
+	FlightStatusDiverted  = "diverted"
 	FlightStatusCancelled = "cancelled"
 	FlightStatusCompleted = "completed"
 )
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// DefaultSeatHoldTTL is how long held seats stay out of inventory while the customer pays.
+const DefaultSeatHoldTTL = 15 * time.Minute
+
+type SeatHoldStatus string
+
+const (
+	// SeatHoldActive holds seats until ExpiresAt
+	SeatHoldActive SeatHoldStatus = "active"
+	// SeatHoldConverted seats now belong to the booking in BookingID
+	SeatHoldConverted SeatHoldStatus = "converted"
+	SeatHoldReleased  SeatHoldStatus = "released"
+	SeatHoldExpired   SeatHoldStatus = "expired"
+)
+
+// SeatHold takes seats out of a flight's inventory for a limited time so they
+// cannot be sold to anyone else while a booking is paid for. Seats go back to
+// inventory when the hold is released or expires.
+type SeatHold struct {
+	ID         string         `json:"id" bson:"_id"`
+	FlightID   string         `json:"flight_id" bson:"flight_id"`
+	UserID     string         `json:"user_id" bson:"user_id"`
+	Passengers int            `json:"passengers" bson:"passengers"`
+	Cabin      CabinClass     `json:"cabin" bson:"cabin"`
+	FareCode   string         `json:"fare_code,omitempty" bson:"fare_code,omitempty"`
+	Status     SeatHoldStatus `json:"status" bson:"status"`
+	BookingID  string         `json:"booking_id,omitempty" bson:"booking_id,omitempty"`
+	ExpiresAt  time.Time      `json:"expires_at" bson:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" bson:"updated_at"`
+}
+
+// SeatHoldRequest asks for seats in a cabin or fare bucket of a flight.
+type SeatHoldRequest struct {
+	FlightID   string `json:"flight_id" validate:"required"`
+	Passengers int    `json:"passengers" validate:"required,min=1"`
+	Cabin      string `json:"cabin,omitempty" validate:"omitempty,oneof=economy premium business first"`
+	FareCode   string `json:"fare_code,omitempty"`
+}
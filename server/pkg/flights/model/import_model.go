@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Formats of bulk flight import and export.
+const (
+	FlightFileCSV  = "csv"
+	FlightFileSSIM = "ssim"
+)
+
+// FlightCSVColumns are the columns of a flight CSV file. Imports match them by
+// header name and need every one; other columns are ignored.
+var FlightCSVColumns = []string{
+	"flight_number",
+	"departure_city",
+	"arrival_city",
+	"departure_time",
+	"arrival_time",
+	"capacity",
+	"price",
+}
+
+// FlightCSVExportColumns are written after FlightCSVColumns on export.
+var FlightCSVExportColumns = []string{"id", "available_seats", "status"}
+
+// MaxImportFlights bounds the dated flights one import may create or update.
+const MaxImportFlights = 20000
+
+// ImportRequest describes a bulk flight upload. SSIM files carry no fares, so
+// flights they create are sold at Price.
+type ImportRequest struct {
+	Format string
+	DryRun bool
+	Price  float64
+}
+
+// ImportLineError reports why a line of an import could not be used.
+type ImportLineError struct {
+	Line         int    `json:"line"`
+	FlightNumber string `json:"flight_number,omitempty"`
+	Date         string `json:"date,omitempty"`
+	Message      string `json:"message"`
+}
+
+// ImportReport summarizes a bulk import. Flights are matched on flight number
+// and local departure date. Nothing is written unless the import was not a
+// dry run and every line was valid, in which case Applied is set.
+type ImportReport struct {
+	Format    string            `json:"format"`
+	DryRun    bool              `json:"dry_run"`
+	Applied   bool              `json:"applied"`
+	Flights   int               `json:"flights"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Errors    []ImportLineError `json:"errors"`
+}
+
+// ExportRequest selects the flights departing in [From, To) to export.
+type ExportRequest struct {
+	Format string
+	From   time.Time
+	To     time.Time
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// MaxItineraryStops is the largest number of connections an itinerary may have.
+const MaxItineraryStops = 2
+
+// ItinerarySearchRequest searches a route for itineraries with up to MaxStops
+// connections, or MaxItineraryStops when MaxStops is not set.
+type ItinerarySearchRequest struct {
+	SearchFlightRequest
+	MaxStops *int `json:"max_stops,omitempty"`
+}
+
+// Default connection window applied at airports without an explicit rule.
+const (
+	DefaultMinConnection = 45 * time.Minute
+	DefaultMaxConnection = 6 * time.Hour
+)
+
+// ConnectionWindow bounds the layover allowed between two legs at an airport.
+type ConnectionWindow struct {
+	Min time.Duration `json:"min" yaml:"min"`
+	Max time.Duration `json:"max" yaml:"max"`
+}
+
+// ConnectionPolicy holds the default connection window and per-airport overrides.
+type ConnectionPolicy struct {
+	Default  ConnectionWindow
+	Airports map[string]ConnectionWindow
+}
+
+// DefaultConnectionPolicy returns a policy using the default connection window everywhere.
+func DefaultConnectionPolicy() ConnectionPolicy {
+	return ConnectionPolicy{
+		Default: ConnectionWindow{
+			Min: DefaultMinConnection,
+			Max: DefaultMaxConnection,
+		},
+	}
+}
+
+// WindowFor returns the connection window for the given airport, falling back
+// to the default for any bound the airport rule leaves unset.
+func (p ConnectionPolicy) WindowFor(airport string) ConnectionWindow {
+	window := p.Default
+	if rule, ok := p.Airports[airport]; ok {
+		if rule.Min > 0 {
+			window.Min = rule.Min
+		}
+		if rule.Max > 0 {
+			window.Max = rule.Max
+		}
+	}
+	return window
+}
+
+// Itinerary is an ordered set of flights taking a traveller from origin to destination.
+type Itinerary struct {
+	Legs              []*Flight `json:"legs"`
+	Stops             int       `json:"stops"`
+	DepartureCity     string    `json:"departure_city"`
+	ArrivalCity       string    `json:"arrival_city"`
+	DepartureTime     time.Time `json:"departure_time"`
+	ArrivalTime       time.Time `json:"arrival_time"`
+	DurationMinutes   int       `json:"duration_minutes"`
+	PricePerPassenger float64   `json:"price_per_passenger"`
+	TotalPrice        float64   `json:"total_price"`
+}
+
+// Carriers returns the airlines flying the legs of the itinerary, each once,
+// in the order they are flown.
+func (i *Itinerary) Carriers() []string {
+	var carriers []string
+	for _, leg := range i.Legs {
+		if carrier := leg.Carrier(); !slices.Contains(carriers, carrier) {
+			carriers = append(carriers, carrier)
+		}
+	}
+	return carriers
+}
+
+// ItinerarySearchResult holds one page of the itineraries found for a route.
+// Total counts the itineraries left after filtering and Facets summarize all
+// of them before filtering.
+type ItinerarySearchResult struct {
+	Itineraries []*Itinerary  `json:"itineraries"`
+	Total       int           `json:"total"`
+	NextCursor  string        `json:"next_cursor,omitempty"`
+	Facets      *SearchFacets `json:"facets"`
+}
+
+// NewItinerary builds an itinerary from consecutive legs, pricing it for the
+// given number of passengers.
+func NewItinerary(legs []*Flight, passengers int) *Itinerary {
+	first, last := legs[0], legs[len(legs)-1]
+
+	var price float64
+	for _, leg := range legs {
+		price += leg.Price
+	}
+
+	return &Itinerary{
+		Legs:              legs,
+		Stops:             len(legs) - 1,
+		DepartureCity:     first.DepartureCity,
+		ArrivalCity:       last.ArrivalCity,
+		DepartureTime:     first.DepartureTime,
+		ArrivalTime:       last.ArrivalTime,
+		DurationMinutes:   int(last.ArrivalTime.Sub(first.DepartureTime).Minutes()),
+		PricePerPassenger: price,
+		TotalPrice:        price * float64(passengers),
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// OverbookingPolicyID is the document ID of the active overbooking policy.
+const OverbookingPolicyID = "default"
+
+// OverbookingRule lets flights sell seats beyond their physical capacity:
+// Seats extra seats or, when Seats is zero, Percent of capacity. A rule names
+// either a flight or a route by its departure and arrival airports.
+type OverbookingRule struct {
+	FlightID      string  `json:"flight_id,omitempty" bson:"flight_id,omitempty"`
+	DepartureCity string  `json:"departure_city,omitempty" bson:"departure_city,omitempty"`
+	ArrivalCity   string  `json:"arrival_city,omitempty" bson:"arrival_city,omitempty"`
+	Percent       float64 `json:"percent,omitempty" bson:"percent,omitempty" validate:"min=0,max=100"`
+	Seats         int     `json:"seats,omitempty" bson:"seats,omitempty" validate:"min=0"`
+}
+
+// Allowance returns the extra seats the rule allows on the given capacity.
+func (r *OverbookingRule) Allowance(capacity int) int {
+	if r.Seats > 0 {
+		return r.Seats
+	}
+	return int(math.Floor(float64(capacity) * r.Percent / 100))
+}
+
+// OverbookingPolicy holds the admin-configured overbooking rules.
+type OverbookingPolicy struct {
+	ID        string            `json:"id" bson:"_id"`
+	Rules     []OverbookingRule `json:"rules" bson:"rules" validate:"dive"`
+	UpdatedAt time.Time         `json:"updated_at" bson:"updated_at"`
+	UpdatedBy string            `json:"updated_by" bson:"updated_by"`
+}
+
+// Allowance returns how many seats a flight may sell beyond its capacity. A
+// rule for the flight wins over one for its route; without either the flight
+// is not overbooked.
+func (p *OverbookingPolicy) Allowance(flight *Flight) int {
+	if p == nil {
+		return 0
+	}
+	var route *OverbookingRule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.FlightID != "" {
+			if rule.FlightID == flight.ID {
+				return rule.Allowance(flight.Capacity)
+			}
+			continue
+		}
+		if route == nil && rule.DepartureCity == flight.DepartureCity && rule.ArrivalCity == flight.ArrivalCity {
+			route = rule
+		}
+	}
+	if route != nil {
+		return route.Allowance(flight.Capacity)
+	}
+	return 0
+}
+
+// Overbook sets how many seats the flight sells beyond its capacity. The
+// extra seats are added to AvailableSeats and, on flights with fare buckets,
+// to the cheapest economy bucket, or the cheapest bucket when there is no
+// economy. Shrinking the allowance only takes back seats that are still
+// unsold, so the flight may keep more than allowance until they are returned.
+// It returns the bucket and the change in seats so the same change can be
+// applied to the stored flight.
+func (f *Flight) Overbook(allowance int) (string, int) {
+	if allowance < 0 {
+		allowance = 0
+	}
+	if len(f.Fares) > 0 {
+		if _, ok := f.FindFare(f.OverbookingFare); !ok {
+			// The bucket holding the extra seats is gone, and its seats with it
+			f.Overbooking = 0
+		}
+		if f.Overbooking == 0 {
+			f.OverbookingFare = f.overbookingFare()
+		}
+	} else {
+		f.OverbookingFare = ""
+	}
+
+	change := allowance - f.Overbooking
+	if change < 0 {
+		unsold := f.AvailableSeats
+		if fare, ok := f.FindFare(f.OverbookingFare); ok {
+			unsold = min(unsold, fare.Seats)
+		}
+		change = -min(-change, max(unsold, 0))
+	}
+	if change != 0 {
+		f.AvailableSeats += change
+		if fare, ok := f.FindFare(f.OverbookingFare); ok {
+			fare.Seats += change
+		}
+		f.Overbooking += change
+	}
+	fareCode := f.OverbookingFare
+	if f.Overbooking == 0 {
+		f.OverbookingFare = ""
+	}
+	return fareCode, change
+}
+
+// overbookingFare picks the fare bucket extra seats are sold from
+func (f *Flight) overbookingFare() string {
+	var best *FareBucket
+	for i := range f.Fares {
+		fare := &f.Fares[i]
+		switch {
+		case best == nil:
+			best = fare
+		case (fare.Cabin == CabinEconomy) != (best.Cabin == CabinEconomy):
+			if fare.Cabin == CabinEconomy {
+				best = fare
+			}
+		case fare.Price < best.Price:
+			best = fare
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Code
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+)
+
+type PriceWatchStatus string
+
+const (
+	PriceWatchActive PriceWatchStatus = "active"
+	// PriceWatchTriggered watches have sent their alert
+	PriceWatchTriggered PriceWatchStatus = "triggered"
+	// PriceWatchExpired watches ended without the price reaching the target
+	PriceWatchExpired PriceWatchStatus = "expired"
+)
+
+// Sources of a price change in a flight's price history.
+const (
+	PriceSourceUpdate  = "update"
+	PriceSourcePricing = "pricing"
+)
+
+// PricePoint is one change in the price of a flight.
+type PricePoint struct {
+	ID         string    `json:"id" bson:"_id"`
+	FlightID   string    `json:"flight_id" bson:"flight_id"`
+	Price      float64   `json:"price" bson:"price"`
+	Source     string    `json:"source" bson:"source"`
+	RecordedAt time.Time `json:"recorded_at" bson:"recorded_at"`
+}
+
+// PriceWatch alerts a user once a fare for Passengers drops to TargetPrice or
+// below, on one flight or on any flight of a route departing on a day.
+type PriceWatch struct {
+	ID            string           `json:"id" bson:"_id"`
+	UserID        string           `json:"user_id" bson:"user_id"`
+	FlightID      string           `json:"flight_id,omitempty" bson:"flight_id,omitempty"`
+	DepartureCity string           `json:"departure_city,omitempty" bson:"departure_city,omitempty"`
+	ArrivalCity   string           `json:"arrival_city,omitempty" bson:"arrival_city,omitempty"`
+	DepartureDate *time.Time       `json:"departure_date,omitempty" bson:"departure_date,omitempty"`
+	Passengers    int              `json:"passengers" bson:"passengers"`
+	TargetPrice   float64          `json:"target_price" bson:"target_price"`
+	Status        PriceWatchStatus `json:"status" bson:"status"`
+	// LastPrice is the lowest fare found when the watch was last evaluated, on LastFlightID
+	LastPrice     float64    `json:"last_price,omitempty" bson:"last_price,omitempty"`
+	LastFlightID  string     `json:"last_flight_id,omitempty" bson:"last_flight_id,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty" bson:"last_checked_at,omitempty"`
+	TriggeredAt   *time.Time `json:"triggered_at,omitempty" bson:"triggered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// PriceWatchRequest watches either a flight or a route and departure date.
+type PriceWatchRequest struct {
+	FlightID      string     `json:"flight_id,omitempty"`
+	DepartureCity string     `json:"departure_city,omitempty"`
+	ArrivalCity   string     `json:"arrival_city,omitempty"`
+	DepartureDate *time.Time `json:"departure_date,omitempty"`
+	Passengers    int        `json:"passengers,omitempty" validate:"omitempty,min=1"`
+	TargetPrice   float64    `json:"target_price" validate:"required,gt=0"`
+}
+
+// LowestFare returns the cheapest fare with room for the passengers, or false
+// when the flight cannot seat them.
+func (f *Flight) LowestFare(passengers int) (float64, bool) {
+	if len(f.Fares) == 0 {
+		return f.Price, f.AvailableSeats >= passengers
+	}
+	lowest, found := 0.0, false
+	for _, fare := range f.Fares {
+		if fare.Seats >= passengers && (!found || fare.Price < lowest) {
+			lowest, found = fare.Price, true
+		}
+	}
+	return lowest, found
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// PricingRulesID is the document ID of the active pricing rules.
+const PricingRulesID = "default"
+
+// DefaultQuoteTTL is how long a price shown at search time is honoured.
+const DefaultQuoteTTL = 20 * time.Minute
+
+// LoadFactorTier applies a multiplier once the share of seats sold reaches MinLoadFactor.
+type LoadFactorTier struct {
+	MinLoadFactor float64 `json:"min_load_factor" bson:"min_load_factor" validate:"min=0,max=1"`
+	Multiplier    float64 `json:"multiplier" bson:"multiplier" validate:"gt=0"`
+}
+
+// AdvancePurchaseTier applies a multiplier when departure is at most MaxDaysOut days away.
+type AdvancePurchaseTier struct {
+	MaxDaysOut int     `json:"max_days_out" bson:"max_days_out" validate:"min=0"`
+	Multiplier float64 `json:"multiplier" bson:"multiplier" validate:"gt=0"`
+}
+
+// PricingRules are the admin-configured adjustments applied on top of base fares.
+type PricingRules struct {
+	ID              string                `json:"id" bson:"_id"`
+	Enabled         bool                  `json:"enabled" bson:"enabled"`
+	LoadFactor      []LoadFactorTier      `json:"load_factor" bson:"load_factor" validate:"dive"`
+	AdvancePurchase []AdvancePurchaseTier `json:"advance_purchase" bson:"advance_purchase" validate:"dive"`
+	// DayOfWeek maps lowercase weekday names of the departure day to a multiplier
+	DayOfWeek     map[string]float64 `json:"day_of_week" bson:"day_of_week"`
+	MinMultiplier float64            `json:"min_multiplier" bson:"min_multiplier" validate:"min=0"`
+	MaxMultiplier float64            `json:"max_multiplier" bson:"max_multiplier" validate:"min=0"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	UpdatedBy     string             `json:"updated_by" bson:"updated_by"`
+}
+
+// Multiplier combines the highest matching load factor tier, the closest
+// matching advance purchase tier and the day-of-week adjustment, clamped to
+// the configured bounds.
+func (r *PricingRules) Multiplier(loadFactor float64, daysOut int, weekday time.Weekday) float64 {
+	if r == nil || !r.Enabled {
+		return 1
+	}
+
+	multiplier := 1.0
+
+	lf, lfThreshold := 1.0, -1.0
+	for _, tier := range r.LoadFactor {
+		if loadFactor >= tier.MinLoadFactor && tier.MinLoadFactor > lfThreshold {
+			lf, lfThreshold = tier.Multiplier, tier.MinLoadFactor
+		}
+	}
+	multiplier *= lf
+
+	ap, apDays := 1.0, math.MaxInt
+	for _, tier := range r.AdvancePurchase {
+		if daysOut <= tier.MaxDaysOut && tier.MaxDaysOut < apDays {
+			ap, apDays = tier.Multiplier, tier.MaxDaysOut
+		}
+	}
+	multiplier *= ap
+
+	if dow, ok := r.DayOfWeek[strings.ToLower(weekday.String())]; ok && dow > 0 {
+		multiplier *= dow
+	}
+
+	if r.MinMultiplier > 0 && multiplier < r.MinMultiplier {
+		multiplier = r.MinMultiplier
+	}
+	if r.MaxMultiplier > 0 && multiplier > r.MaxMultiplier {
+		multiplier = r.MaxMultiplier
+	}
+	return multiplier
+}
+
+// FareQuote records the prices shown for a flight so the same prices are
+// charged if it is booked before the quote expires. Prices are keyed by fare
+// code, with an empty code for flights sold from a single pool.
+type FareQuote struct {
+	ID        string             `json:"id"`
+	FlightID  string             `json:"flight_id"`
+	Prices    map[string]float64 `json:"prices"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
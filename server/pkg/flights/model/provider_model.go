@@ -0,0 +1,31 @@
+package model
+
+// CatalogProvider is the name under which our own flight inventory is reported.
+const CatalogProvider = "catalog"
+
+// Outcomes of querying a flight provider.
+const (
+	ProviderStatusOK      = "ok"
+	ProviderStatusError   = "error"
+	ProviderStatusTimeout = "timeout"
+)
+
+// ProviderStatus reports how one flight provider answered a search.
+type ProviderStatus struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Flights int    `json:"flights"`
+	Error   string `json:"error,omitempty"`
+}
+
+// FlightSearchResult holds one page of the merged flights from every
+// provider. Partial is set when at least one provider failed or timed out.
+// Total counts the flights left after filtering, across all pages.
+type FlightSearchResult struct {
+	Flights    []*Flight        `json:"flights"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Facets     *SearchFacets    `json:"facets"`
+	Partial    bool             `json:"partial"`
+	Providers  []ProviderStatus `json:"providers"`
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ScheduleTimeLayout is the layout of a schedule's local departure time.
+const ScheduleTimeLayout = "15:04"
+
+// Horizon over which schedules are materialized into flights.
+const (
+	DefaultScheduleHorizonDays = 60
+	MaxScheduleHorizonDays     = 365
+)
+
+// ScheduleTemplate describes a flight operated on a recurring weekly pattern.
+// DepartureTime is local to TimeZone, DaysOfWeek holds lower-case weekday
+// names ("monday") and the validity period is inclusive of both dates.
+type ScheduleTemplate struct {
+	ID              string       `json:"id" bson:"_id"`
+	FlightNumber    string       `json:"flight_number" bson:"flight_number"`
+	DepartureCity   string       `json:"departure_city" bson:"departure_city"`
+	ArrivalCity     string       `json:"arrival_city" bson:"arrival_city"`
+	DepartureTime   string       `json:"departure_time" bson:"departure_time"`
+	TimeZone        string       `json:"time_zone" bson:"time_zone"`
+	DurationMinutes int          `json:"duration_minutes" bson:"duration_minutes"`
+	DaysOfWeek      []string     `json:"days_of_week" bson:"days_of_week"`
+	ValidFrom       time.Time    `json:"valid_from" bson:"valid_from"`
+	ValidTo         time.Time    `json:"valid_to" bson:"valid_to"`
+	Capacity        int          `json:"capacity" bson:"capacity"`
+	Price           float64      `json:"price" bson:"price"`
+	Fares           []FareBucket `json:"fares,omitempty" bson:"fares,omitempty"`
+	CreatedAt       time.Time    `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" bson:"updated_at"`
+}
+
+// OperatesOn reports whether the schedule flies on the given weekday.
+func (t *ScheduleTemplate) OperatesOn(weekday time.Weekday) bool {
+	name := strings.ToLower(weekday.String())
+	for _, day := range t.DaysOfWeek {
+		if day == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Departures returns the departure times of the schedule falling in [from, to).
+func (t *ScheduleTemplate) Departures(from, to time.Time) ([]time.Time, error) {
+	loc, err := time.LoadLocation(t.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	clock, err := time.Parse(ScheduleTimeLayout, t.DepartureTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validity dates are calendar days in the schedule's time zone
+	day := time.Date(t.ValidFrom.Year(), t.ValidFrom.Month(), t.ValidFrom.Day(), 0, 0, 0, 0, loc)
+	last := time.Date(t.ValidTo.Year(), t.ValidTo.Month(), t.ValidTo.Day(), 0, 0, 0, 0, loc)
+	if start := from.In(loc); start.After(day) {
+		day = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	}
+
+	var departures []time.Time
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		departure := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !departure.Before(to) {
+			break
+		}
+		if departure.Before(from) || !t.OperatesOn(day.Weekday()) {
+			continue
+		}
+		departures = append(departures, departure.UTC())
+	}
+	return departures, nil
+}
+
+// seats returns the number of seats an instance of the schedule is sold with.
+func (t *ScheduleTemplate) seats() int {
+	if len(t.Fares) == 0 {
+		return t.Capacity
+	}
+	seats := 0
+	for _, fare := range t.Fares {
+		seats += fare.Seats
+	}
+	return seats
+}
+
+// Matches reports whether a flight instance departing at the given time
+// already has the route, times, capacity and fares of the schedule. Seats
+// sold out of each fare bucket are not compared.
+func (t *ScheduleTemplate) Matches(flight *Flight, departure time.Time) bool {
+	if flight.FlightNumber != t.FlightNumber ||
+		flight.DepartureCity != t.DepartureCity ||
+		flight.ArrivalCity != t.ArrivalCity ||
+		!flight.DepartureTime.Equal(departure) ||
+		!flight.ArrivalTime.Equal(departure.Add(time.Duration(t.DurationMinutes)*time.Minute)) ||
+		flight.Capacity != t.seats() ||
+		flight.Price != t.Price ||
+		len(flight.Fares) != len(t.Fares) {
+		return false
+	}
+	for i, fare := range t.Fares {
+		if flight.Fares[i].Code != fare.Code || flight.Fares[i].Cabin != fare.Cabin || flight.Fares[i].Price != fare.Price {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply sets the scheduled fields of a flight instance departing at the given
+// time and resets its inventory to the schedule's.
+func (t *ScheduleTemplate) Apply(flight *Flight, departure time.Time) {
+	var fares []FareBucket
+	if len(t.Fares) > 0 {
+		fares = make([]FareBucket, len(t.Fares))
+		copy(fares, t.Fares)
+	}
+
+	flight.ScheduleID = t.ID
+	flight.FlightNumber = t.FlightNumber
+	flight.DepartureCity = t.DepartureCity
+	flight.ArrivalCity = t.ArrivalCity
+	flight.DepartureTime = departure
+	flight.ArrivalTime = departure.Add(time.Duration(t.DurationMinutes) * time.Minute)
+	flight.Capacity = t.seats()
+	flight.AvailableSeats = t.seats()
+	flight.Overbooking = 0
+	flight.OverbookingFare = ""
+	flight.Price = t.Price
+	flight.Fares = fares
+}
+
+// GenerateScheduleRequest sets how many days ahead flights are materialized.
+type GenerateScheduleRequest struct {
+	HorizonDays int `json:"horizon_days"`
+}
+
+// ScheduleConflict describes a scheduled departure the generator could not
+// create or bring in line with its template.
+type ScheduleConflict struct {
+	Departure time.Time `json:"departure"`
+	FlightID  string    `json:"flight_id,omitempty"`
+	Reason    string    `json:"reason"`
+}
+
+// ScheduleGenerationReport summarizes one run of the generator for a schedule.
+type ScheduleGenerationReport struct {
+	ScheduleID string             `json:"schedule_id"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Created    int                `json:"created"`
+	Updated    int                `json:"updated"`
+	Removed    int                `json:"removed"`
+	Conflicts  []ScheduleConflict `json:"conflicts"`
+}
+
+// ScheduleResponse pairs a saved schedule with the flights it generated.
+type ScheduleResponse struct {
+	Schedule   *ScheduleTemplate         `json:"schedule"`
+	Generation *ScheduleGenerationReport `json:"generation"`
+}
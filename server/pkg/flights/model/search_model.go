@@ -0,0 +1,73 @@
+package model
+
+// Sort orders for flight search results. Ties are broken by departure time.
+const (
+	SortByDeparture = "departure"
+	SortByArrival   = "arrival"
+	SortByPrice     = "price"
+	SortByDuration  = "duration"
+)
+
+// Page sizes for flight search results.
+const (
+	DefaultSearchPageSize = 50
+	MaxSearchPageSize     = 200
+)
+
+// SearchOptions narrows, orders and pages flight search results. Time windows
+// are "HH:MM" local to the departure or arrival airport and may wrap past
+// midnight ("22:00" to "02:00"). Zero values leave a filter off.
+type SearchOptions struct {
+	Sort              string   `json:"sort,omitempty"`
+	MinPrice          float64  `json:"min_price,omitempty"`
+	MaxPrice          float64  `json:"max_price,omitempty"`
+	DepartureTimeFrom string   `json:"departure_time_from,omitempty"`
+	DepartureTimeTo   string   `json:"departure_time_to,omitempty"`
+	ArrivalTimeFrom   string   `json:"arrival_time_from,omitempty"`
+	ArrivalTimeTo     string   `json:"arrival_time_to,omitempty"`
+	Carriers          []string `json:"carriers,omitempty"`
+	Cursor            string   `json:"cursor,omitempty"`
+	Limit             int      `json:"limit,omitempty"`
+}
+
+// TimeOfDayBucket is a named part of the day used to facet departure and
+// arrival times. To is exclusive.
+type TimeOfDayBucket struct {
+	Name string
+	From string
+	To   string
+}
+
+// TimeOfDayBuckets split the day for time facets.
+var TimeOfDayBuckets = []TimeOfDayBucket{
+	{Name: "night", From: "00:00", To: "06:00"},
+	{Name: "morning", From: "06:00", To: "12:00"},
+	{Name: "afternoon", From: "12:00", To: "18:00"},
+	{Name: "evening", From: "18:00", To: "24:00"},
+}
+
+// FacetCount is the number of results sharing one value of a facet. From and
+// To are set on time facets so the bucket can be sent back as a filter.
+type FacetCount struct {
+	Value string `json:"value"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Count int    `json:"count"`
+}
+
+// PriceRange is the cheapest and dearest price among results.
+type PriceRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// SearchFacets summarize every flight or itinerary a search found, before
+// filters and paging, so filter sidebars can show the choices available.
+// Stops are only counted for itineraries.
+type SearchFacets struct {
+	Carriers       []FacetCount `json:"carriers"`
+	Stops          []FacetCount `json:"stops,omitempty"`
+	DepartureTimes []FacetCount `json:"departure_times"`
+	ArrivalTimes   []FacetCount `json:"arrival_times"`
+	Price          *PriceRange  `json:"price,omitempty"`
+}
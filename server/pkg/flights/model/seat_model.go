@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+// AisleMarker separates seat letters that sit either side of an aisle in a
+// cabin layout, e.g. "ABC DEF".
+const AisleMarker = ' '
+
+// CabinLayout describes a block of identical rows used to generate seats.
+type CabinLayout struct {
+	Cabin        string   `json:"cabin" bson:"cabin" validate:"required"`
+	FirstRow     int      `json:"first_row" bson:"first_row" validate:"required,min=1"`
+	LastRow      int      `json:"last_row" bson:"last_row" validate:"required,gtefield=FirstRow"`
+	SeatLetters  string   `json:"seat_letters" bson:"seat_letters" validate:"required"`
+	ExitRows     []int    `json:"exit_rows,omitempty" bson:"exit_rows,omitempty"`
+	BlockedSeats []string `json:"blocked_seats,omitempty" bson:"blocked_seats,omitempty"`
+}
+
+// Seat is a single sellable position on a flight.
+type Seat struct {
+	Number    string `json:"number" bson:"number"`
+	Row       int    `json:"row" bson:"row"`
+	Letter    string `json:"letter" bson:"letter"`
+	Cabin     string `json:"cabin" bson:"cabin"`
+	Window    bool   `json:"window" bson:"window"`
+	Aisle     bool   `json:"aisle" bson:"aisle"`
+	ExitRow   bool   `json:"exit_row" bson:"exit_row"`
+	Blocked   bool   `json:"blocked" bson:"blocked"`
+	Occupied  bool   `json:"occupied" bson:"-"`
+	BookingID string `json:"-" bson:"booking_id"`
+}
+
+// SeatMap holds every seat on a flight along with its current assignment.
+type SeatMap struct {
+	FlightID  string        `json:"flight_id" bson:"_id"`
+	Layout    []CabinLayout `json:"layout" bson:"layout"`
+	Seats     []Seat        `json:"seats" bson:"seats"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// ConfigureSeatMapRequest replaces the cabin layout of a flight.
+type ConfigureSeatMapRequest struct {
+	Layout []CabinLayout `json:"layout" validate:"required,min=1,dive"`
+}
+
+// AvailableSeats counts seats that are neither blocked nor assigned.
+func (m *SeatMap) AvailableSeats() int {
+	count := 0
+	for _, seat := range m.Seats {
+		if !seat.Blocked && seat.BookingID == "" {
+			count++
+		}
+	}
+	return count
+}
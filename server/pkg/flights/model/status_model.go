@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+)
+
+// flightTransitions lists the statuses each flight status may move to.
+// Landed, cancelled, diverted and completed are terminal.
+var flightTransitions = map[string][]string{
+	FlightStatusScheduled: {FlightStatusDelayed, FlightStatusBoarding, FlightStatusCancelled},
+	FlightStatusDelayed:   {FlightStatusDelayed, FlightStatusBoarding, FlightStatusCancelled},
+	FlightStatusBoarding:  {FlightStatusDelayed, FlightStatusDeparted, FlightStatusCancelled},
+	FlightStatusDeparted:  {FlightStatusLanded, FlightStatusDiverted},
+}
+
+// SellableStatuses are the statuses under which a flight can still be searched and booked.
+var SellableStatuses = []string{FlightStatusScheduled, FlightStatusDelayed}
+
+// IsSellable reports whether a flight in the given status can still be booked.
+func IsSellable(status string) bool {
+	for _, sellable := range SellableStatuses {
+		if status == sellable {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransition reports whether a flight may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, next := range flightTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// FlightStatusEvent records a single status transition of a flight.
+type FlightStatusEvent struct {
+	ID                     string     `json:"id" bson:"_id"`
+	FlightID               string     `json:"flight_id" bson:"flight_id"`
+	From                   string     `json:"from" bson:"from"`
+	To                     string     `json:"to" bson:"to"`
+	Reason                 string     `json:"reason,omitempty" bson:"reason,omitempty"`
+	EstimatedDepartureTime *time.Time `json:"estimated_departure_time,omitempty" bson:"estimated_departure_time,omitempty"`
+	EstimatedArrivalTime   *time.Time `json:"estimated_arrival_time,omitempty" bson:"estimated_arrival_time,omitempty"`
+	ActualTime             *time.Time `json:"actual_time,omitempty" bson:"actual_time,omitempty"`
+	DivertedTo             string     `json:"diverted_to,omitempty" bson:"diverted_to,omitempty"`
+	RecordedBy             string     `json:"recorded_by" bson:"recorded_by"`
+	CreatedAt              time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// FlightStatusRequest carries the operational details of a status transition.
+// Delays need a new estimated departure, diversions need the airport landed
+// at, and departures and landings default their actual time to now.
+type FlightStatusRequest struct {
+	Reason                 string     `json:"reason,omitempty"`
+	EstimatedDepartureTime *time.Time `json:"estimated_departure_time,omitempty"`
+	EstimatedArrivalTime   *time.Time `json:"estimated_arrival_time,omitempty"`
+	ActualTime             *time.Time `json:"actual_time,omitempty"`
+	DivertedTo             string     `json:"diverted_to,omitempty"`
+}
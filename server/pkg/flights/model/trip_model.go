@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+)
+
+type TripType string
+
+const (
+	TripTypeOneWay    TripType = "one_way"
+	TripTypeRoundTrip TripType = "round_trip"
+	TripTypeMultiCity TripType = "multi_city"
+)
+
+// Limits applied to multi-slice searches to keep combinations bounded.
+const (
+	MaxTripSlices          = 6
+	MaxItinerariesPerSlice = 10
+	MaxTripOptions         = 50
+)
+
+// TripSlice is one origin/destination/date leg of a journey request.
+type TripSlice struct {
+	DepartureCity string    `json:"departure_city" validate:"required"`
+	ArrivalCity   string    `json:"arrival_city" validate:"required"`
+	DepartureDate time.Time `json:"departure_date" validate:"required"`
+}
+
+// TripSearchRequest describes a one-way, round-trip or multi-city search as
+// an ordered list of slices.
+type TripSearchRequest struct {
+	TripType   TripType    `json:"trip_type" validate:"required,oneof=one_way round_trip multi_city"`
+	Slices     []TripSlice `json:"slices" validate:"required,min=1,dive"`
+	Passengers int         `json:"passengers" validate:"required,min=1"`
+	MaxStops   *int        `json:"max_stops,omitempty" validate:"omitempty,min=0"`
+}
+
+// TripOption is a priced combination of one itinerary per requested slice.
+type TripOption struct {
+	TripType          TripType     `json:"trip_type"`
+	Slices            []*Itinerary `json:"slices"`
+	PricePerPassenger float64      `json:"price_per_passenger"`
+	TotalPrice        float64      `json:"total_price"`
+}
+
+// NewTripOption prices a combination of slice itineraries as a whole.
+func NewTripOption(tripType TripType, slices []*Itinerary) *TripOption {
+	option := &TripOption{
+		TripType: tripType,
+		Slices:   slices,
+	}
+	for _, slice := range slices {
+		option.PricePerPassenger += slice.PricePerPassenger
+		option.TotalPrice += slice.TotalPrice
+	}
+	return option
+}
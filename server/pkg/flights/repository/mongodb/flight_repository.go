@@ -41,23 +41,93 @@ func (r *MongoFlightRepository) Update(ctx context.Context, flight *model.Flight
 	return err
 }
 
+func (r *MongoFlightRepository) Replace(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error) {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": flight.ID, "updated_at": lastUpdated}, flight)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
 func (r *MongoFlightRepository) Delete(ctx context.Context, id string) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
 func (r *MongoFlightRepository) Search(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	from, to := criteria.DepartureWindow()
 	filter := bson.M{
 		"departure_city": criteria.DepartureCity,
 		"arrival_city":   criteria.ArrivalCity,
 		"departure_time": bson.M{
-			"$gte": criteria.DepartureDate,
-			"$lt":  criteria.DepartureDate.Add(24 * time.Hour),
+			"$gte": from,
+			"$lt":  to,
 		},
 		"available_seats": bson.M{
 			"$gte": criteria.Passengers,
 		},
-		"status": model.FlightStatusScheduled,
+		"status": bson.M{"$in": model.SellableStatuses},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "departure_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []*model.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+
+	return flights, nil
+}
+
+func (r *MongoFlightRepository) FindDepartures(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	filter := bson.M{
+		"departure_city": city,
+		"departure_time": bson.M{
+			"$gte": from,
+			"$lte": to,
+		},
+		"available_seats": bson.M{
+			"$gte": passengers,
+		},
+		"status": bson.M{"$in": model.SellableStatuses},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "departure_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []*model.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+
+	return flights, nil
+}
+
+// FindByRoute lists the sellable flights on a route departing in [from, to)
+// with seats for the passengers, in departure order
+func (r *MongoFlightRepository) FindByRoute(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	filter := bson.M{
+		"departure_city": departureCity,
+		"arrival_city":   arrivalCity,
+		"departure_time": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+		"available_seats": bson.M{
+			"$gte": passengers,
+		},
+		"status": bson.M{"$in": model.SellableStatuses},
 	}
 
 	opts := options.Find().SetSort(bson.D{{Key: "departure_time", Value: 1}})
@@ -76,16 +146,209 @@ func (r *MongoFlightRepository) Search(ctx context.Context, criteria model.Searc
 	return flights, nil
 }
 
-func (r *MongoFlightRepository) UpdateSeats(ctx context.Context, flightID string, seats int) error {
+func (r *MongoFlightRepository) UpdateSeats(ctx context.Context, flightID string, change int) (bool, error) {
+	filter := bson.M{"_id": flightID}
+	if change > 0 {
+		filter["available_seats"] = bson.M{"$gte": change}
+	}
+	result, err := r.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{"available_seats": -change},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoFlightRepository) UpdateFareSeats(ctx context.Context, flightID, fareCode string, change int) (bool, error) {
+	filter := bson.M{"_id": flightID, "fares.code": fareCode}
+	if change > 0 {
+		delete(filter, "fares.code")
+		filter["fares"] = bson.M{"$elemMatch": bson.M{"code": fareCode, "seats": bson.M{"$gte": change}}}
+	}
+	result, err := r.collection.UpdateOne(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{
+				"fares.$.seats":   -change,
+				"available_seats": -change,
+			},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil || result.MatchedCount != 1 {
+		return false, err
+	}
+	r.refreshPrice(ctx, flightID)
+	return true, nil
+}
+
+// refreshPrice sets the headline price of a flight with fare buckets to the
+// cheapest fare with seats left, or the cheapest fare once all are sold out,
+// as model.Flight.HeadlinePrice does. It runs after every write to bucket
+// seats, so the last writer leaves the price matching the seats it left. The
+// seats are moved either way, so a failed refresh waits for the next write.
+func (r *MongoFlightRepository) refreshPrice(ctx context.Context, flightID string) error {
+	open := bson.M{"$filter": bson.M{"input": "$fares", "cond": bson.M{"$gt": bson.A{"$$this.seats", 0}}}}
 	_, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{"_id": flightID},
+		bson.M{"_id": flightID, "fares.0": bson.M{"$exists": true}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"price": bson.M{"$ifNull": bson.A{bson.M{"$min": bson.M{"$map": bson.M{"input": open, "in": "$$this.price"}}}, bson.M{"$min": "$fares.price"}}},
+		}}}},
+	)
+	return err
+}
+
+func (r *MongoFlightRepository) UpdateOverbooking(ctx context.Context, flightID, fareCode string, change int) (bool, error) {
+	filter := bson.M{"_id": flightID}
+	inc := bson.M{
+		"overbooking":     change,
+		"available_seats": change,
+	}
+	set := bson.M{"updated_at": time.Now()}
+	if change < 0 {
+		filter["available_seats"] = bson.M{"$gte": -change}
+	}
+	if fareCode != "" {
+		filter["fares.code"] = fareCode
+		if change < 0 {
+			delete(filter, "fares.code")
+			filter["fares"] = bson.M{"$elemMatch": bson.M{"code": fareCode, "seats": bson.M{"$gte": -change}}}
+		}
+		inc["fares.$.seats"] = change
+		set["overbooking_fare"] = fareCode
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": inc, "$set": set})
+	if err != nil || result.MatchedCount != 1 {
+		return false, err
+	}
+	if fareCode != "" {
+		r.refreshPrice(ctx, flightID)
+	}
+	return true, nil
+}
+
+func (r *MongoFlightRepository) UpdateStatus(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": flight.ID, "status": expectedStatus},
 		bson.M{
 			"$set": bson.M{
-				"available_seats": seats,
-				"updated_at":      time.Now(),
+				"status":                   flight.Status,
+				"estimated_departure_time": flight.EstimatedDepartureTime,
+				"estimated_arrival_time":   flight.EstimatedArrivalTime,
+				"actual_departure_time":    flight.ActualDepartureTime,
+				"actual_arrival_time":      flight.ActualArrivalTime,
+				"diverted_to":              flight.DivertedTo,
+				"updated_at":               flight.UpdatedAt,
 			},
 		},
 	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoFlightRepository) FindBySchedule(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error) {
+	filter := bson.M{
+		"schedule_id":    scheduleID,
+		"departure_time": bson.M{"$gte": from},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "departure_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []*model.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+
+	return flights, nil
+}
+
+func (r *MongoFlightRepository) FindByFlightNumber(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error) {
+	filter := bson.M{
+		"flight_number": flightNumber,
+		"departure_time": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "departure_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []*model.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+
+	return flights, nil
+}
+
+func (r *MongoFlightRepository) FindDepartingBetween(ctx context.Context, from, to time.Time) ([]*model.Flight, error) {
+	filter := bson.M{
+		"departure_time": bson.M{
+			"$gte": from,
+			"$lt":  to,
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "flight_number", Value: 1}, {Key: "departure_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []*model.Flight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+
+	return flights, nil
+}
+
+// SaveBatch inserts and replaces flights in a single transaction, so a failed
+// import leaves the collection untouched. Transactions need a replica set.
+func (r *MongoFlightRepository) SaveBatch(ctx context.Context, created, updated []*model.Flight) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		writes := make([]mongo.WriteModel, 0, len(created)+len(updated))
+		for _, flight := range created {
+			writes = append(writes, mongo.NewInsertOneModel().SetDocument(flight))
+		}
+		for _, flight := range updated {
+			writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": flight.ID}).SetReplacement(flight))
+		}
+		if len(writes) == 0 {
+			return nil, nil
+		}
+		return r.collection.BulkWrite(sc, writes)
+	})
 	return err
 }
@@ -0,0 +1,112 @@
+// pkg/flights/repository/mongodb/hold_repository.go
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoSeatHoldRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSeatHoldRepository(db *mongo.Database) *MongoSeatHoldRepository {
+	return &MongoSeatHoldRepository{
+		collection: db.Collection("seat_holds"),
+	}
+}
+
+func (r *MongoSeatHoldRepository) Create(ctx context.Context, hold *model.SeatHold) error {
+	_, err := r.collection.InsertOne(ctx, hold)
+	return err
+}
+
+func (r *MongoSeatHoldRepository) FindByID(ctx context.Context, id string) (*model.SeatHold, error) {
+	var hold model.SeatHold
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&hold)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &hold, err
+}
+
+func (r *MongoSeatHoldRepository) Convert(ctx context.Context, id, bookingID string, now time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":        id,
+			"status":     model.SeatHoldActive,
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{
+			"$set": bson.M{
+				"status":     model.SeatHoldConverted,
+				"booking_id": bookingID,
+				"updated_at": now,
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSeatHoldRepository) Unconvert(ctx context.Context, id, bookingID string) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":        id,
+			"status":     model.SeatHoldConverted,
+			"booking_id": bookingID,
+		},
+		bson.M{
+			"$set":   bson.M{"status": model.SeatHoldActive, "updated_at": time.Now()},
+			"$unset": bson.M{"booking_id": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSeatHoldRepository) Close(ctx context.Context, id string, status model.SeatHoldStatus) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": model.SeatHoldActive},
+		bson.M{
+			"$set": bson.M{
+				"status":     status,
+				"updated_at": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSeatHoldRepository) FindExpired(ctx context.Context, now time.Time) ([]*model.SeatHold, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"status":     model.SeatHoldActive,
+		"expires_at": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var holds []*model.SeatHold
+	if err := cursor.All(ctx, &holds); err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
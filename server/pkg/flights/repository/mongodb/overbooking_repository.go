@@ -0,0 +1,42 @@
+// pkg/flights/repository/mongodb/overbooking_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoOverbookingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOverbookingRepository(db *mongo.Database) *MongoOverbookingRepository {
+	return &MongoOverbookingRepository{
+		collection: db.Collection("overbooking_policies"),
+	}
+}
+
+func (r *MongoOverbookingRepository) Get(ctx context.Context) (*model.OverbookingPolicy, error) {
+	var policy model.OverbookingPolicy
+	err := r.collection.FindOne(ctx, bson.M{"_id": model.OverbookingPolicyID}).Decode(&policy)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &policy, err
+}
+
+func (r *MongoOverbookingRepository) Save(ctx context.Context, policy *model.OverbookingPolicy) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": policy.ID},
+		policy,
+		opts,
+	)
+	return err
+}
@@ -0,0 +1,55 @@
+// pkg/flights/repository/mongodb/price_history_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPriceHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPriceHistoryRepository(db *mongo.Database) *MongoPriceHistoryRepository {
+	return &MongoPriceHistoryRepository{
+		collection: db.Collection("flight_price_history"),
+	}
+}
+
+func (r *MongoPriceHistoryRepository) Record(ctx context.Context, point *model.PricePoint) error {
+	_, err := r.collection.InsertOne(ctx, point)
+	return err
+}
+
+func (r *MongoPriceHistoryRepository) Latest(ctx context.Context, flightID string) (*model.PricePoint, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "recorded_at", Value: -1}})
+
+	var point model.PricePoint
+	err := r.collection.FindOne(ctx, bson.M{"flight_id": flightID}, opts).Decode(&point)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &point, err
+}
+
+func (r *MongoPriceHistoryRepository) ListByFlight(ctx context.Context, flightID string) ([]*model.PricePoint, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "recorded_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"flight_id": flightID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var points []*model.PricePoint
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
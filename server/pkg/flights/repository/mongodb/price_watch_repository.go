@@ -0,0 +1,103 @@
+// pkg/flights/repository/mongodb/price_watch_repository.go
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPriceWatchRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPriceWatchRepository(db *mongo.Database) *MongoPriceWatchRepository {
+	return &MongoPriceWatchRepository{
+		collection: db.Collection("price_watches"),
+	}
+}
+
+func (r *MongoPriceWatchRepository) Create(ctx context.Context, watch *model.PriceWatch) error {
+	_, err := r.collection.InsertOne(ctx, watch)
+	return err
+}
+
+func (r *MongoPriceWatchRepository) FindByID(ctx context.Context, id string) (*model.PriceWatch, error) {
+	var watch model.PriceWatch
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&watch)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &watch, err
+}
+
+func (r *MongoPriceWatchRepository) ListByUser(ctx context.Context, userID string) ([]*model.PriceWatch, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	return r.find(ctx, bson.M{"user_id": userID}, opts)
+}
+
+func (r *MongoPriceWatchRepository) ListActive(ctx context.Context) ([]*model.PriceWatch, error) {
+	return r.find(ctx, bson.M{"status": model.PriceWatchActive})
+}
+
+func (r *MongoPriceWatchRepository) Update(ctx context.Context, watch *model.PriceWatch) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": watch.ID, "status": model.PriceWatchActive},
+		bson.M{
+			"$set": bson.M{
+				"last_price":      watch.LastPrice,
+				"last_flight_id":  watch.LastFlightID,
+				"last_checked_at": watch.LastCheckedAt,
+				"updated_at":      watch.UpdatedAt,
+			},
+		},
+	)
+	return err
+}
+
+func (r *MongoPriceWatchRepository) Close(ctx context.Context, watch *model.PriceWatch, status model.PriceWatchStatus) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": watch.ID, "status": model.PriceWatchActive},
+		bson.M{
+			"$set": bson.M{
+				"status":          status,
+				"last_price":      watch.LastPrice,
+				"last_flight_id":  watch.LastFlightID,
+				"last_checked_at": watch.LastCheckedAt,
+				"triggered_at":    watch.TriggeredAt,
+				"updated_at":      time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoPriceWatchRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *MongoPriceWatchRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]*model.PriceWatch, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var watches []*model.PriceWatch
+	if err := cursor.All(ctx, &watches); err != nil {
+		return nil, err
+	}
+
+	return watches, nil
+}
@@ -0,0 +1,42 @@
+// pkg/flights/repository/mongodb/pricing_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoPricingRulesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoPricingRulesRepository(db *mongo.Database) *MongoPricingRulesRepository {
+	return &MongoPricingRulesRepository{
+		collection: db.Collection("pricing_rules"),
+	}
+}
+
+func (r *MongoPricingRulesRepository) Get(ctx context.Context) (*model.PricingRules, error) {
+	var rules model.PricingRules
+	err := r.collection.FindOne(ctx, bson.M{"_id": model.PricingRulesID}).Decode(&rules)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &rules, err
+}
+
+func (r *MongoPricingRulesRepository) Save(ctx context.Context, rules *model.PricingRules) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": rules.ID},
+		rules,
+		opts,
+	)
+	return err
+}
@@ -0,0 +1,58 @@
+// pkg/flights/repository/mongodb/schedule_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoScheduleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoScheduleRepository(db *mongo.Database) *MongoScheduleRepository {
+	return &MongoScheduleRepository{
+		collection: db.Collection("flight_schedules"),
+	}
+}
+
+func (r *MongoScheduleRepository) Create(ctx context.Context, schedule *model.ScheduleTemplate) error {
+	_, err := r.collection.InsertOne(ctx, schedule)
+	return err
+}
+
+func (r *MongoScheduleRepository) FindByID(ctx context.Context, id string) (*model.ScheduleTemplate, error) {
+	var schedule model.ScheduleTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &schedule, err
+}
+
+func (r *MongoScheduleRepository) Update(ctx context.Context, schedule *model.ScheduleTemplate) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": schedule.ID}, schedule)
+	return err
+}
+
+func (r *MongoScheduleRepository) List(ctx context.Context) ([]*model.ScheduleTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "flight_number", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schedules := []*model.ScheduleTemplate{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,96 @@
+// pkg/flights/repository/mongodb/seat_map_repository.go
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoSeatMapRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSeatMapRepository(db *mongo.Database) *MongoSeatMapRepository {
+	return &MongoSeatMapRepository{
+		collection: db.Collection("seat_maps"),
+	}
+}
+
+func (r *MongoSeatMapRepository) FindByFlightID(ctx context.Context, flightID string) (*model.SeatMap, error) {
+	var seatMap model.SeatMap
+	err := r.collection.FindOne(ctx, bson.M{"_id": flightID}).Decode(&seatMap)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &seatMap, err
+}
+
+func (r *MongoSeatMapRepository) Create(ctx context.Context, seatMap *model.SeatMap) error {
+	_, err := r.collection.InsertOne(ctx, seatMap)
+	return err
+}
+
+func (r *MongoSeatMapRepository) Replace(ctx context.Context, seatMap *model.SeatMap, expectedUpdatedAt time.Time) (bool, error) {
+	result, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": seatMap.FlightID, "updated_at": expectedUpdatedAt},
+		seatMap,
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSeatMapRepository) AssignSeat(ctx context.Context, flightID, seatNumber, bookingID string) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": flightID,
+			"seats": bson.M{
+				"$elemMatch": bson.M{
+					"number":     seatNumber,
+					"booking_id": "",
+					"blocked":    false,
+				},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"seats.$.booking_id": bookingID,
+				"updated_at":         time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoSeatMapRepository) ReleaseSeat(ctx context.Context, flightID, seatNumber, bookingID string) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": flightID,
+			"seats": bson.M{
+				"$elemMatch": bson.M{
+					"number":     seatNumber,
+					"booking_id": bookingID,
+				},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"seats.$.booking_id": "",
+				"updated_at":         time.Now(),
+			},
+		},
+	)
+	return err
+}
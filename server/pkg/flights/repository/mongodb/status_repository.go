@@ -0,0 +1,44 @@
+// pkg/flights/repository/mongodb/status_repository.go
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoFlightStatusRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoFlightStatusRepository(db *mongo.Database) *MongoFlightStatusRepository {
+	return &MongoFlightStatusRepository{
+		collection: db.Collection("flight_status_events"),
+	}
+}
+
+func (r *MongoFlightStatusRepository) Record(ctx context.Context, event *model.FlightStatusEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *MongoFlightStatusRepository) ListByFlight(ctx context.Context, flightID string) ([]*model.FlightStatusEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"flight_id": flightID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*model.FlightStatusEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
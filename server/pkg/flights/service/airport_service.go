@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	airportmodel "github.com/Siya360/take-flight/server/pkg/airports/model"
+	"github.com/Siya360/take-flight/server/pkg/common"
+)
+
+const errMsgUnknownAirport = "Unknown airport code: %s"
+
+// AirportDirectory resolves the IATA codes flights operate between
+type AirportDirectory interface {
+	GetAirport(ctx context.Context, code string) (*airportmodel.Airport, error)
+	MetroAirports(ctx context.Context, code string) ([]string, error)
+}
+
+// WithAirports validates flight routes against an airport registry and makes
+// date searches use the departure airport's local day
+func (s *FlightService) WithAirports(airports AirportDirectory) *FlightService {
+	s.airports = airports
+	return s
+}
+
+// resolveRoute upper-cases a departure and arrival code and checks both are
+// known airports, returning the departure airport. Without a registry the
+// codes are accepted as they are and no airport is returned.
+func (s *FlightService) resolveRoute(ctx context.Context, departure, arrival *string) (*airportmodel.Airport, error) {
+	if s.airports == nil {
+		return nil, nil
+	}
+
+	var origin *airportmodel.Airport
+	for i, code := range []*string{departure, arrival} {
+		*code = strings.ToUpper(strings.TrimSpace(*code))
+		airport, err := s.airports.GetAirport(ctx, *code)
+		if err != nil || airport == nil {
+			return nil, common.NewAppError(common.ErrInvalidInput, fmt.Sprintf(errMsgUnknownAirport, *code), http.StatusBadRequest)
+		}
+		if i == 0 {
+			origin = airport
+		}
+	}
+	return origin, nil
+}
+
+// airportLocation returns the time zone of an airport, or UTC when the
+// airport is unknown or no registry is configured
+func (s *FlightService) airportLocation(ctx context.Context, code string) *time.Location {
+	if s.airports == nil {
+		return time.UTC
+	}
+	airport, err := s.airports.GetAirport(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || airport == nil {
+		return time.UTC
+	}
+	loc, err := airport.Location()
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// localDay moves the calendar date of a search onto midnight at the
+// departure airport so the day searched is the traveller's local day
+func (s *FlightService) localDay(ctx context.Context, code string, date time.Time) time.Time {
+	loc := s.airportLocation(ctx, code)
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// searchAirports returns the airports a search code covers: every member of a
+// metro area, or just the code itself
+func (s *FlightService) searchAirports(ctx context.Context, code string) []string {
+	if s.airports == nil {
+		return []string{code}
+	}
+	members, err := s.airports.MetroAirports(ctx, code)
+	if err != nil || len(members) == 0 {
+		return []string{code}
+	}
+	return members
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgCacheDisabled = "Flight cache is not configured"
+
+	// DefaultFlightCacheTTL is how long flights and searches stay cached when no TTL is configured
+	DefaultFlightCacheTTL = 5 * time.Minute
+
+	cacheKeyFlightGeneration = "flight_generation:%s"
+	cacheKeySearchGeneration = "flight_search_generation:%s:%s:%s"
+	cacheKeyCachedFlight     = "flight:%s:%s"
+	cacheKeyCachedSearch     = "flight_search:%s:%s:%d:%d:%d:%s"
+	generationDayLayout      = "2006-01-02"
+)
+
+// errFlightNotFound keeps lookups of missing flights out of the cache
+var errFlightNotFound = errors.New("flight not found")
+
+// CacheStore holds the generations cached flights and searches are filed under
+type CacheStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// WithFlightCache caches flight lookups and catalog searches with the given
+// strategies, whose entries must expire a fixed ttl after they are cached;
+// strategies that extend entries on every hit, such as sliding ones, would
+// outlive their generation. Entries are filed under generations: every write
+// to a flight moves the flight, and the day its route departs on, to a new
+// generation, so entries it made stale are never read again and simply expire.
+func (s *FlightService) WithFlightCache(store CacheStore, lookups, searches CacheStrategy, ttl time.Duration) *FlightService {
+	if ttl <= 0 {
+		ttl = DefaultFlightCacheTTL
+	}
+	cached := &cachedFlightRepository{
+		FlightRepository: s.repo,
+		store:            store,
+		lookups:          lookups,
+		searches:         searches,
+		ttl:              ttl,
+	}
+	s.repo = cached
+	s.flightCache = cached
+	return s
+}
+
+// GetCacheStats returns the flight cache hit and miss counters
+func (s *FlightService) GetCacheStats(ctx context.Context) (*model.CacheStats, error) {
+	if s.flightCache == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgCacheDisabled, http.StatusNotFound)
+	}
+	return s.flightCache.Stats(), nil
+}
+
+// cachedFlightRepository serves FindByID and Search from the cache and moves
+// flights to a new generation whenever they are written. Writes read the
+// flight they replace from the store, since a cached copy may predate the
+// route or departure the stale entries were filed under.
+type cachedFlightRepository struct {
+	FlightRepository
+	store    CacheStore
+	lookups  CacheStrategy
+	searches CacheStrategy
+	ttl      time.Duration
+
+	flightHits    atomic.Int64
+	flightMisses  atomic.Int64
+	searchHits    atomic.Int64
+	searchMisses  atomic.Int64
+	invalidations atomic.Int64
+}
+
+func (r *cachedFlightRepository) Stats() *model.CacheStats {
+	return &model.CacheStats{
+		FlightHits:    r.flightHits.Load(),
+		FlightMisses:  r.flightMisses.Load(),
+		SearchHits:    r.searchHits.Load(),
+		SearchMisses:  r.searchMisses.Load(),
+		Invalidations: r.invalidations.Load(),
+	}
+}
+
+func (r *cachedFlightRepository) FindByID(ctx context.Context, id string) (*model.Flight, error) {
+	key := fmt.Sprintf(cacheKeyCachedFlight, id, r.generation(ctx, fmt.Sprintf(cacheKeyFlightGeneration, id)))
+	missed := false
+	value, err := r.lookups.Get(ctx, key, func() (interface{}, error) {
+		missed = true
+		flight, err := r.FlightRepository.FindByID(ctx, id)
+		if err == nil && flight == nil {
+			err = errFlightNotFound
+		}
+		return flight, err
+	})
+	r.count(missed, &r.flightHits, &r.flightMisses)
+
+	var flight *model.Flight
+	switch {
+	case errors.Is(err, errFlightNotFound):
+		return nil, nil
+	case !decodeCached(value, &flight):
+		if err == nil {
+			err = errFlightNotFound
+		}
+		return nil, err
+	}
+	return flight, nil
+}
+
+func (r *cachedFlightRepository) Search(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	from, to := criteria.DepartureWindow()
+	key := fmt.Sprintf(cacheKeyCachedSearch, criteria.DepartureCity, criteria.ArrivalCity,
+		from.Unix(), to.Unix(), criteria.Passengers, r.routeGeneration(ctx, criteria.DepartureCity, criteria.ArrivalCity, from, to))
+
+	missed := false
+	value, err := r.searches.Get(ctx, key, func() (interface{}, error) {
+		missed = true
+		return r.FlightRepository.Search(ctx, criteria)
+	})
+	r.count(missed, &r.searchHits, &r.searchMisses)
+
+	var flights []*model.Flight
+	if !decodeCached(value, &flights) {
+		return nil, err
+	}
+	return flights, nil
+}
+
+func (r *cachedFlightRepository) Create(ctx context.Context, flight *model.Flight) error {
+	if err := r.FlightRepository.Create(ctx, flight); err != nil {
+		return err
+	}
+	r.invalidate(ctx, flight)
+	return nil
+}
+
+func (r *cachedFlightRepository) Update(ctx context.Context, flight *model.Flight) error {
+	previous, _ := r.FlightRepository.FindByID(ctx, flight.ID)
+	if err := r.FlightRepository.Update(ctx, flight); err != nil {
+		return err
+	}
+	r.invalidate(ctx, previous, flight)
+	return nil
+}
+
+func (r *cachedFlightRepository) Replace(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error) {
+	previous, _ := r.FlightRepository.FindByID(ctx, flight.ID)
+	ok, err := r.FlightRepository.Replace(ctx, flight, lastUpdated)
+	if ok {
+		r.invalidate(ctx, previous, flight)
+	}
+	return ok, err
+}
+
+func (r *cachedFlightRepository) Delete(ctx context.Context, id string) error {
+	previous, _ := r.FlightRepository.FindByID(ctx, id)
+	if err := r.FlightRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, previous)
+	return nil
+}
+
+func (r *cachedFlightRepository) UpdateSeats(ctx context.Context, flightID string, change int) (bool, error) {
+	ok, err := r.FlightRepository.UpdateSeats(ctx, flightID, change)
+	if ok {
+		r.invalidateID(ctx, flightID)
+	}
+	return ok, err
+}
+
+func (r *cachedFlightRepository) UpdateFareSeats(ctx context.Context, flightID, fareCode string, change int) (bool, error) {
+	ok, err := r.FlightRepository.UpdateFareSeats(ctx, flightID, fareCode, change)
+	if ok {
+		r.invalidateID(ctx, flightID)
+	}
+	return ok, err
+}
+
+func (r *cachedFlightRepository) UpdateStatus(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+	ok, err := r.FlightRepository.UpdateStatus(ctx, flight, expectedStatus)
+	if ok {
+		r.invalidate(ctx, flight)
+	}
+	return ok, err
+}
+
+func (r *cachedFlightRepository) UpdateOverbooking(ctx context.Context, flightID, fareCode string, change int) (bool, error) {
+	ok, err := r.FlightRepository.UpdateOverbooking(ctx, flightID, fareCode, change)
+	if ok {
+		r.invalidateID(ctx, flightID)
+	}
+	return ok, err
+}
+
+func (r *cachedFlightRepository) SaveBatch(ctx context.Context, created, updated []*model.Flight) error {
+	var previous []*model.Flight
+	for _, flight := range updated {
+		if found, _ := r.FlightRepository.FindByID(ctx, flight.ID); found != nil {
+			previous = append(previous, found)
+		}
+	}
+	if err := r.FlightRepository.SaveBatch(ctx, created, updated); err != nil {
+		return err
+	}
+	r.invalidate(ctx, previous...)
+	r.invalidate(ctx, created...)
+	r.invalidate(ctx, updated...)
+	return nil
+}
+
+// invalidateID moves a flight written by ID, and the searches over its route
+// and day, to a new generation
+func (r *cachedFlightRepository) invalidateID(ctx context.Context, flightID string) {
+	flight, _ := r.FlightRepository.FindByID(ctx, flightID)
+	if flight == nil {
+		flight = &model.Flight{ID: flightID}
+	}
+	r.invalidate(ctx, flight)
+}
+
+// invalidate moves flights, and the searches over their route and day, to new generations
+func (r *cachedFlightRepository) invalidate(ctx context.Context, flights ...*model.Flight) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for _, flight := range flights {
+		if flight == nil {
+			continue
+		}
+		r.invalidations.Add(1)
+		r.store.Set(ctx, fmt.Sprintf(cacheKeyFlightGeneration, flight.ID), generation, r.ttl)
+		if flight.DepartureCity != "" {
+			key := searchGenerationKey(flight.DepartureCity, flight.ArrivalCity, flight.DepartureTime)
+			r.store.Set(ctx, key, generation, r.ttl)
+		}
+	}
+}
+
+// generation returns the current generation under a key; entries made
+// before anything was invalidated use the empty generation. A generation
+// lives for ttl after the write that made it, and entries are cached for at
+// most ttl, so by the time it expires back to the empty generation every
+// entry it replaced has expired too.
+func (r *cachedFlightRepository) generation(ctx context.Context, key string) string {
+	generation, err := r.store.Get(ctx, key)
+	if err != nil {
+		return ""
+	}
+	return generation
+}
+
+// routeGeneration joins the generations of the searches over a route on
+// every day a departure window touches, so it changes whenever a flight on
+// the route departing in the window is written
+func (r *cachedFlightRepository) routeGeneration(ctx context.Context, departureCity, arrivalCity string, from, to time.Time) string {
+	var generations []string
+	for _, day := range routeDays(from, to) {
+		generations = append(generations, r.generation(ctx, searchGenerationKey(departureCity, arrivalCity, day)))
+	}
+	return strings.Join(generations, ",")
+}
+
+func (r *cachedFlightRepository) count(missed bool, hits, misses *atomic.Int64) {
+	if missed {
+		misses.Add(1)
+	} else {
+		hits.Add(1)
+	}
+}
+
+func searchGenerationKey(departureCity, arrivalCity string, day time.Time) string {
+	return fmt.Sprintf(cacheKeySearchGeneration, departureCity, arrivalCity, day.UTC().Format(generationDayLayout))
+}
+
+// routeDays lists the UTC days a departure window touches
+func routeDays(from, to time.Time) []time.Time {
+	from, to = from.UTC(), to.UTC()
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	var days []time.Time
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// decodeCached reads a cache strategy's result, which is the fetched value on
+// a miss and its JSON on a hit
+func decodeCached[T any](value interface{}, target *T) bool {
+	switch v := value.(type) {
+	case T:
+		*target = v
+		return true
+	case string:
+		return json.Unmarshal([]byte(v), target) == nil
+	}
+	return false
+}
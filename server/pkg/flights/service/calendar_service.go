@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgInvalidMonth        = "Month must be formatted as YYYY-MM"
+	errMsgFailedToGetCalendar = "Failed to build fare calendar"
+)
+
+// CacheStrategy matches the strategies provided by internal/cache
+type CacheStrategy interface {
+	Get(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error)
+}
+
+// WithCalendarCache sets the cache strategy used for fare calendars. With a
+// flight cache, calendars are filed under the generations of their route's
+// days, so writes to its flights are seen straight away, and the strategy must
+// expire entries within the flight cache ttl as its own strategies do.
+// Without one, calendars are only refreshed as the strategy expires them.
+func (s *FlightService) WithCalendarCache(strategy CacheStrategy) *FlightService {
+	s.calendarCache = strategy
+	return s
+}
+
+// GetFareCalendar returns the cheapest available fare per day for a route
+// over one month, priced as a search would show it. Days without
+// availability are included and marked as such.
+func (s *FlightService) GetFareCalendar(ctx context.Context, req model.FareCalendarRequest) (*model.FareCalendar, error) {
+	if req.DepartureCity == "" || req.ArrivalCity == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidSearch, http.StatusBadRequest)
+	}
+	month, err := time.Parse(model.CalendarMonthLayout, req.Month)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidMonth, http.StatusBadRequest)
+	}
+	if req.Passengers < 1 {
+		req.Passengers = 1
+	}
+
+	// Days are the departure airport's local days
+	loc := s.airportLocation(ctx, req.DepartureCity)
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 1, 0)
+	if s.calendarCache == nil {
+		return s.buildFareCalendar(ctx, req, start, end)
+	}
+
+	key := fmt.Sprintf(common.CacheKeyFareCalendar, req.DepartureCity, req.ArrivalCity, req.Month, req.Passengers)
+	if s.flightCache != nil {
+		key += ":" + s.flightCache.routeGeneration(ctx, req.DepartureCity, req.ArrivalCity, start, end)
+	}
+	value, err := s.calendarCache.Get(ctx, key, func() (interface{}, error) {
+		return s.buildFareCalendar(ctx, req, start, end)
+	})
+
+	// A failed cache write still returns the freshly built calendar
+	var calendar *model.FareCalendar
+	if decodeCached(value, &calendar) && calendar != nil {
+		return calendar, nil
+	}
+	var appErr *common.AppError
+	if errors.As(err, &appErr) {
+		return nil, err
+	}
+	return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToGetCalendar, http.StatusInternalServerError)
+}
+
+// buildFareCalendar prices the flights on the route and fills in every day
+// of the month with the cheapest fare with seats for the passengers
+func (s *FlightService) buildFareCalendar(ctx context.Context, req model.FareCalendarRequest, start, end time.Time) (*model.FareCalendar, error) {
+	flights, err := s.repo.FindByRoute(ctx, req.DepartureCity, req.ArrivalCity, start, end, req.Passengers)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToGetCalendar, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	byDate := make(map[string]model.DailyFare)
+	for _, flight := range flights {
+		if _, err := s.priceFlight(ctx, flight, now); err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToPrice, http.StatusInternalServerError)
+		}
+		price, ok := flight.LowestFare(req.Passengers)
+		if !ok {
+			continue
+		}
+		date := flight.DepartureTime.In(start.Location()).Format("2006-01-02")
+		fare, seen := byDate[date]
+		if !seen || price < fare.LowestPrice {
+			fare.LowestPrice = price
+		}
+		fare.Flights++
+		byDate[date] = fare
+	}
+
+	calendar := &model.FareCalendar{
+		DepartureCity: req.DepartureCity,
+		ArrivalCity:   req.ArrivalCity,
+		Month:         req.Month,
+	}
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		fare, ok := byDate[date]
+		fare.Date = date
+		fare.Available = ok
+		calendar.Days = append(calendar.Days, fare)
+	}
+
+	return calendar, nil
+}
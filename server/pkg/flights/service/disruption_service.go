@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+// DisruptionHandler is told about flights that have been cancelled or delayed
+// so the bookings on them can be looked after
+type DisruptionHandler interface {
+	HandleDisruption(ctx context.Context, flight *model.Flight, event *model.FlightStatusEvent)
+}
+
+// WithDisruptionHandler sets the handler told about cancellations and delays
+func (s *FlightService) WithDisruptionHandler(handler DisruptionHandler) *FlightService {
+	s.disruptions = handler
+	return s
+}
+
+// FindAlternativeFlights lists sellable flights on the same route as a
+// disrupted flight that depart between from and to with room for the given
+// number of passengers, earliest first.
+func (s *FlightService) FindAlternativeFlights(ctx context.Context, flight *model.Flight, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	departures, err := s.repo.FindDepartures(ctx, flight.DepartureCity, from, to, passengers)
+	if err != nil {
+		return nil, err
+	}
+	alternatives := make([]*model.Flight, 0, len(departures))
+	for _, departure := range departures {
+		if departure.ID != flight.ID && departure.ArrivalCity == flight.ArrivalCity {
+			alternatives = append(alternatives, departure)
+		}
+	}
+	return alternatives, nil
+}
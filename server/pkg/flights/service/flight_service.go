@@ -10,25 +10,80 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	errMsgFareNotFound = "Fare not found on flight"
+	errMsgInvalidFares = "Fare buckets need a unique code, a valid cabin, non-negative price and seats, and non-negative fees and change deadline"
+
+	errMsgFailedToUpdateSeats = "Failed to update flight seats"
+	errMsgFlightChanged       = "Flight changed while it was being edited, please retry"
+)
+
 type FlightRepository interface {
 	Create(ctx context.Context, flight *model.Flight) error
 	FindByID(ctx context.Context, id string) (*model.Flight, error)
 	Update(ctx context.Context, flight *model.Flight) error
+	// Replace writes the whole flight only if it was last written at
+	// lastUpdated, so seats sold or returned in the meantime are not
+	// overwritten, and reports whether it did
+	Replace(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error)
 	Delete(ctx context.Context, id string) error
 	Search(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error)
-	UpdateSeats(ctx context.Context, flightID string, seats int) error
+	FindDepartures(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error)
+	// FindByRoute lists the sellable flights on a route departing in [from, to)
+	// with seats for the passengers
+	FindByRoute(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error)
+	// UpdateSeats and UpdateFareSeats take change seats out of inventory in a
+	// single conditional write, or return them when change is negative. Taking
+	// seats reports false when fewer than change are left. Writes to a fare
+	// bucket also refresh the headline price from the buckets with seats.
+	UpdateSeats(ctx context.Context, flightID string, change int) (bool, error)
+	UpdateFareSeats(ctx context.Context, flightID, fareCode string, change int) (bool, error)
+	// UpdateStatus writes the status fields only if the stored status still equals expectedStatus
+	UpdateStatus(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error)
+	FindBySchedule(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error)
+	FindByFlightNumber(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error)
+	FindDepartingBetween(ctx context.Context, from, to time.Time) ([]*model.Flight, error)
+	// UpdateOverbooking adds change seats, or removes them when negative, to
+	// the overbooking allowance, the available seats and the given fare
+	// bucket, refreshing the headline price as UpdateFareSeats does. Removing
+	// seats reports false when fewer than change are unsold.
+	UpdateOverbooking(ctx context.Context, flightID, fareCode string, change int) (bool, error)
+	// SaveBatch creates and replaces flights atomically: all are written or none
+	SaveBatch(ctx context.Context, created, updated []*model.Flight) error
 }
 
 type FlightService struct {
-	repo FlightRepository
+	repo          FlightRepository
+	connections   model.ConnectionPolicy
+	calendarCache CacheStrategy
+	seatMaps      SeatMapRepository
+	pricing       PricingEngine
+	quotes        QuoteStore
+	statusHistory FlightStatusRepository
+	schedules     ScheduleRepository
+	airports      AirportDirectory
+	providers     []registeredProvider
+	disruptions   DisruptionHandler
+	holds         SeatHoldRepository
+	holdTTL       time.Duration
+	overbooking   OverbookingRepository
+	flightCache   *cachedFlightRepository
+	priceWatches  PriceWatchRepository
+	priceHistory  *priceTracker
+	priceAlerts   PriceAlertNotifier
 }
 
 func NewFlightService(repo FlightRepository) *FlightService {
-	return &FlightService{repo: repo}
+	return &FlightService{
+		repo:        repo,
+		connections: model.DefaultConnectionPolicy(),
+	}
 }
 
-func (s *FlightService) SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
-	return s.repo.Search(ctx, criteria)
+// WithConnectionPolicy sets the connection windows used when building itineraries
+func (s *FlightService) WithConnectionPolicy(policy model.ConnectionPolicy) *FlightService {
+	s.connections = policy
+	return s
 }
 
 func (s *FlightService) GetFlight(ctx context.Context, id string) (*model.Flight, error) {
@@ -36,9 +91,28 @@ func (s *FlightService) GetFlight(ctx context.Context, id string) (*model.Flight
 }
 
 func (s *FlightService) CreateFlight(ctx context.Context, flight *model.Flight) (*model.Flight, error) {
+	if _, err := s.resolveRoute(ctx, &flight.DepartureCity, &flight.ArrivalCity); err != nil {
+		return nil, err
+	}
+	if err := normalizeFares(flight); err != nil {
+		return nil, err
+	}
+	if flight.Capacity == 0 {
+		flight.Capacity = flight.AvailableSeats
+	}
+	if flight.Status == "" {
+		flight.Status = model.FlightStatusScheduled
+	}
+	if flight.Status != model.FlightStatusScheduled {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgNewFlightStatus, http.StatusBadRequest)
+	}
 	if flight.ID == "" {
 		flight.ID = uuid.NewString()
 	}
+	flight.Overbooking, flight.OverbookingFare = 0, ""
+	if err := s.overbook(ctx, flight); err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	if flight.CreatedAt.IsZero() {
 		flight.CreatedAt = now
@@ -56,29 +130,79 @@ func (s *FlightService) UpdateFlight(ctx context.Context, id string, flight *mod
 	if err != nil || existing == nil {
 		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
 	}
+	if _, err := s.resolveRoute(ctx, &flight.DepartureCity, &flight.ArrivalCity); err != nil {
+		return nil, err
+	}
+	if err := normalizeFares(flight); err != nil {
+		return nil, err
+	}
+	if flight.Status == "" {
+		flight.Status = existing.Status
+	}
+	if flight.Status != existing.Status {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgStatusViaEndpoint, http.StatusConflict)
+	}
+	// Operational times are owned by the status endpoints
+	flight.EstimatedDepartureTime = existing.EstimatedDepartureTime
+	flight.EstimatedArrivalTime = existing.EstimatedArrivalTime
+	flight.ActualDepartureTime = existing.ActualDepartureTime
+	flight.ActualArrivalTime = existing.ActualArrivalTime
+	flight.DivertedTo = existing.DivertedTo
+	if flight.Capacity == 0 {
+		flight.Capacity = existing.Capacity
+	}
+	if flight.Capacity == 0 {
+		flight.Capacity = flight.AvailableSeats
+	}
+	// Inventory sent back by admins already includes the overbooked seats
+	flight.Overbooking = existing.Overbooking
+	flight.OverbookingFare = existing.OverbookingFare
 
 	flight.ID = id
+	if err := s.overbook(ctx, flight); err != nil {
+		return nil, err
+	}
 	flight.CreatedAt = existing.CreatedAt
 	flight.UpdatedAt = time.Now()
 
-	if err := s.repo.Update(ctx, flight); err != nil {
+	ok, err := s.repo.Replace(ctx, flight, existing.UpdatedAt)
+	if err != nil {
 		return nil, common.NewAppError(common.ErrInternalServer, "Failed to update flight", http.StatusInternalServerError)
 	}
+	if !ok {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgFlightChanged, http.StatusConflict)
+	}
+	if flight.Price != existing.Price {
+		s.recordPrice(ctx, flight, model.PriceSourceUpdate)
+	}
 	return flight, nil
 }
 
-func (s *FlightService) UpdateSeats(ctx context.Context, flightID string, passengerChange int) error {
+// UpdateSeats takes passengerChange seats out of inventory, or returns them
+// when negative. Bookings on a fare bucket draw from that bucket; an empty
+// fare code uses the flight's single seat pool.
+func (s *FlightService) UpdateSeats(ctx context.Context, flightID, fareCode string, passengerChange int) error {
 	flight, err := s.repo.FindByID(ctx, flightID)
 	if err != nil || flight == nil {
 		return common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
 	}
 
-	newSeats := flight.AvailableSeats - passengerChange
-	if newSeats < 0 {
+	var ok bool
+	if fareCode == "" {
+		ok, err = s.repo.UpdateSeats(ctx, flightID, passengerChange)
+	} else {
+		if _, found := flight.FindFare(fareCode); !found {
+			return common.NewAppError(common.ErrInvalidInput, errMsgFareNotFound, http.StatusBadRequest)
+		}
+		ok, err = s.repo.UpdateFareSeats(ctx, flightID, fareCode, passengerChange)
+	}
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToUpdateSeats, http.StatusInternalServerError)
+	}
+	if !ok {
 		return common.NewAppError(common.ErrInvalidInput, "Insufficient available seats", http.StatusBadRequest)
 	}
-
-	return s.repo.UpdateSeats(ctx, flightID, newSeats)
+	return nil
 }
 
 func (s *FlightService) DeleteFlight(ctx context.Context, id string) error {
@@ -91,3 +215,25 @@ func (s *FlightService) DeleteFlight(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// normalizeFares validates the fare buckets of a flight and derives its seat
+// count and headline price from them
+func normalizeFares(flight *model.Flight) error {
+	if len(flight.Fares) == 0 {
+		return nil
+	}
+
+	codes := make(map[string]bool, len(flight.Fares))
+	total := 0
+	for _, fare := range flight.Fares {
+		if fare.Code == "" || codes[fare.Code] || !fare.Cabin.IsValid() || fare.Seats < 0 || fare.Price < 0 || !fare.FareRules().IsValid() {
+			return common.NewAppError(common.ErrInvalidInput, errMsgInvalidFares, http.StatusBadRequest)
+		}
+		codes[fare.Code] = true
+		total += fare.Seats
+	}
+
+	flight.AvailableSeats = total
+	flight.Price = flight.HeadlinePrice()
+	return nil
+}
@@ -1,9 +1,18 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Siya360/take-flight/server/internal/cache"
+	"github.com/Siya360/take-flight/server/pkg/airports/repository/embedded"
+	airportservice "github.com/Siya360/take-flight/server/pkg/airports/service"
 	"github.com/Siya360/take-flight/server/pkg/flights/model"
 )
 
@@ -11,9 +20,19 @@ type mockFlightRepo struct {
 	createFunc      func(ctx context.Context, flight *model.Flight) error
 	findByIDFunc    func(ctx context.Context, id string) (*model.Flight, error)
 	updateFunc      func(ctx context.Context, flight *model.Flight) error
+	replaceFunc     func(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error)
 	deleteFunc      func(ctx context.Context, id string) error
 	searchFunc      func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error)
-	updateSeatsFunc func(ctx context.Context, id string, seats int) error
+	departuresFunc  func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error)
+	routeFunc       func(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error)
+	updateSeatsFunc func(ctx context.Context, id string, change int) (bool, error)
+	fareSeatsFunc   func(ctx context.Context, id, fareCode string, change int) (bool, error)
+	statusFunc      func(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error)
+	scheduleFunc    func(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error)
+	numberFunc      func(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error)
+	departingFunc   func(ctx context.Context, from, to time.Time) ([]*model.Flight, error)
+	saveBatchFunc   func(ctx context.Context, created, updated []*model.Flight) error
+	overbookFunc    func(ctx context.Context, id, fareCode string, change int) (bool, error)
 }
 
 func (m *mockFlightRepo) Create(ctx context.Context, flight *model.Flight) error {
@@ -37,6 +56,13 @@ func (m *mockFlightRepo) Update(ctx context.Context, flight *model.Flight) error
 	return nil
 }
 
+func (m *mockFlightRepo) Replace(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error) {
+	if m.replaceFunc != nil {
+		return m.replaceFunc(ctx, flight, lastUpdated)
+	}
+	return true, m.Update(ctx, flight)
+}
+
 func (m *mockFlightRepo) Delete(ctx context.Context, id string) error {
 	if m.deleteFunc != nil {
 		return m.deleteFunc(ctx, id)
@@ -51,9 +77,72 @@ func (m *mockFlightRepo) Search(ctx context.Context, criteria model.SearchFlight
 	return nil, nil
 }
 
-func (m *mockFlightRepo) UpdateSeats(ctx context.Context, id string, seats int) error {
+func (m *mockFlightRepo) FindDepartures(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	if m.departuresFunc != nil {
+		return m.departuresFunc(ctx, city, from, to, passengers)
+	}
+	return nil, nil
+}
+
+func (m *mockFlightRepo) FindByRoute(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+	if m.routeFunc != nil {
+		return m.routeFunc(ctx, departureCity, arrivalCity, from, to, passengers)
+	}
+	return nil, nil
+}
+
+func (m *mockFlightRepo) UpdateSeats(ctx context.Context, id string, change int) (bool, error) {
 	if m.updateSeatsFunc != nil {
-		return m.updateSeatsFunc(ctx, id, seats)
+		return m.updateSeatsFunc(ctx, id, change)
+	}
+	return true, nil
+}
+
+func (m *mockFlightRepo) UpdateFareSeats(ctx context.Context, id, fareCode string, change int) (bool, error) {
+	if m.fareSeatsFunc != nil {
+		return m.fareSeatsFunc(ctx, id, fareCode, change)
+	}
+	return true, nil
+}
+
+func (m *mockFlightRepo) UpdateStatus(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+	if m.statusFunc != nil {
+		return m.statusFunc(ctx, flight, expectedStatus)
+	}
+	return true, nil
+}
+
+func (m *mockFlightRepo) FindBySchedule(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error) {
+	if m.scheduleFunc != nil {
+		return m.scheduleFunc(ctx, scheduleID, from)
+	}
+	return nil, nil
+}
+
+func (m *mockFlightRepo) FindByFlightNumber(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error) {
+	if m.numberFunc != nil {
+		return m.numberFunc(ctx, flightNumber, from, to)
+	}
+	return nil, nil
+}
+
+func (m *mockFlightRepo) FindDepartingBetween(ctx context.Context, from, to time.Time) ([]*model.Flight, error) {
+	if m.departingFunc != nil {
+		return m.departingFunc(ctx, from, to)
+	}
+	return nil, nil
+}
+
+func (m *mockFlightRepo) UpdateOverbooking(ctx context.Context, id, fareCode string, change int) (bool, error) {
+	if m.overbookFunc != nil {
+		return m.overbookFunc(ctx, id, fareCode, change)
+	}
+	return true, nil
+}
+
+func (m *mockFlightRepo) SaveBatch(ctx context.Context, created, updated []*model.Flight) error {
+	if m.saveBatchFunc != nil {
+		return m.saveBatchFunc(ctx, created, updated)
 	}
 	return nil
 }
@@ -73,14 +162,1816 @@ func TestCreateFlightSetsID(t *testing.T) {
 	}
 }
 
+func TestCreateFlightValidatesFareRules(t *testing.T) {
+	svc := NewFlightService(&mockFlightRepo{})
+	saver := model.FareBucket{Code: "V", Cabin: model.CabinEconomy, Price: 80, Seats: 10,
+		Rules: &model.FareRules{CancellationFee: 50, ChangeFee: 25, ChangeDeadlineHours: 24}}
+	flex := model.FareBucket{Code: "Y", Cabin: model.CabinEconomy, Price: 200, Seats: 10}
+
+	flight := &model.Flight{Fares: []model.FareBucket{saver, flex}}
+	if _, err := svc.CreateFlight(context.Background(), flight); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules := flight.Fares[0].FareRules(); rules.Refundable || rules.ChangeFee != 25 {
+		t.Fatalf("expected the saver rules to be kept, got %+v", rules)
+	}
+	if rules := flight.Fares[1].FareRules(); !rules.Refundable || rules.CancellationFee != 0 {
+		t.Fatalf("expected a fare without rules to be fully flexible, got %+v", rules)
+	}
+	departure := time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	if deadline := saver.FareRules().ChangeDeadline(departure); !deadline.Equal(departure.Add(-24 * time.Hour)) {
+		t.Fatalf("expected changes to close a day before departure, got %v", deadline)
+	}
+
+	saver.Rules = &model.FareRules{ChangeFee: -1}
+	if _, err := svc.CreateFlight(context.Background(), &model.Flight{Fares: []model.FareBucket{saver}}); err == nil {
+		t.Fatal("expected a negative change fee to be rejected")
+	}
+}
+
+// seatInventory stands in for the conditional seat updates of the store
+type seatInventory struct {
+	mu     sync.Mutex
+	flight *model.Flight
+}
+
+func (i *seatInventory) repo() *mockFlightRepo {
+	return &mockFlightRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			copied := *i.flight
+			copied.Fares = append([]model.FareBucket(nil), i.flight.Fares...)
+			return &copied, nil
+		},
+		updateSeatsFunc: func(ctx context.Context, id string, change int) (bool, error) {
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			if change > 0 && i.flight.AvailableSeats < change {
+				return false, nil
+			}
+			i.flight.AvailableSeats -= change
+			return true, nil
+		},
+		fareSeatsFunc: func(ctx context.Context, id, fareCode string, change int) (bool, error) {
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			fare, ok := i.flight.FindFare(fareCode)
+			if !ok || (change > 0 && fare.Seats < change) {
+				return false, nil
+			}
+			fare.Seats -= change
+			i.flight.AvailableSeats -= change
+			i.flight.Price = i.flight.HeadlinePrice()
+			return true, nil
+		},
+	}
+}
+
 func TestUpdateSeatsInsufficient(t *testing.T) {
+	inventory := &seatInventory{flight: &model.Flight{ID: "1", AvailableSeats: 1}}
+	svc := NewFlightService(inventory.repo())
+	if err := svc.UpdateSeats(context.Background(), "1", "", 2); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestUpdateSeatsNeverOversells(t *testing.T) {
+	inventory := &seatInventory{flight: &model.Flight{ID: "1", AvailableSeats: 10}}
+	svc := NewFlightService(inventory.repo())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sold := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := svc.UpdateSeats(context.Background(), "1", "", 1); err == nil {
+				mu.Lock()
+				sold++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sold != 10 || inventory.flight.AvailableSeats != 0 {
+		t.Fatalf("expected exactly 10 seats sold, got %d with %d left", sold, inventory.flight.AvailableSeats)
+	}
+}
+
+func TestUpdateFlightKeepsSeatsSoldWhileEditing(t *testing.T) {
+	edited := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	stored := &model.Flight{ID: "1", DepartureCity: "JNB", ArrivalCity: "CPT", Status: model.FlightStatusScheduled,
+		Capacity: 10, AvailableSeats: 10, Price: 100, UpdatedAt: edited}
+	sellDuringEdit := true
 	repo := &mockFlightRepo{
 		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
-			return &model.Flight{ID: id, AvailableSeats: 1}, nil
+			copied := *stored
+			if sellDuringEdit {
+				// A seat is sold between the admin's read and write
+				stored.AvailableSeats--
+				stored.UpdatedAt = edited.Add(time.Minute)
+			}
+			return &copied, nil
+		},
+		replaceFunc: func(ctx context.Context, flight *model.Flight, lastUpdated time.Time) (bool, error) {
+			if !stored.UpdatedAt.Equal(lastUpdated) {
+				return false, nil
+			}
+			copied := *flight
+			stored = &copied
+			return true, nil
 		},
 	}
 	svc := NewFlightService(repo)
-	if err := svc.UpdateSeats(context.Background(), "1", 2); err == nil {
-		t.Fatal("expected error")
+	ctx := context.Background()
+
+	update := *stored
+	update.Price = 120
+	if _, err := svc.UpdateFlight(ctx, "1", &update); err == nil {
+		t.Fatal("expected an edit racing a sale to be rejected")
+	}
+	if stored.AvailableSeats != 9 || stored.Price != 100 {
+		t.Fatalf("expected the sale to be kept, got %d seats at %.2f", stored.AvailableSeats, stored.Price)
+	}
+
+	sellDuringEdit = false
+	update = *stored
+	update.Price = 120
+	if _, err := svc.UpdateFlight(ctx, "1", &update); err != nil || stored.Price != 120 || stored.AvailableSeats != 9 {
+		t.Fatalf("expected the retried edit to apply, got %+v, %v", stored, err)
+	}
+}
+
+func TestSearchItinerariesRespectsConnectionWindows(t *testing.T) {
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	flights := []*model.Flight{
+		{ID: "direct", DepartureCity: "A", ArrivalCity: "C", DepartureTime: at(8, 0), ArrivalTime: at(14, 0), Price: 300, AvailableSeats: 5},
+		{ID: "a-b", DepartureCity: "A", ArrivalCity: "B", DepartureTime: at(7, 0), ArrivalTime: at(9, 0), Price: 100, AvailableSeats: 5},
+		{ID: "b-c-tight", DepartureCity: "B", ArrivalCity: "C", DepartureTime: at(9, 30), ArrivalTime: at(11, 0), Price: 80, AvailableSeats: 5},
+		{ID: "b-c", DepartureCity: "B", ArrivalCity: "C", DepartureTime: at(10, 30), ArrivalTime: at(12, 0), Price: 90, AvailableSeats: 5},
+		{ID: "b-a", DepartureCity: "B", ArrivalCity: "A", DepartureTime: at(10, 30), ArrivalTime: at(12, 0), Price: 90, AvailableSeats: 5},
+		{ID: "b-c-full", DepartureCity: "B", ArrivalCity: "C", DepartureTime: at(11, 0), ArrivalTime: at(12, 30), Price: 50, AvailableSeats: 1},
+	}
+	repo := &mockFlightRepo{
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			var out []*model.Flight
+			for _, f := range flights {
+				if f.DepartureCity == city && !f.DepartureTime.Before(from) && !f.DepartureTime.After(to) && f.AvailableSeats >= passengers {
+					out = append(out, f)
+				}
+			}
+			return out, nil
+		},
+	}
+	svc := NewFlightService(repo).WithConnectionPolicy(model.ConnectionPolicy{
+		Default:  model.ConnectionWindow{Min: 30 * time.Minute, Max: 4 * time.Hour},
+		Airports: map[string]model.ConnectionWindow{"B": {Min: time.Hour}},
+	})
+
+	one := 1
+	result, err := svc.SearchItineraries(context.Background(), model.ItinerarySearchRequest{
+		SearchFlightRequest: model.SearchFlightRequest{
+			DepartureCity: "A",
+			ArrivalCity:   "C",
+			DepartureDate: day,
+			Passengers:    2,
+		},
+		MaxStops: &one,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	itineraries := result.Itineraries
+	if len(itineraries) != 2 {
+		t.Fatalf("expected 2 itineraries got %d", len(itineraries))
+	}
+
+	connecting := itineraries[0]
+	if connecting.Stops != 1 || connecting.Legs[0].ID != "a-b" || connecting.Legs[1].ID != "b-c" {
+		t.Fatalf("unexpected connecting itinerary: %+v", connecting)
+	}
+	if connecting.DurationMinutes != 300 || connecting.TotalPrice != 380 {
+		t.Fatalf("unexpected totals: %d minutes, %.2f", connecting.DurationMinutes, connecting.TotalPrice)
+	}
+	if itineraries[1].Stops != 0 || itineraries[1].Legs[0].ID != "direct" {
+		t.Fatalf("unexpected direct itinerary: %+v", itineraries[1])
+	}
+}
+
+func TestSearchItinerariesLooksUpEachConnectionOnce(t *testing.T) {
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	flights := []*model.Flight{
+		{ID: "a-b-early", DepartureCity: "A", ArrivalCity: "B", DepartureTime: at(6), ArrivalTime: at(8), Price: 100, AvailableSeats: 5},
+		{ID: "a-b-late", DepartureCity: "A", ArrivalCity: "B", DepartureTime: at(8), ArrivalTime: at(10), Price: 100, AvailableSeats: 5},
+		{ID: "b-c", DepartureCity: "B", ArrivalCity: "C", DepartureTime: at(11), ArrivalTime: at(13), Price: 90, AvailableSeats: 5},
+		{ID: "b-d", DepartureCity: "B", ArrivalCity: "D", DepartureTime: at(11), ArrivalTime: at(12), Price: 40, AvailableSeats: 5},
+	}
+	lookups := map[string]int{}
+	repo := &mockFlightRepo{
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			lookups[city]++
+			var out []*model.Flight
+			for _, f := range flights {
+				if f.DepartureCity == city && !f.DepartureTime.Before(from) && !f.DepartureTime.After(to) {
+					copied := *f
+					out = append(out, &copied)
+				}
+			}
+			return out, nil
+		},
+	}
+	svc := NewFlightService(repo).
+		WithConnectionPolicy(model.ConnectionPolicy{Default: model.ConnectionWindow{Min: 30 * time.Minute, Max: 4 * time.Hour}}).
+		WithPricing(NewRuleBasedPricing(&mockPricingRulesRepo{}), cache.NewMockCacheClient())
+
+	one := 1
+	result, err := svc.SearchItineraries(context.Background(), model.ItinerarySearchRequest{
+		SearchFlightRequest: model.SearchFlightRequest{DepartureCity: "A", ArrivalCity: "C", DepartureDate: day, Passengers: 1},
+		MaxStops:            &one,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Every depth is searched so the stops facet is complete, which looks up D once
+	itineraries := result.Itineraries
+	if len(itineraries) != 2 || lookups["A"] != 1 || lookups["B"] != 1 || lookups["D"] != 1 {
+		t.Fatalf("expected both connections through one lookup at B, got %d itineraries and lookups %v", len(itineraries), lookups)
+	}
+	for _, itinerary := range itineraries {
+		for _, leg := range itinerary.Legs {
+			if leg.QuoteID == "" {
+				t.Fatalf("expected every leg of a complete itinerary to be quoted, %s was not", leg.ID)
+			}
+		}
+	}
+}
+
+func TestSearchItinerariesAppliesSearchOptions(t *testing.T) {
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	flights := []*model.Flight{
+		{ID: "direct", FlightNumber: "BA1", DepartureCity: "A", ArrivalCity: "C", DepartureTime: at(8, 0), ArrivalTime: at(14, 0), Price: 300, AvailableSeats: 5},
+		{ID: "a-b", FlightNumber: "AA1", DepartureCity: "A", ArrivalCity: "B", DepartureTime: at(7, 0), ArrivalTime: at(9, 0), Price: 100, AvailableSeats: 5},
+		{ID: "b-c", FlightNumber: "BA2", DepartureCity: "B", ArrivalCity: "C", DepartureTime: at(10, 30), ArrivalTime: at(12, 0), Price: 90, AvailableSeats: 5},
+	}
+	svc := NewFlightService(&mockFlightRepo{
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			var out []*model.Flight
+			for _, f := range flights {
+				if f.DepartureCity == city && !f.DepartureTime.Before(from) && !f.DepartureTime.After(to) {
+					out = append(out, f)
+				}
+			}
+			return out, nil
+		},
+	})
+	search := func(maxStops *int, options model.SearchOptions) *model.ItinerarySearchResult {
+		t.Helper()
+		result, err := svc.SearchItineraries(context.Background(), model.ItinerarySearchRequest{
+			SearchFlightRequest: model.SearchFlightRequest{DepartureCity: "A", ArrivalCity: "C", DepartureDate: day, SearchOptions: options},
+			MaxStops:            maxStops,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	none, one := 0, 1
+	first := search(&one, model.SearchOptions{Sort: model.SortByPrice, Limit: 1})
+	if first.Total != 2 || len(first.Itineraries) != 1 || first.Itineraries[0].Stops != 1 || first.NextCursor == "" {
+		t.Fatalf("expected the cheaper connection first of 2, got %+v", first)
+	}
+	facets := first.Facets
+	if len(facets.Stops) != 2 || facets.Stops[0] != (model.FacetCount{Value: "0", Count: 1}) || facets.Stops[1] != (model.FacetCount{Value: "1", Count: 1}) {
+		t.Fatalf("unexpected stops facet: %+v", facets.Stops)
+	}
+	if len(facets.Carriers) != 2 || facets.Carriers[0] != (model.FacetCount{Value: "BA", Count: 2}) {
+		t.Fatalf("expected BA on both itineraries, got %+v", facets.Carriers)
+	}
+	if facets.Price == nil || facets.Price.Min != 190 || facets.Price.Max != 300 {
+		t.Fatalf("unexpected price facet: %+v", facets.Price)
+	}
+
+	second := search(&one, model.SearchOptions{Sort: model.SortByPrice, Limit: 1, Cursor: first.NextCursor})
+	if len(second.Itineraries) != 1 || second.Itineraries[0].Legs[0].ID != "direct" || second.NextCursor != "" {
+		t.Fatalf("expected the direct flight on the last page, got %+v", second)
+	}
+
+	// Every leg must be flown by a chosen carrier
+	if ba := search(&one, model.SearchOptions{Carriers: []string{"BA"}}); ba.Total != 1 || ba.Itineraries[0].Stops != 0 {
+		t.Fatalf("expected only the direct BA itinerary, got %+v", ba.Itineraries)
+	}
+
+	// max_stops filters the results but not the facets
+	direct := search(&none, model.SearchOptions{})
+	if direct.Total != 1 || direct.Itineraries[0].Stops != 0 || len(direct.Facets.Stops) != 2 {
+		t.Fatalf("expected one direct itinerary with both stop counts faceted, got %+v", direct)
+	}
+
+	// Without max_stops connections are included
+	if all := search(nil, model.SearchOptions{}); all.Total != 2 {
+		t.Fatalf("expected connections when max_stops is not set, got %d itineraries", all.Total)
+	}
+}
+
+func TestSearchTripsSequencesSlicesBeforeCuttingThem(t *testing.T) {
+	out := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	back := out.Add(24 * time.Hour)
+
+	// The outbound lands at noon on the return day, after the cheapest
+	// returns have left
+	flights := []*model.Flight{
+		{ID: "out", DepartureCity: "A", ArrivalCity: "B", DepartureTime: out.Add(22 * time.Hour), ArrivalTime: back.Add(12 * time.Hour), Price: 100, AvailableSeats: 5},
+		{ID: "back-late", DepartureCity: "B", ArrivalCity: "A", DepartureTime: back.Add(18 * time.Hour), ArrivalTime: back.Add(20 * time.Hour), Price: 500, AvailableSeats: 5},
+	}
+	for i := 0; i < model.MaxItinerariesPerSlice; i++ {
+		flights = append(flights, &model.Flight{ID: fmt.Sprintf("back-early-%d", i), DepartureCity: "B", ArrivalCity: "A",
+			DepartureTime: back.Add(time.Duration(i) * time.Minute), ArrivalTime: back.Add(2 * time.Hour), Price: 50, AvailableSeats: 5})
+	}
+	svc := NewFlightService(&mockFlightRepo{
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			var result []*model.Flight
+			for _, f := range flights {
+				if f.DepartureCity == city && !f.DepartureTime.Before(from) && !f.DepartureTime.After(to) {
+					result = append(result, f)
+				}
+			}
+			return result, nil
+		},
+	})
+
+	direct := 0
+	options, err := svc.SearchTrips(context.Background(), model.TripSearchRequest{
+		TripType: model.TripTypeRoundTrip,
+		Slices: []model.TripSlice{
+			{DepartureCity: "A", ArrivalCity: "B", DepartureDate: out},
+			{DepartureCity: "B", ArrivalCity: "A", DepartureDate: back},
+		},
+		Passengers: 1,
+		MaxStops:   &direct,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(options) != 1 || options[0].Slices[1].Legs[0].ID != "back-late" {
+		t.Fatalf("expected the only return that can follow the outbound, got %d options", len(options))
+	}
+}
+
+func TestSearchTripsCombinesRoundTrip(t *testing.T) {
+	out := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	back := out.Add(24 * time.Hour)
+
+	flights := []*model.Flight{
+		{ID: "out-late", DepartureCity: "A", ArrivalCity: "B", DepartureTime: out.Add(20 * time.Hour), ArrivalTime: back.Add(1 * time.Hour), Price: 100, AvailableSeats: 5},
+		{ID: "out", DepartureCity: "A", ArrivalCity: "B", DepartureTime: out.Add(8 * time.Hour), ArrivalTime: out.Add(10 * time.Hour), Price: 150, AvailableSeats: 5},
+		{ID: "back-early", DepartureCity: "B", ArrivalCity: "A", DepartureTime: back.Add(1 * time.Hour), ArrivalTime: back.Add(3 * time.Hour), Price: 50, AvailableSeats: 5},
+		{ID: "back", DepartureCity: "B", ArrivalCity: "A", DepartureTime: back.Add(18 * time.Hour), ArrivalTime: back.Add(20 * time.Hour), Price: 120, AvailableSeats: 5},
+	}
+	repo := &mockFlightRepo{
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			var result []*model.Flight
+			for _, f := range flights {
+				if f.DepartureCity == city && !f.DepartureTime.Before(from) && !f.DepartureTime.After(to) {
+					result = append(result, f)
+				}
+			}
+			return result, nil
+		},
+	}
+	svc := NewFlightService(repo)
+
+	options, err := svc.SearchTrips(context.Background(), model.TripSearchRequest{
+		TripType: model.TripTypeRoundTrip,
+		Slices: []model.TripSlice{
+			{DepartureCity: "A", ArrivalCity: "B", DepartureDate: out},
+			{DepartureCity: "B", ArrivalCity: "A", DepartureDate: back},
+		},
+		Passengers: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// out-late arrives after back-early departs, so that pairing is dropped
+	if len(options) != 3 {
+		t.Fatalf("expected 3 options got %d", len(options))
+	}
+	cheapest := options[0]
+	if cheapest.Slices[0].Legs[0].ID != "out" || cheapest.Slices[1].Legs[0].ID != "back-early" {
+		t.Fatalf("unexpected cheapest option: %+v", cheapest)
+	}
+	if cheapest.TotalPrice != 400 {
+		t.Fatalf("expected total 400 got %.2f", cheapest.TotalPrice)
+	}
+
+	_, err = svc.SearchTrips(context.Background(), model.TripSearchRequest{
+		TripType: model.TripTypeRoundTrip,
+		Slices: []model.TripSlice{
+			{DepartureCity: "A", ArrivalCity: "B", DepartureDate: out},
+			{DepartureCity: "B", ArrivalCity: "C", DepartureDate: back},
+		},
+		Passengers: 1,
+	})
+	if err == nil {
+		t.Fatal("expected mismatched round trip to be rejected")
+	}
+}
+
+func TestGetFareCalendarFillsMonthAndCaches(t *testing.T) {
+	valentine := time.Date(2025, 2, 14, 9, 0, 0, 0, time.UTC)
+	flights := []model.Flight{
+		// Sold out at 40, and 80% full so the open fare is priced at 2x
+		{ID: "full", DepartureCity: "A", ArrivalCity: "B", DepartureTime: valentine, Capacity: 10, AvailableSeats: 2, Fares: []model.FareBucket{
+			{Code: "V", Cabin: model.CabinEconomy, Price: 40, Seats: 0},
+			{Code: "Y", Cabin: model.CabinEconomy, Price: 60, Seats: 2},
+		}},
+		{ID: "empty", DepartureCity: "A", ArrivalCity: "B", DepartureTime: valentine.Add(6 * time.Hour), Capacity: 10, AvailableSeats: 10, Fares: []model.FareBucket{
+			{Code: "Y", Cabin: model.CabinEconomy, Price: 99, Seats: 10},
+		}},
+	}
+	calls := 0
+	repo := &mockFlightRepo{
+		routeFunc: func(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			calls++
+			if !from.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("unexpected range %s - %s", from, to)
+			}
+			var out []*model.Flight
+			for _, flight := range flights {
+				copied := flight
+				copied.Fares = append([]model.FareBucket(nil), flight.Fares...)
+				out = append(out, &copied)
+			}
+			return out, nil
+		},
+	}
+	pricing := NewRuleBasedPricing(&mockPricingRulesRepo{rules: &model.PricingRules{
+		Enabled:    true,
+		LoadFactor: []model.LoadFactorTier{{MinLoadFactor: 0.5, Multiplier: 2}},
+	}})
+	svc := NewFlightService(repo).
+		WithPricing(pricing, cache.NewMockCacheClient()).
+		WithCalendarCache(cache.NewSimpleCache(cache.NewMockCacheClient(), time.Minute))
+
+	req := model.FareCalendarRequest{DepartureCity: "A", ArrivalCity: "B", Month: "2025-02", Passengers: 1}
+	for i := 0; i < 2; i++ {
+		calendar, err := svc.GetFareCalendar(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calendar.Days) != 28 {
+			t.Fatalf("expected 28 days got %d", len(calendar.Days))
+		}
+		day := calendar.Days[13]
+		if !day.Available || day.LowestPrice != 99 || day.Flights != 2 {
+			t.Fatalf("expected the priced fare with seats, got %+v", day)
+		}
+		if calendar.Days[0].Available {
+			t.Fatal("expected first day to be unavailable")
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected repository to be queried once, got %d", calls)
+	}
+
+	if _, err := svc.GetFareCalendar(context.Background(), model.FareCalendarRequest{DepartureCity: "A", ArrivalCity: "B", Month: "Feb"}); err == nil {
+		t.Fatal("expected invalid month to be rejected")
+	}
+}
+
+func TestFareCalendarFollowsFlightWrites(t *testing.T) {
+	inventory := &seatInventory{flight: &model.Flight{ID: "1", DepartureCity: "A", ArrivalCity: "B",
+		DepartureTime: time.Date(2025, 2, 14, 9, 0, 0, 0, time.UTC), Fares: []model.FareBucket{
+			{Code: "Y", Cabin: model.CabinEconomy, Price: 60, Seats: 2},
+			{Code: "J", Cabin: model.CabinBusiness, Price: 200, Seats: 2},
+		}}}
+	if err := normalizeFares(inventory.flight); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo := inventory.repo()
+	repo.routeFunc = func(ctx context.Context, departureCity, arrivalCity string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+		if from.Month() != time.February {
+			return nil, errors.New("connection reset")
+		}
+		flight, _ := repo.findByIDFunc(ctx, "1")
+		return []*model.Flight{flight}, nil
+	}
+	client := cache.NewMockCacheClient()
+	builder := cache.NewCacheBuilder(client).WithTTL(time.Minute)
+	svc := NewFlightService(repo).
+		WithFlightCache(client, builder.BuildSimple(), builder.BuildSimple(), time.Minute).
+		WithCalendarCache(builder.BuildSimple())
+	ctx := context.Background()
+
+	req := model.FareCalendarRequest{DepartureCity: "A", ArrivalCity: "B", Month: "2025-02"}
+	if calendar, err := svc.GetFareCalendar(ctx, req); err != nil || calendar.Days[13].LowestPrice != 60 {
+		t.Fatalf("expected 60, got %+v, %v", calendar, err)
+	}
+	// Selling out economy is seen without waiting for the calendar to expire
+	if err := svc.UpdateSeats(ctx, "1", "Y", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calendar, err := svc.GetFareCalendar(ctx, req); err != nil || calendar.Days[13].LowestPrice != 200 {
+		t.Fatalf("expected business at 200 once economy sold out, got %+v, %v", calendar, err)
+	}
+
+	req.Month = "2025-03"
+	if _, err := svc.GetFareCalendar(ctx, req); err == nil {
+		t.Fatal("expected a failed lookup to be reported")
+	}
+}
+
+type mockSeatMapRepo struct {
+	taken    map[string]bool
+	released []string
+}
+
+func (m *mockSeatMapRepo) FindByFlightID(ctx context.Context, flightID string) (*model.SeatMap, error) {
+	return nil, nil
+}
+
+func (m *mockSeatMapRepo) Create(ctx context.Context, seatMap *model.SeatMap) error { return nil }
+
+func (m *mockSeatMapRepo) Replace(ctx context.Context, seatMap *model.SeatMap, expectedUpdatedAt time.Time) (bool, error) {
+	return true, nil
+}
+
+func (m *mockSeatMapRepo) AssignSeat(ctx context.Context, flightID, seatNumber, bookingID string) (bool, error) {
+	if m.taken[seatNumber] {
+		return false, nil
+	}
+	m.taken[seatNumber] = true
+	return true, nil
+}
+
+func (m *mockSeatMapRepo) ReleaseSeat(ctx context.Context, flightID, seatNumber, bookingID string) error {
+	delete(m.taken, seatNumber)
+	m.released = append(m.released, seatNumber)
+	return nil
+}
+
+func TestConfigureSeatMapBuildsSeatAttributes(t *testing.T) {
+	repo := &mockFlightRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
+			return &model.Flight{ID: id}, nil
+		},
+	}
+	svc := NewFlightService(repo).WithSeatMaps(&mockSeatMapRepo{taken: map[string]bool{}})
+
+	seatMap, err := svc.ConfigureSeatMap(context.Background(), "1", &model.ConfigureSeatMapRequest{
+		Layout: []model.CabinLayout{
+			{Cabin: "economy", FirstRow: 10, LastRow: 12, SeatLetters: "ABC DEF", ExitRows: []int{11}, BlockedSeats: []string{"12f"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(seatMap.Seats) != 18 || seatMap.AvailableSeats() != 17 {
+		t.Fatalf("expected 18 seats with 17 available, got %d/%d", len(seatMap.Seats), seatMap.AvailableSeats())
+	}
+
+	seats := make(map[string]model.Seat)
+	for _, seat := range seatMap.Seats {
+		seats[seat.Number] = seat
+	}
+	if !seats["10A"].Window || seats["10A"].Aisle {
+		t.Fatalf("expected 10A to be a window seat: %+v", seats["10A"])
+	}
+	if !seats["10C"].Aisle || !seats["10D"].Aisle || seats["10B"].Aisle {
+		t.Fatal("expected C and D to be aisle seats")
+	}
+	if !seats["11B"].ExitRow || seats["10B"].ExitRow {
+		t.Fatal("expected only row 11 to be an exit row")
+	}
+	if !seats["12F"].Blocked {
+		t.Fatal("expected 12F to be blocked")
+	}
+}
+
+func TestAssignSeatsRollsBackOnConflict(t *testing.T) {
+	seatRepo := &mockSeatMapRepo{taken: map[string]bool{"10C": true}}
+	svc := NewFlightService(&mockFlightRepo{}).WithSeatMaps(seatRepo)
+
+	err := svc.AssignSeats(context.Background(), "1", "booking", []string{"10A", "10B", "10C"})
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if seatRepo.taken["10A"] || seatRepo.taken["10B"] {
+		t.Fatal("expected earlier seats to be released")
+	}
+	if len(seatRepo.released) != 2 {
+		t.Fatalf("expected 2 released seats got %v", seatRepo.released)
+	}
+}
+
+func TestUpdateSeatsDecrementsFareBucket(t *testing.T) {
+	flight := &model.Flight{ID: "1", Fares: []model.FareBucket{
+		{Code: "Y", Cabin: model.CabinEconomy, Price: 100, Seats: 10},
+		{Code: "J", Cabin: model.CabinBusiness, Price: 900, Seats: 2},
+	}}
+	if err := normalizeFares(flight); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inventory := &seatInventory{flight: flight}
+	svc := NewFlightService(inventory.repo())
+
+	if err := svc.UpdateSeats(context.Background(), "1", "J", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fare, _ := flight.FindFare("J"); fare.Seats != 0 || flight.AvailableSeats != 10 {
+		t.Fatalf("unexpected update: %d fare seats, %d total", fare.Seats, flight.AvailableSeats)
+	}
+	if err := svc.UpdateSeats(context.Background(), "1", "J", 3); err == nil {
+		t.Fatal("expected business bucket to be exhausted")
+	}
+	if err := svc.UpdateSeats(context.Background(), "1", "F", 1); err == nil {
+		t.Fatal("expected unknown fare to be rejected")
+	}
+}
+
+func TestHeadlinePriceSkipsSoldOutFares(t *testing.T) {
+	flight := &model.Flight{ID: "1", Fares: []model.FareBucket{
+		{Code: "V", Cabin: model.CabinEconomy, Price: 60, Seats: 0},
+		{Code: "Y", Cabin: model.CabinEconomy, Price: 100, Seats: 2},
+		{Code: "J", Cabin: model.CabinBusiness, Price: 900, Seats: 2},
+	}}
+	if err := normalizeFares(flight); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flight.Price != 100 {
+		t.Fatalf("expected the sold-out saver fare to be skipped, got %.2f", flight.Price)
+	}
+
+	// Selling out the cheapest open fare moves the headline price up
+	inventory := &seatInventory{flight: flight}
+	svc := NewFlightService(inventory.repo())
+	if err := svc.UpdateSeats(context.Background(), "1", "Y", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flight.Price != 900 {
+		t.Fatalf("expected headline price 900 once economy sold out, got %.2f", flight.Price)
+	}
+	if err := svc.UpdateSeats(context.Background(), "1", "V", -1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flight.Price != 60 {
+		t.Fatalf("expected the returned saver seat to lower the price, got %.2f", flight.Price)
+	}
+
+	// Once every fare is sold out the cheapest fare is shown
+	flight.Fares[0].Seats, flight.Fares[2].Seats = 0, 0
+	if price := flight.HeadlinePrice(); price != 60 {
+		t.Fatalf("expected the cheapest fare of a sold-out flight, got %.2f", price)
+	}
+}
+
+type mockSeatHoldRepo struct {
+	mu    sync.Mutex
+	holds map[string]*model.SeatHold
+}
+
+func (m *mockSeatHoldRepo) Create(ctx context.Context, hold *model.SeatHold) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *hold
+	m.holds[hold.ID] = &copied
+	return nil
+}
+
+func (m *mockSeatHoldRepo) FindByID(ctx context.Context, id string) (*model.SeatHold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hold, ok := m.holds[id]; ok {
+		copied := *hold
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockSeatHoldRepo) Convert(ctx context.Context, id, bookingID string, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hold, ok := m.holds[id]
+	if !ok || hold.Status != model.SeatHoldActive || !now.Before(hold.ExpiresAt) {
+		return false, nil
+	}
+	hold.Status, hold.BookingID = model.SeatHoldConverted, bookingID
+	return true, nil
+}
+
+func (m *mockSeatHoldRepo) Unconvert(ctx context.Context, id, bookingID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hold, ok := m.holds[id]
+	if !ok || hold.Status != model.SeatHoldConverted || hold.BookingID != bookingID {
+		return false, nil
+	}
+	hold.Status, hold.BookingID = model.SeatHoldActive, ""
+	return true, nil
+}
+
+func (m *mockSeatHoldRepo) Close(ctx context.Context, id string, status model.SeatHoldStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hold, ok := m.holds[id]
+	if !ok || hold.Status != model.SeatHoldActive {
+		return false, nil
+	}
+	hold.Status = status
+	return true, nil
+}
+
+func (m *mockSeatHoldRepo) FindExpired(ctx context.Context, now time.Time) ([]*model.SeatHold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var expired []*model.SeatHold
+	for _, hold := range m.holds {
+		if hold.Status == model.SeatHoldActive && !now.Before(hold.ExpiresAt) {
+			copied := *hold
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+func TestSeatHoldsExpireAndConvert(t *testing.T) {
+	flight := &model.Flight{ID: "1", Status: model.FlightStatusScheduled, Fares: []model.FareBucket{
+		{Code: "Y", Cabin: model.CabinEconomy, Price: 100, Seats: 4},
+	}}
+	if err := normalizeFares(flight); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inventory := &seatInventory{flight: flight}
+	holds := &mockSeatHoldRepo{holds: map[string]*model.SeatHold{}}
+	svc := NewFlightService(inventory.repo()).WithSeatHolds(holds, 15*time.Minute)
+	ctx := context.Background()
+
+	first, err := svc.HoldSeats(ctx, "u1", &model.SeatHoldRequest{FlightID: "1", Passengers: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.FareCode != "Y" || flight.AvailableSeats != 1 {
+		t.Fatalf("expected held seats to leave inventory, got fare %q and %d seats left", first.FareCode, flight.AvailableSeats)
+	}
+	if _, err := svc.HoldSeats(ctx, "u2", &model.SeatHoldRequest{FlightID: "1", Passengers: 2}); err == nil {
+		t.Fatal("expected held seats not to be sold twice")
+	}
+	if _, err := svc.HoldSeats(ctx, "u2", &model.SeatHoldRequest{FlightID: "1", Passengers: -2}); err == nil || flight.AvailableSeats != 1 {
+		t.Fatalf("expected a hold for no passengers to be refused, got %d seats left", flight.AvailableSeats)
+	}
+
+	// The first hold runs out before it is booked
+	holds.holds[first.ID].ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := svc.ConvertHold(ctx, first.ID, "b1"); err == nil {
+		t.Fatal("expected expired hold not to convert")
+	}
+	if expired, err := svc.ExpireHolds(ctx, time.Now()); err != nil || expired != 0 {
+		t.Fatalf("expected the hold to be expired once, got %d, %v", expired, err)
+	}
+	if flight.AvailableSeats != 4 || holds.holds[first.ID].Status != model.SeatHoldExpired {
+		t.Fatalf("expected expired seats back in inventory, got %d seats and status %s", flight.AvailableSeats, holds.holds[first.ID].Status)
+	}
+
+	second, err := svc.HoldSeats(ctx, "u2", &model.SeatHoldRequest{FlightID: "1", Passengers: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	converted, err := svc.ConvertHold(ctx, second.ID, "b2")
+	if err != nil || converted.BookingID != "b2" {
+		t.Fatalf("expected hold to convert, got %+v, %v", converted, err)
+	}
+	if _, err := svc.ReleaseHold(ctx, second.ID); err == nil {
+		t.Fatal("expected converted hold not to be released")
+	}
+
+	// A booking that fails to save hands the seats back to its hold
+	if err := svc.RevertHold(ctx, second.ID, "b3"); err == nil {
+		t.Fatal("expected only the converting booking to revert the hold")
+	}
+	if err := svc.RevertHold(ctx, second.ID, "b2"); err != nil || holds.holds[second.ID].Status != model.SeatHoldActive {
+		t.Fatalf("expected hold to be active again, got %s, %v", holds.holds[second.ID].Status, err)
+	}
+	if _, err := svc.ConvertHold(ctx, second.ID, "b2"); err != nil {
+		t.Fatalf("expected reverted hold to convert again: %v", err)
+	}
+	if expired, _ := svc.ExpireHolds(ctx, time.Now().Add(time.Hour)); expired != 0 || flight.AvailableSeats != 2 {
+		t.Fatalf("expected booked seats to stay sold, got %d expired and %d seats", expired, flight.AvailableSeats)
+	}
+
+	third, err := svc.HoldSeats(ctx, "u3", &model.SeatHoldRequest{FlightID: "1", Passengers: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.ReleaseHold(ctx, third.ID); err != nil || flight.AvailableSeats != 2 {
+		t.Fatalf("expected released seats back in inventory, got %d, %v", flight.AvailableSeats, err)
+	}
+}
+
+type mockPricingRulesRepo struct {
+	rules *model.PricingRules
+}
+
+func (m *mockPricingRulesRepo) Get(ctx context.Context) (*model.PricingRules, error) {
+	return m.rules, nil
+}
+
+func (m *mockPricingRulesRepo) Save(ctx context.Context, rules *model.PricingRules) error {
+	m.rules = rules
+	return nil
+}
+
+func TestDynamicPricingHonoursQuotedPrice(t *testing.T) {
+	// A departure three days out with 80% of seats sold
+	departure := time.Now().Add(3*24*time.Hour + time.Hour)
+	weekday := strings.ToLower(departure.Weekday().String())
+	flight := &model.Flight{ID: "1", DepartureTime: departure, Capacity: 100, AvailableSeats: 20, Price: 100}
+
+	pricing := NewRuleBasedPricing(&mockPricingRulesRepo{})
+	svc := NewFlightService(&mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			copied := *flight
+			return []*model.Flight{&copied}, nil
+		},
+	}).WithPricing(pricing, cache.NewMockCacheClient())
+
+	_, err := svc.UpdatePricingRules(context.Background(), "admin", &model.PricingRules{
+		Enabled: true,
+		LoadFactor: []model.LoadFactorTier{
+			{MinLoadFactor: 0.5, Multiplier: 1.1},
+			{MinLoadFactor: 0.75, Multiplier: 1.25},
+		},
+		AdvancePurchase: []model.AdvancePurchaseTier{
+			{MaxDaysOut: 30, Multiplier: 1.1},
+			{MaxDaysOut: 7, Multiplier: 1.2},
+		},
+		DayOfWeek:     map[string]float64{weekday: 0.9},
+		MaxMultiplier: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := svc.SearchFlights(context.Background(), model.SearchFlightRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	quoted := results.Flights[0]
+	if quoted.Price != 135 || quoted.QuoteID == "" {
+		t.Fatalf("expected quoted price 135 with a quote, got %.2f %q", quoted.Price, quoted.QuoteID)
+	}
+
+	// Selling more seats raises the live price, but the quote still holds
+	flight.AvailableSeats = 5
+	if _, err := svc.UpdatePricingRules(context.Background(), "admin", &model.PricingRules{Enabled: true, LoadFactor: []model.LoadFactorTier{{MinLoadFactor: 0.9, Multiplier: 2}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fare := model.FareBucket{Cabin: model.CabinEconomy, Price: flight.Price}
+	price, err := svc.FarePrice(context.Background(), flight, fare, quoted.QuoteID)
+	if err != nil || price != 135 {
+		t.Fatalf("expected quoted price 135, got %.2f (%v)", price, err)
+	}
+	live, err := svc.FarePrice(context.Background(), flight, fare, "")
+	if err != nil || live != 200 {
+		t.Fatalf("expected live price 200, got %.2f (%v)", live, err)
+	}
+	if _, err := svc.FarePrice(context.Background(), flight, fare, "unknown"); err == nil {
+		t.Fatal("expected unknown quote to be rejected")
+	}
+}
+
+type mockStatusRepo struct {
+	events []*model.FlightStatusEvent
+}
+
+func (m *mockStatusRepo) Record(ctx context.Context, event *model.FlightStatusEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockStatusRepo) ListByFlight(ctx context.Context, flightID string) ([]*model.FlightStatusEvent, error) {
+	return m.events, nil
+}
+
+func TestTransitionStatusEnforcesLifecycle(t *testing.T) {
+	departure := time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+	stored := &model.Flight{ID: "1", Status: model.FlightStatusScheduled, DepartureTime: departure, ArrivalTime: departure.Add(2 * time.Hour)}
+	repo := &mockFlightRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
+			copied := *stored
+			return &copied, nil
+		},
+		statusFunc: func(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+			if expectedStatus != stored.Status {
+				return false, nil
+			}
+			stored = flight
+			return true, nil
+		},
+	}
+	history := &mockStatusRepo{}
+	svc := NewFlightService(repo).WithStatusHistory(history)
+	ctx := context.Background()
+
+	if _, err := svc.TransitionStatus(ctx, "1", model.FlightStatusLanded, "admin", &model.FlightStatusRequest{}); err == nil {
+		t.Fatal("expected scheduled -> landed to be rejected")
+	}
+	if _, err := svc.TransitionStatus(ctx, "1", model.FlightStatusDelayed, "admin", &model.FlightStatusRequest{}); err == nil {
+		t.Fatal("expected delay without estimate to be rejected")
+	}
+
+	estimate := departure.Add(90 * time.Minute)
+	flight, err := svc.TransitionStatus(ctx, "1", model.FlightStatusDelayed, "admin", &model.FlightStatusRequest{EstimatedDepartureTime: &estimate, Reason: "weather"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !flight.EstimatedArrivalTime.Equal(departure.Add(210 * time.Minute)) {
+		t.Fatalf("expected estimated arrival to shift with the delay, got %s", flight.EstimatedArrivalTime)
+	}
+
+	for _, status := range []string{model.FlightStatusBoarding, model.FlightStatusDeparted, model.FlightStatusLanded} {
+		if _, err := svc.TransitionStatus(ctx, "1", status, "admin", &model.FlightStatusRequest{}); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+	if stored.ActualDepartureTime == nil || stored.ActualArrivalTime == nil {
+		t.Fatal("expected actual times to be recorded")
+	}
+	if _, err := svc.TransitionStatus(ctx, "1", model.FlightStatusCancelled, "admin", &model.FlightStatusRequest{}); err == nil {
+		t.Fatal("expected landed flight to be terminal")
+	}
+
+	if len(history.events) != 4 || history.events[0].From != model.FlightStatusScheduled || history.events[3].To != model.FlightStatusLanded {
+		t.Fatalf("unexpected history: %+v", history.events)
+	}
+
+	if _, err := svc.UpdateFlight(ctx, "1", &model.Flight{Status: model.FlightStatusScheduled}); err == nil {
+		t.Fatal("expected status change through UpdateFlight to be rejected")
+	}
+}
+
+type recordingDisruptionHandler struct {
+	flights []*model.Flight
+}
+
+func (h *recordingDisruptionHandler) HandleDisruption(ctx context.Context, flight *model.Flight, event *model.FlightStatusEvent) {
+	h.flights = append(h.flights, flight)
+}
+
+func TestCancellationNotifiesDisruptionHandler(t *testing.T) {
+	departure := time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+	stored := &model.Flight{ID: "1", FlightNumber: "TF100", DepartureCity: "JNB", ArrivalCity: "CPT", Status: model.FlightStatusScheduled, DepartureTime: departure, ArrivalTime: departure.Add(2 * time.Hour)}
+	repo := &mockFlightRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
+			copied := *stored
+			return &copied, nil
+		},
+		statusFunc: func(ctx context.Context, flight *model.Flight, expectedStatus string) (bool, error) {
+			stored = flight
+			return true, nil
+		},
+		departuresFunc: func(ctx context.Context, city string, from, to time.Time, passengers int) ([]*model.Flight, error) {
+			return []*model.Flight{
+				stored,
+				{ID: "2", DepartureCity: "JNB", ArrivalCity: "DUR", DepartureTime: departure.Add(time.Hour)},
+				{ID: "3", DepartureCity: "JNB", ArrivalCity: "CPT", DepartureTime: departure.Add(3 * time.Hour)},
+			}, nil
+		},
+	}
+	handler := &recordingDisruptionHandler{}
+	svc := NewFlightService(repo).WithDisruptionHandler(handler)
+	ctx := context.Background()
+
+	if _, err := svc.TransitionStatus(ctx, "1", model.FlightStatusBoarding, "admin", &model.FlightStatusRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(handler.flights) != 0 {
+		t.Fatal("expected boarding not to be treated as a disruption")
+	}
+	stored.Status = model.FlightStatusScheduled
+	if _, err := svc.TransitionStatus(ctx, "1", model.FlightStatusCancelled, "admin", &model.FlightStatusRequest{Reason: "crew"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(handler.flights) != 1 || handler.flights[0].Status != model.FlightStatusCancelled {
+		t.Fatalf("expected handler to see the cancelled flight, got %+v", handler.flights)
+	}
+
+	alternatives, err := svc.FindAlternativeFlights(ctx, stored, departure, departure.Add(48*time.Hour), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alternatives) != 1 || alternatives[0].ID != "3" {
+		t.Fatalf("expected only the later flight on the same route, got %+v", alternatives)
+	}
+}
+
+type mockScheduleRepo struct {
+	schedules map[string]*model.ScheduleTemplate
+}
+
+func (m *mockScheduleRepo) Create(ctx context.Context, schedule *model.ScheduleTemplate) error {
+	m.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (m *mockScheduleRepo) FindByID(ctx context.Context, id string) (*model.ScheduleTemplate, error) {
+	return m.schedules[id], nil
+}
+
+func (m *mockScheduleRepo) Update(ctx context.Context, schedule *model.ScheduleTemplate) error {
+	m.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (m *mockScheduleRepo) List(ctx context.Context) ([]*model.ScheduleTemplate, error) {
+	var schedules []*model.ScheduleTemplate
+	for _, schedule := range m.schedules {
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func TestSchedulesMaterializeAndUpdateUnsoldFlights(t *testing.T) {
+	flights := map[string]*model.Flight{}
+	repo := &mockFlightRepo{
+		createFunc: func(ctx context.Context, flight *model.Flight) error {
+			flights[flight.ID] = flight
+			return nil
+		},
+		updateFunc: func(ctx context.Context, flight *model.Flight) error {
+			flights[flight.ID] = flight
+			return nil
+		},
+		deleteFunc: func(ctx context.Context, id string) error {
+			delete(flights, id)
+			return nil
+		},
+		scheduleFunc: func(ctx context.Context, scheduleID string, from time.Time) ([]*model.Flight, error) {
+			var result []*model.Flight
+			for _, f := range flights {
+				if f.ScheduleID == scheduleID && !f.DepartureTime.Before(from) {
+					copied := *f
+					result = append(result, &copied)
+				}
+			}
+			return result, nil
+		},
+		numberFunc: func(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error) {
+			var result []*model.Flight
+			for _, f := range flights {
+				if f.FlightNumber == flightNumber && !f.DepartureTime.Before(from) && f.DepartureTime.Before(to) {
+					result = append(result, f)
+				}
+			}
+			return result, nil
+		},
+	}
+	svc := NewFlightService(repo).WithSchedules(&mockScheduleRepo{schedules: map[string]*model.ScheduleTemplate{}})
+	ctx := context.Background()
+
+	loc, _ := time.LoadLocation("America/New_York")
+	start := time.Now().AddDate(0, 0, 1)
+	template := func(departureTime string, days ...string) *model.ScheduleTemplate {
+		return &model.ScheduleTemplate{
+			FlightNumber:    "TF100",
+			DepartureCity:   "JFK",
+			ArrivalCity:     "LHR",
+			DepartureTime:   departureTime,
+			TimeZone:        "America/New_York",
+			DurationMinutes: 420,
+			DaysOfWeek:      days,
+			ValidFrom:       start,
+			ValidTo:         start.AddDate(0, 0, 20),
+			Capacity:        100,
+			Price:           300,
+		}
+	}
+
+	// A one-off flight already uses the flight number on the first Monday
+	mondays, _ := template("09:00", "monday").Departures(start.Add(-24*time.Hour), start.AddDate(0, 0, 21))
+	flights["adhoc"] = &model.Flight{ID: "adhoc", FlightNumber: "TF100", DepartureTime: mondays[0].Add(-2 * time.Hour), Status: model.FlightStatusScheduled}
+
+	created, err := svc.CreateSchedule(ctx, template("09:00", "Monday", "wednesday", "monday"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schedule := created.Schedule
+	if len(schedule.DaysOfWeek) != 2 {
+		t.Fatalf("expected normalized days of week, got %v", schedule.DaysOfWeek)
+	}
+	report := created.Generation
+	if report.Created < 4 || len(report.Conflicts) != 1 || report.Conflicts[0].FlightID != "adhoc" {
+		t.Fatalf("unexpected generation report: %+v", report)
+	}
+	var sold *model.Flight
+	for _, f := range flights {
+		if f.ScheduleID == "" {
+			continue
+		}
+		local := f.DepartureTime.In(loc)
+		if local.Hour() != 9 || (local.Weekday() != time.Monday && local.Weekday() != time.Wednesday) {
+			t.Fatalf("unexpected departure %s", local)
+		}
+		if sold == nil && local.Weekday() == time.Wednesday {
+			sold = f
+		}
+	}
+	sold.AvailableSeats = 90
+	soldDeparture := sold.DepartureTime
+
+	// Retiming and dropping Wednesdays only touches unsold instances
+	updated, err := svc.UpdateSchedule(ctx, schedule.ID, template("10:30", "monday"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report = updated.Generation
+	if report.Created != 0 || report.Updated != len(mondays)-1 || report.Removed != created.Generation.Created-len(mondays) {
+		t.Fatalf("unexpected update report: %+v", report)
+	}
+	if len(report.Conflicts) != 2 {
+		t.Fatalf("expected flight number and sold instance conflicts, got %+v", report.Conflicts)
+	}
+	if !flights[sold.ID].DepartureTime.Equal(soldDeparture) {
+		t.Fatal("expected sold instance to be left unchanged")
+	}
+	for _, f := range flights {
+		if f.ScheduleID != "" && f.ID != sold.ID && f.DepartureTime.In(loc).Hour() != 10 {
+			t.Fatalf("expected unsold instance to be retimed, got %s", f.DepartureTime.In(loc))
+		}
+	}
+}
+
+func TestAirportRegistryValidatesRoutesAndLocalisesSearch(t *testing.T) {
+	airportRepo, err := embedded.NewEmbeddedAirportRepository()
+	if err != nil {
+		t.Fatalf("failed to load airports: %v", err)
+	}
+
+	var searched model.SearchFlightRequest
+	repo := &mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			searched = criteria
+			return nil, nil
+		},
+	}
+	svc := NewFlightService(repo).WithAirports(airportservice.NewAirportService(airportRepo))
+	ctx := context.Background()
+
+	if _, err := svc.CreateFlight(ctx, &model.Flight{DepartureCity: "jfk", ArrivalCity: "XXX", AvailableSeats: 10}); err == nil {
+		t.Fatal("expected unknown arrival airport to be rejected")
+	}
+	flight, err := svc.CreateFlight(ctx, &model.Flight{DepartureCity: "jfk", ArrivalCity: " lhr", AvailableSeats: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if flight.DepartureCity != "JFK" || flight.ArrivalCity != "LHR" {
+		t.Fatalf("expected codes to be normalized, got %s-%s", flight.DepartureCity, flight.ArrivalCity)
+	}
+
+	// 1 November 2026 is the day New York leaves daylight saving time
+	_, err = svc.SearchFlights(ctx, model.SearchFlightRequest{
+		DepartureCity: "JFK",
+		ArrivalCity:   "LHR",
+		DepartureDate: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		Passengers:    1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	from, to := searched.DepartureWindow()
+	if !from.Equal(time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 11, 2, 5, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected New York local day, got %s to %s", from.UTC(), to.UTC())
+	}
+}
+
+func TestSearchFlightsExpandsMetroCodes(t *testing.T) {
+	airportRepo, err := embedded.NewEmbeddedAirportRepository()
+	if err != nil {
+		t.Fatalf("failed to load airports: %v", err)
+	}
+
+	base := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	var searched []string
+	repo := &mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			searched = append(searched, criteria.DepartureCity+"-"+criteria.ArrivalCity)
+			offset := time.Duration(len(searched)) * time.Hour
+			return []*model.Flight{{ID: criteria.DepartureCity, DepartureTime: base.Add(24*time.Hour - offset)}}, nil
+		},
+	}
+	svc := NewFlightService(repo).WithAirports(airportservice.NewAirportService(airportRepo))
+
+	result, err := svc.SearchFlights(context.Background(), model.SearchFlightRequest{
+		DepartureCity: "NYC",
+		ArrivalCity:   "LHR",
+		DepartureDate: base,
+		Passengers:    1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(searched, ",") != "JFK-LHR,LGA-LHR,EWR-LHR" {
+		t.Fatalf("expected every New York airport to be searched, got %v", searched)
+	}
+	flights := result.Flights
+	if len(flights) != 3 || flights[0].ID != "EWR" || flights[2].ID != "JFK" {
+		t.Fatalf("expected merged results ordered by departure, got %+v", flights)
+	}
+}
+
+type stubProvider struct {
+	name    string
+	flights []*model.Flight
+	err     error
+	delay   time.Duration
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	time.Sleep(p.delay)
+	return p.flights, p.err
+}
+
+func TestSearchFlightsMergesProvidersWithPartialResults(t *testing.T) {
+	departure := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	repo := &mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			return []*model.Flight{{ID: "local", FlightNumber: "BA178", DepartureTime: departure.Add(2 * time.Hour), Price: 500}}, nil
+		},
+	}
+	gds := &stubProvider{name: "gds", flights: []*model.Flight{
+		{ID: "gds-1", FlightNumber: "ba 178", DepartureTime: departure.Add(2 * time.Hour), Price: 450},
+		{ID: "gds-2", FlightNumber: "AA100", DepartureTime: departure},
+	}}
+	broken := &stubProvider{name: "broken", err: errors.New("connection refused by 10.0.0.1")}
+	slow := &stubProvider{name: "slow", delay: 200 * time.Millisecond, flights: []*model.Flight{{ID: "slow-1", FlightNumber: "DL1"}}}
+
+	svc := NewFlightService(repo).
+		WithProvider(gds, time.Second).
+		WithProvider(broken, time.Second).
+		WithProvider(slow, 20*time.Millisecond)
+
+	start := time.Now()
+	result, err := svc.SearchFlights(context.Background(), model.SearchFlightRequest{DepartureCity: "JFK", ArrivalCity: "LHR", DepartureDate: departure})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Fatal("expected the slow provider to be abandoned at its timeout")
+	}
+
+	if len(result.Flights) != 2 || result.Flights[0].ID != "gds-2" || result.Flights[1].ID != "local" {
+		t.Fatalf("expected catalog flight to win the duplicate and results sorted, got %+v", result.Flights)
+	}
+	if !result.Partial {
+		t.Fatal("expected partial results")
+	}
+	want := map[string]string{
+		model.CatalogProvider: model.ProviderStatusOK,
+		"gds":                 model.ProviderStatusOK,
+		"broken":              model.ProviderStatusError,
+		"slow":                model.ProviderStatusTimeout,
+	}
+	for _, status := range result.Providers {
+		if want[status.Name] != status.Status {
+			t.Fatalf("unexpected status for %s: %+v", status.Name, status)
+		}
+		if strings.Contains(status.Error, "10.0.0.1") {
+			t.Fatal("expected provider internals to stay out of the report")
+		}
+	}
+
+	repo.searchFunc = func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+		return nil, errors.New("mongo down")
+	}
+	if _, err := NewFlightService(repo).WithProvider(broken, time.Second).SearchFlights(context.Background(), model.SearchFlightRequest{}); err == nil {
+		t.Fatal("expected an error when every provider fails")
+	}
+}
+
+func TestSearchFlightsFiltersSortsAndPages(t *testing.T) {
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	flight := func(id, number string, departHour, minutes int, price float64) *model.Flight {
+		departure := day.Add(time.Duration(departHour) * time.Hour)
+		return &model.Flight{
+			ID:            id,
+			FlightNumber:  number,
+			DepartureCity: "JFK",
+			ArrivalCity:   "LAX",
+			DepartureTime: departure,
+			ArrivalTime:   departure.Add(time.Duration(minutes) * time.Minute),
+			Price:         price,
+		}
+	}
+	repo := &mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			return []*model.Flight{
+				flight("aa-early", "AA1", 7, 360, 300),
+				flight("aa-late", "AA2", 19, 330, 180),
+				flight("ua-noon", "UA9", 12, 300, 250),
+				flight("dl-night", "DL5", 23, 400, 120),
+			}, nil
+		},
+	}
+	svc := NewFlightService(repo)
+	search := func(options model.SearchOptions) *model.FlightSearchResult {
+		t.Helper()
+		result, err := svc.SearchFlights(context.Background(), model.SearchFlightRequest{
+			DepartureCity: "JFK",
+			ArrivalCity:   "LAX",
+			DepartureDate: day,
+			SearchOptions: options,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+	ids := func(flights []*model.Flight) string {
+		var out []string
+		for _, f := range flights {
+			out = append(out, f.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	result := search(model.SearchOptions{Sort: model.SortByPrice, Limit: 3})
+	if got := ids(result.Flights); got != "dl-night,aa-late,ua-noon" || result.Total != 4 || result.NextCursor == "" {
+		t.Fatalf("unexpected first page %s (total %d)", got, result.Total)
+	}
+	next := search(model.SearchOptions{Sort: model.SortByPrice, Limit: 3, Cursor: result.NextCursor})
+	if got := ids(next.Flights); got != "aa-early" || next.NextCursor != "" {
+		t.Fatalf("unexpected second page %s", got)
+	}
+
+	facets := result.Facets
+	if facets.Carriers[0].Value != "AA" || facets.Carriers[0].Count != 2 || facets.Price.Min != 120 || facets.Price.Max != 300 {
+		t.Fatalf("unexpected facets %+v", facets)
+	}
+	if facets.DepartureTimes[1].Value != "morning" || facets.DepartureTimes[1].Count != 1 || facets.DepartureTimes[3].Count != 2 {
+		t.Fatalf("unexpected departure time facet %+v", facets.DepartureTimes)
+	}
+
+	filtered := search(model.SearchOptions{Carriers: []string{"aa", "UA"}, MaxPrice: 260, Sort: model.SortByDuration})
+	if got := ids(filtered.Flights); got != "ua-noon,aa-late" {
+		t.Fatalf("unexpected filtered results %s", got)
+	}
+	if len(filtered.Facets.Carriers) != 3 {
+		t.Fatal("expected facets to ignore filters")
+	}
+
+	// Arrival window wrapping midnight: DL5 lands 05:40, AA2 lands 00:30
+	overnight := search(model.SearchOptions{ArrivalTimeFrom: "22:00", ArrivalTimeTo: "06:00", Sort: model.SortByArrival})
+	if got := ids(overnight.Flights); got != "aa-late,dl-night" {
+		t.Fatalf("unexpected overnight results %s", got)
+	}
+
+	for _, options := range []model.SearchOptions{
+		{Sort: "cheapest"},
+		{MinPrice: 300, MaxPrice: 100},
+		{DepartureTimeFrom: "25:00"},
+		{Cursor: result.NextCursor},
+		{Cursor: "not-a-cursor"},
+	} {
+		if _, err := svc.SearchFlights(context.Background(), model.SearchFlightRequest{SearchOptions: options}); err == nil {
+			t.Fatalf("expected options %+v to be rejected", options)
+		}
+	}
+}
+
+func TestImportFlightsReportsLinesAndUpsertsAtomically(t *testing.T) {
+	airportRepo, err := embedded.NewEmbeddedAirportRepository()
+	if err != nil {
+		t.Fatalf("failed to load airports: %v", err)
+	}
+
+	stored := map[string]*model.Flight{
+		"BA178": {ID: "ba-1", FlightNumber: "BA178", DepartureCity: "JFK", ArrivalCity: "LHR", Status: model.FlightStatusScheduled,
+			DepartureTime: time.Date(2026, 7, 1, 22, 0, 0, 0, time.UTC), ArrivalTime: time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC),
+			Capacity: 200, AvailableSeats: 150, Price: 500},
+		"AA100": {ID: "aa-1", FlightNumber: "AA100", DepartureCity: "JFK", ArrivalCity: "LAX", Status: model.FlightStatusDeparted,
+			DepartureTime: time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), ArrivalTime: time.Date(2026, 7, 1, 18, 0, 0, 0, time.UTC),
+			Capacity: 150, AvailableSeats: 0, Price: 300},
+	}
+	var created, updated []*model.Flight
+	saves := 0
+	repo := &mockFlightRepo{
+		numberFunc: func(ctx context.Context, flightNumber string, from, to time.Time) ([]*model.Flight, error) {
+			if flight, ok := stored[flightNumber]; ok {
+				copied := *flight
+				return []*model.Flight{&copied}, nil
+			}
+			return nil, nil
+		},
+		saveBatchFunc: func(ctx context.Context, c, u []*model.Flight) error {
+			saves++
+			created, updated = c, u
+			return nil
+		},
+	}
+	svc := NewFlightService(repo).WithAirports(airportservice.NewAirportService(airportRepo))
+	ctx := context.Background()
+
+	file := strings.Join([]string{
+		"flight_number,departure_city,arrival_city,departure_time,arrival_time,capacity,price",
+		"BA178,jfk,lhr,2026-07-01T22:00:00Z,2026-07-02T09:00:00Z,220,520",
+		"BA178,JFK,LHR,2026-07-02T22:00:00Z,2026-07-03T09:00:00Z,220,520",
+		"AA100,JFK,LAX,2026-07-01T13:00:00Z,2026-07-01T19:00:00Z,150,300",
+		"XX1,JFK,ZZZ,2026-07-01T13:00:00Z,2026-07-01T19:00:00Z,150,300",
+		"BA178,JFK,LHR,2026-07-02T23:00:00Z,2026-07-03T10:00:00Z,220,520",
+		"BA179,LHR,JFK,tomorrow,2026-07-03T10:00:00Z,220,520",
+	}, "\n")
+
+	report, err := svc.ImportFlights(ctx, model.ImportRequest{Format: "csv"}, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Applied || saves != 0 {
+		t.Fatal("expected nothing to be written while lines are invalid")
+	}
+	if report.Created != 1 || report.Updated != 1 || len(report.Errors) != 4 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	lines := map[int]bool{}
+	for _, lineErr := range report.Errors {
+		lines[lineErr.Line] = true
+	}
+	for _, line := range []int{4, 5, 6, 7} {
+		if !lines[line] {
+			t.Fatalf("expected an error on line %d, got %+v", line, report.Errors)
+		}
+	}
+
+	valid := strings.Join(strings.Split(file, "\n")[:3], "\n")
+	report, err = svc.ImportFlights(ctx, model.ImportRequest{Format: "csv", DryRun: true}, strings.NewReader(valid))
+	if err != nil || report.Applied || saves != 0 || len(report.Errors) != 0 {
+		t.Fatalf("expected a clean dry run without writes, got %+v (%v)", report, err)
+	}
+
+	report, err = svc.ImportFlights(ctx, model.ImportRequest{Format: "csv"}, strings.NewReader(valid))
+	if err != nil || !report.Applied || saves != 1 {
+		t.Fatalf("expected the import to be applied once, got %+v (%v)", report, err)
+	}
+	if len(created) != 1 || created[0].AvailableSeats != 220 || created[0].Status != model.FlightStatusScheduled {
+		t.Fatalf("unexpected created flights %+v", created)
+	}
+	if len(updated) != 1 || updated[0].ID != "ba-1" || updated[0].AvailableSeats != 170 || updated[0].Price != 520 {
+		t.Fatalf("expected sold seats to be kept on update, got %+v", updated)
+	}
+
+	// An SSIM export of a stored flight imports back unchanged
+	repo.departingFunc = func(ctx context.Context, from, to time.Time) ([]*model.Flight, error) {
+		return []*model.Flight{stored["BA178"]}, nil
+	}
+	var exported bytes.Buffer
+	if err := svc.ExportFlights(ctx, model.ExportRequest{Format: "ssim", From: time.Now(), To: time.Now().AddDate(1, 0, 0)}, &exported); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+	report, err = svc.ImportFlights(ctx, model.ImportRequest{Format: "ssim", DryRun: true}, &exported)
+	if err != nil || report.Flights != 1 || report.Unchanged != 1 || len(report.Errors) != 0 {
+		t.Fatalf("expected the SSIM export to round trip, got %+v (%v)", report, err)
+	}
+}
+
+type mockOverbookingRepo struct {
+	policy *model.OverbookingPolicy
+}
+
+func (m *mockOverbookingRepo) Get(ctx context.Context) (*model.OverbookingPolicy, error) {
+	return m.policy, nil
+}
+
+func (m *mockOverbookingRepo) Save(ctx context.Context, policy *model.OverbookingPolicy) error {
+	m.policy = policy
+	return nil
+}
+
+func TestOverbookingPolicySellsBeyondCapacity(t *testing.T) {
+	ctx := context.Background()
+	flight := &model.Flight{
+		ID: "1", DepartureCity: "JNB", ArrivalCity: "CPT", Status: model.FlightStatusScheduled,
+		Capacity: 100, AvailableSeats: 10,
+		Fares: []model.FareBucket{
+			{Code: "J", Cabin: model.CabinBusiness, Price: 300, Seats: 0},
+			{Code: "Y", Cabin: model.CabinEconomy, Price: 120, Seats: 4},
+			{Code: "V", Cabin: model.CabinEconomy, Price: 80, Seats: 6},
+		},
+	}
+
+	applied := map[string]int{}
+	svc := NewFlightService(&mockFlightRepo{
+		departingFunc: func(ctx context.Context, from, to time.Time) ([]*model.Flight, error) {
+			return []*model.Flight{flight}, nil
+		},
+		overbookFunc: func(ctx context.Context, id, fareCode string, change int) (bool, error) {
+			applied[fareCode] += change
+			return true, nil
+		},
+	}).WithOverbooking(&mockOverbookingRepo{})
+
+	invalid := &model.OverbookingPolicy{Rules: []model.OverbookingRule{{FlightID: "1", DepartureCity: "JNB", ArrivalCity: "CPT", Percent: 5}}}
+	if _, err := svc.UpdateOverbookingPolicy(ctx, "admin", invalid); err == nil {
+		t.Fatal("expected a rule naming both a flight and a route to be rejected")
+	}
+
+	// A route rule of 5% sells five extra seats from the cheapest economy fare
+	policy := &model.OverbookingPolicy{Rules: []model.OverbookingRule{{DepartureCity: "jnb", ArrivalCity: "cpt", Percent: 5}}}
+	if _, err := svc.UpdateOverbookingPolicy(ctx, "admin", policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied["V"] != 5 || flight.AvailableSeats != 15 || flight.SellableCapacity() != 105 || flight.Sold() != 90 {
+		t.Fatalf("expected five extra seats in V, got %v, %+v", applied, flight)
+	}
+
+	// A flight rule wins over the route, and shrinking it takes back unsold seats
+	policy = &model.OverbookingPolicy{Rules: []model.OverbookingRule{
+		{DepartureCity: "JNB", ArrivalCity: "CPT", Percent: 5},
+		{FlightID: "1", Seats: 2},
+	}}
+	if _, err := svc.UpdateOverbookingPolicy(ctx, "admin", policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied["V"] != 2 || flight.AvailableSeats != 12 || flight.Fares[2].Seats != 8 {
+		t.Fatalf("expected the flight rule to leave two extra seats, got %v, %+v", applied, flight)
+	}
+
+	// Dropping the rules takes back only the overbooked seats still unsold
+	flight.Fares[2].Seats, flight.AvailableSeats = 1, 5
+	if _, err := svc.UpdateOverbookingPolicy(ctx, "admin", &model.OverbookingPolicy{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied["V"] != 1 || flight.Overbooking != 1 || flight.Fares[2].Seats != 0 || flight.OverbookingFare != "V" {
+		t.Fatalf("expected one sold overbooked seat to stay, got %v, %+v", applied, flight)
+	}
+}
+
+func TestFlightCacheInvalidatesOnWrites(t *testing.T) {
+	ctx := context.Background()
+	departure := time.Date(2030, 3, 14, 9, 0, 0, 0, time.UTC)
+	flight := &model.Flight{ID: "1", DepartureCity: "JNB", ArrivalCity: "CPT", DepartureTime: departure,
+		Status: model.FlightStatusScheduled, Capacity: 100, AvailableSeats: 100}
+
+	lookups, searches := 0, 0
+	inventory := &seatInventory{flight: flight}
+	repo := inventory.repo()
+	find := repo.findByIDFunc
+	repo.findByIDFunc = func(ctx context.Context, id string) (*model.Flight, error) {
+		lookups++
+		return find(ctx, id)
+	}
+	repo.searchFunc = func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+		searches++
+		if criteria.ArrivalCity != flight.ArrivalCity {
+			return nil, nil
+		}
+		copied := *flight
+		return []*model.Flight{&copied}, nil
+	}
+	client := cache.NewMockCacheClient()
+	builder := cache.NewCacheBuilder(client).WithTTL(time.Minute)
+	svc := NewFlightService(repo).WithFlightCache(client, builder.BuildSimple(), builder.BuildSimple(), time.Minute)
+
+	search := func(arrival string) []*model.Flight {
+		t.Helper()
+		result, err := svc.SearchFlights(ctx, model.SearchFlightRequest{DepartureCity: "JNB", ArrivalCity: arrival, DepartureDate: departure, Passengers: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Flights
+	}
+
+	for i := 0; i < 2; i++ {
+		if found, err := svc.GetFlight(ctx, "1"); err != nil || found == nil || found.AvailableSeats != 100 {
+			t.Fatalf("unexpected lookup %+v (%v)", found, err)
+		}
+		search("CPT")
+		search("DUR")
+	}
+	if lookups != 1 || searches != 2 {
+		t.Fatalf("expected repeat reads to be cached, got %d lookups and %d searches", lookups, searches)
+	}
+
+	// Selling seats invalidates the flight and searches over its route and day only
+	if err := svc.UpdateSeats(ctx, "1", "", 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found, _ := svc.GetFlight(ctx, "1")
+	results := search("CPT")
+	search("DUR")
+	if found.AvailableSeats != 98 || len(results) != 1 || results[0].AvailableSeats != 98 {
+		t.Fatalf("expected fresh seat counts, got %+v and %+v", found, results)
+	}
+	if searches != 3 {
+		t.Fatalf("expected only the JNB-CPT search to be refetched, got %d searches", searches)
+	}
+
+	stats, err := svc.GetCacheStats(ctx)
+	if err != nil || stats.SearchHits != 3 || stats.SearchMisses != 3 || stats.Invalidations != 1 {
+		t.Fatalf("unexpected cache stats %+v (%v)", stats, err)
+	}
+	if stats.FlightMisses < 2 {
+		t.Fatalf("expected the lookup after the sale to miss, got %+v", stats)
+	}
+}
+
+type mockPriceWatchRepo struct {
+	watches map[string]*model.PriceWatch
+}
+
+func (m *mockPriceWatchRepo) Create(ctx context.Context, watch *model.PriceWatch) error {
+	copied := *watch
+	m.watches[watch.ID] = &copied
+	return nil
+}
+
+func (m *mockPriceWatchRepo) FindByID(ctx context.Context, id string) (*model.PriceWatch, error) {
+	return m.watches[id], nil
+}
+
+func (m *mockPriceWatchRepo) ListByUser(ctx context.Context, userID string) ([]*model.PriceWatch, error) {
+	var watches []*model.PriceWatch
+	for _, watch := range m.watches {
+		if watch.UserID == userID {
+			watches = append(watches, watch)
+		}
+	}
+	return watches, nil
+}
+
+func (m *mockPriceWatchRepo) ListActive(ctx context.Context) ([]*model.PriceWatch, error) {
+	var watches []*model.PriceWatch
+	for _, watch := range m.watches {
+		if watch.Status == model.PriceWatchActive {
+			copied := *watch
+			watches = append(watches, &copied)
+		}
+	}
+	return watches, nil
+}
+
+func (m *mockPriceWatchRepo) Update(ctx context.Context, watch *model.PriceWatch) error {
+	copied := *watch
+	m.watches[watch.ID] = &copied
+	return nil
+}
+
+func (m *mockPriceWatchRepo) Close(ctx context.Context, watch *model.PriceWatch, status model.PriceWatchStatus) (bool, error) {
+	if stored := m.watches[watch.ID]; stored == nil || stored.Status != model.PriceWatchActive {
+		return false, nil
+	}
+	copied := *watch
+	copied.Status = status
+	m.watches[watch.ID] = &copied
+	return true, nil
+}
+
+func (m *mockPriceWatchRepo) Delete(ctx context.Context, id string) error {
+	delete(m.watches, id)
+	return nil
+}
+
+type mockPriceHistoryRepo struct {
+	points []*model.PricePoint
+}
+
+func (m *mockPriceHistoryRepo) Record(ctx context.Context, point *model.PricePoint) error {
+	m.points = append(m.points, point)
+	return nil
+}
+
+func (m *mockPriceHistoryRepo) Latest(ctx context.Context, flightID string) (*model.PricePoint, error) {
+	for i := len(m.points) - 1; i >= 0; i-- {
+		if m.points[i].FlightID == flightID {
+			return m.points[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockPriceHistoryRepo) ListByFlight(ctx context.Context, flightID string) ([]*model.PricePoint, error) {
+	return m.points, nil
+}
+
+func TestPriceHistoryRecordsRepricingNotSearches(t *testing.T) {
+	ctx := context.Background()
+	departure := time.Now().Add(60 * 24 * time.Hour)
+	flight := &model.Flight{ID: "1", DepartureTime: departure, Status: model.FlightStatusScheduled,
+		Capacity: 100, AvailableSeats: 100, Price: 100}
+	listFlight := func(ctx context.Context, _, _ time.Time) ([]*model.Flight, error) {
+		copied := *flight
+		return []*model.Flight{&copied}, nil
+	}
+	history := &mockPriceHistoryRepo{}
+	svc := NewFlightService(&mockFlightRepo{
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			return listFlight(ctx, time.Time{}, time.Time{})
+		},
+		departingFunc: listFlight,
+	}).WithPricing(NewRuleBasedPricing(&mockPricingRulesRepo{}), cache.NewMockCacheClient()).
+		WithPriceWatches(&mockPriceWatchRepo{watches: map[string]*model.PriceWatch{}}, history, nil)
+
+	if _, err := svc.SearchFlights(ctx, model.SearchFlightRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.points) != 0 {
+		t.Fatalf("expected searches not to record prices, got %+v", history.points)
+	}
+
+	rules := &model.PricingRules{Enabled: true, AdvancePurchase: []model.AdvancePurchaseTier{{MaxDaysOut: 90, Multiplier: 1.5}}}
+	if _, err := svc.UpdatePricingRules(ctx, "admin", rules); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history.points) != 1 || history.points[0].Price != 150 || history.points[0].Source != model.PriceSourcePricing {
+		t.Fatalf("expected the new price to be recorded, got %+v", history.points)
+	}
+}
+
+type recordingPriceAlerts struct {
+	alerts []*model.PriceWatch
+}
+
+func (r *recordingPriceAlerts) NotifyPriceDrop(ctx context.Context, watch *model.PriceWatch, flight *model.Flight) error {
+	r.alerts = append(r.alerts, watch)
+	return nil
+}
+
+func TestPriceWatchesRecordHistoryAndAlertOnce(t *testing.T) {
+	ctx := context.Background()
+	departure := time.Now().Add(10 * 24 * time.Hour)
+	stored := &model.Flight{ID: "1", FlightNumber: "SA303", DepartureCity: "JNB", ArrivalCity: "CPT", DepartureTime: departure,
+		Status: model.FlightStatusScheduled, Capacity: 100, AvailableSeats: 100, Price: 150}
+	repo := &mockFlightRepo{
+		findByIDFunc: func(ctx context.Context, id string) (*model.Flight, error) {
+			copied := *stored
+			return &copied, nil
+		},
+		updateFunc: func(ctx context.Context, flight *model.Flight) error {
+			copied := *flight
+			stored = &copied
+			return nil
+		},
+		searchFunc: func(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+			copied := *stored
+			return []*model.Flight{&copied}, nil
+		},
+	}
+	watches := &mockPriceWatchRepo{watches: map[string]*model.PriceWatch{}}
+	history := &mockPriceHistoryRepo{}
+	alerts := &recordingPriceAlerts{}
+	svc := NewFlightService(repo).WithPriceWatches(watches, history, alerts)
+
+	if _, err := svc.CreatePriceWatch(ctx, "u1", &model.PriceWatchRequest{FlightID: "1", DepartureCity: "JNB", TargetPrice: 100}); err == nil {
+		t.Fatal("expected a watch on both a flight and a route to be rejected")
+	}
+	routeWatch, err := svc.CreatePriceWatch(ctx, "u1", &model.PriceWatchRequest{DepartureCity: "jnb", ArrivalCity: "cpt", DepartureDate: &departure, TargetPrice: 120})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flightWatch, err := svc.CreatePriceWatch(ctx, "u2", &model.PriceWatchRequest{FlightID: "1", TargetPrice: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Price changes are recorded once each
+	for _, price := range []float64{110, 110} {
+		update := *stored
+		update.Price = price
+		if _, err := svc.UpdateFlight(ctx, "1", &update); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(history.points) != 1 || history.points[0].Price != 110 || history.points[0].Source != model.PriceSourceUpdate {
+		t.Fatalf("expected one recorded price change, got %+v", history.points)
+	}
+
+	triggered, err := svc.EvaluatePriceWatches(ctx, time.Now())
+	if err != nil || triggered != 1 || len(alerts.alerts) != 1 || alerts.alerts[0].ID != routeWatch.ID {
+		t.Fatalf("expected the route watch to alert, got %d alerts (%v)", triggered, err)
+	}
+	if watch := watches.watches[flightWatch.ID]; watch.Status != model.PriceWatchActive || watch.LastPrice != 110 {
+		t.Fatalf("expected the flight watch to stay active at 110, got %+v", watch)
+	}
+
+	if triggered, _ := svc.EvaluatePriceWatches(ctx, time.Now()); triggered != 0 {
+		t.Fatalf("expected a triggered watch not to alert again, got %d", triggered)
+	}
+
+	// Watches expire once the flight has left
+	if _, err := svc.EvaluatePriceWatches(ctx, departure.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if watch := watches.watches[flightWatch.ID]; watch.Status != model.PriceWatchExpired {
+		t.Fatalf("expected the flight watch to expire, got %s", watch.Status)
 	}
 }
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgSeatHoldsDisabled  = "Seat holds are not configured"
+	errMsgHoldNotFound       = "Seat hold not found"
+	errMsgHoldNotActive      = "Seat hold has expired or was already used"
+	errMsgHoldNotConverted   = "Seat hold is not held by the booking"
+	errMsgHoldNoPassengers   = "A seat hold needs at least one passenger"
+	errMsgHoldUnavailable    = "Requested cabin or fare is not available for this many passengers"
+	errMsgFlightNotHoldable  = "Flight is no longer open for booking"
+	errMsgFailedToSaveHold   = "Failed to save seat hold"
+	errMsgFailedToExpireHold = "Failed to expire seat holds"
+)
+
+type SeatHoldRepository interface {
+	Create(ctx context.Context, hold *model.SeatHold) error
+	FindByID(ctx context.Context, id string) (*model.SeatHold, error)
+	// Convert hands an active hold that has not expired by now to a booking
+	// and reports whether it succeeded
+	Convert(ctx context.Context, id, bookingID string, now time.Time) (bool, error)
+	// Unconvert makes a hold converted to the booking active again and
+	// reports whether it was still converted to it
+	Unconvert(ctx context.Context, id, bookingID string) (bool, error)
+	// Close ends an active hold with the given status and reports whether it was still active
+	Close(ctx context.Context, id string, status model.SeatHoldStatus) (bool, error)
+	// FindExpired lists active holds that expired before now
+	FindExpired(ctx context.Context, now time.Time) ([]*model.SeatHold, error)
+}
+
+// WithSeatHolds enables seat holds that keep seats out of inventory for ttl
+func (s *FlightService) WithSeatHolds(repo SeatHoldRepository, ttl time.Duration) *FlightService {
+	if ttl <= 0 {
+		ttl = model.DefaultSeatHoldTTL
+	}
+	s.holds = repo
+	s.holdTTL = ttl
+	return s
+}
+
+// HoldSeats takes seats out of inventory for a customer until the hold
+// expires, is released, or is converted into a booking
+func (s *FlightService) HoldSeats(ctx context.Context, userID string, req *model.SeatHoldRequest) (*model.SeatHold, error) {
+	if s.holds == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSeatHoldsDisabled, http.StatusNotFound)
+	}
+	if req.Passengers < 1 {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldNoPassengers, http.StatusBadRequest)
+	}
+	flight, err := s.repo.FindByID(ctx, req.FlightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+	}
+	if !model.IsSellable(flight.Status) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgFlightNotHoldable, http.StatusBadRequest)
+	}
+	fare, ok := flight.SelectFare(model.CabinClass(req.Cabin), req.FareCode, req.Passengers)
+	if !ok {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldUnavailable, http.StatusBadRequest)
+	}
+	if err := s.UpdateSeats(ctx, flight.ID, fare.Code, req.Passengers); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hold := &model.SeatHold{
+		ID:         uuid.NewString(),
+		FlightID:   flight.ID,
+		UserID:     userID,
+		Passengers: req.Passengers,
+		Cabin:      fare.Cabin,
+		FareCode:   fare.Code,
+		Status:     model.SeatHoldActive,
+		ExpiresAt:  now.Add(s.holdTTL),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.holds.Create(ctx, hold); err != nil {
+		s.UpdateSeats(ctx, flight.ID, fare.Code, -req.Passengers)
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveHold, http.StatusInternalServerError)
+	}
+	return hold, nil
+}
+
+// GetHold returns a seat hold, expiring it first if its time is up
+func (s *FlightService) GetHold(ctx context.Context, id string) (*model.SeatHold, error) {
+	if s.holds == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSeatHoldsDisabled, http.StatusNotFound)
+	}
+	hold, err := s.holds.FindByID(ctx, id)
+	if err != nil || hold == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgHoldNotFound, http.StatusNotFound)
+	}
+	if hold.Status == model.SeatHoldActive && !time.Now().Before(hold.ExpiresAt) {
+		if err := s.closeHold(ctx, hold, model.SeatHoldExpired); err != nil {
+			return nil, err
+		}
+	}
+	return hold, nil
+}
+
+// ReleaseHold gives the seats of an active hold back to inventory
+func (s *FlightService) ReleaseHold(ctx context.Context, id string) (*model.SeatHold, error) {
+	hold, err := s.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != model.SeatHoldActive {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldNotActive, http.StatusConflict)
+	}
+	if err := s.closeHold(ctx, hold, model.SeatHoldReleased); err != nil {
+		return nil, err
+	}
+	if hold.Status != model.SeatHoldReleased {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldNotActive, http.StatusConflict)
+	}
+	return hold, nil
+}
+
+// ConvertHold hands the seats of an active hold to a booking. The seats were
+// taken when the hold was made, so conversion cannot run out of them: it only
+// fails once the hold has expired or was released.
+func (s *FlightService) ConvertHold(ctx context.Context, id, bookingID string) (*model.SeatHold, error) {
+	hold, err := s.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.holds.Convert(ctx, id, bookingID, time.Now())
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveHold, http.StatusInternalServerError)
+	}
+	if !ok {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgHoldNotActive, http.StatusConflict)
+	}
+	hold.Status = model.SeatHoldConverted
+	hold.BookingID = bookingID
+	return hold, nil
+}
+
+// RevertHold takes the seats of a hold back from a booking that could not be
+// saved. The hold is active again until it expires, when its seats return to
+// inventory as any other hold's do.
+func (s *FlightService) RevertHold(ctx context.Context, id, bookingID string) error {
+	if s.holds == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgSeatHoldsDisabled, http.StatusNotFound)
+	}
+	ok, err := s.holds.Unconvert(ctx, id, bookingID)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveHold, http.StatusInternalServerError)
+	}
+	if !ok {
+		return common.NewAppError(common.ErrInvalidInput, errMsgHoldNotConverted, http.StatusConflict)
+	}
+	return nil
+}
+
+// ExpireHolds returns the seats of every hold that expired before now to
+// inventory and reports how many holds were expired
+func (s *FlightService) ExpireHolds(ctx context.Context, now time.Time) (int, error) {
+	if s.holds == nil {
+		return 0, nil
+	}
+	holds, err := s.holds.FindExpired(ctx, now)
+	if err != nil {
+		return 0, common.NewAppError(common.ErrInternalServer, errMsgFailedToExpireHold, http.StatusInternalServerError)
+	}
+	expired := 0
+	for _, hold := range holds {
+		if err := s.closeHold(ctx, hold, model.SeatHoldExpired); err != nil {
+			return expired, err
+		}
+		if hold.Status == model.SeatHoldExpired {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// closeHold ends an active hold and returns its seats. Only the caller that
+// closes the hold returns the seats, so a hold racing between release, expiry
+// and conversion gives its seats back at most once.
+func (s *FlightService) closeHold(ctx context.Context, hold *model.SeatHold, status model.SeatHoldStatus) error {
+	ok, err := s.holds.Close(ctx, hold.ID, status)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveHold, http.StatusInternalServerError)
+	}
+	if !ok {
+		// Someone else settled the hold first
+		current, err := s.holds.FindByID(ctx, hold.ID)
+		if err == nil && current != nil {
+			*hold = *current
+		}
+		return nil
+	}
+	hold.Status = status
+	hold.UpdatedAt = time.Now()
+	return s.UpdateSeats(ctx, hold.FlightID, hold.FareCode, -hold.Passengers)
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/Siya360/take-flight/server/pkg/ssim"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgUnknownFileFormat = "Format must be csv or ssim"
+	errMsgUnreadableImport  = "Import file could not be read"
+	errMsgTooManyImported   = "Import has more flights than allowed in one file"
+	errMsgImportFailed      = "Failed to save imported flights"
+	errMsgExportFailed      = "Failed to export flights"
+	errMsgInvalidExport     = "Export needs a date range ending after it starts"
+	errMsgNoSSIMDesignator  = "Flight %s has no IATA designator and cannot be written as SSIM"
+	lineMsgMissingPrice     = "SSIM records carry no fares; set a price for new flights"
+	lineMsgNotScheduled     = "Flight is %s; only scheduled flights can be changed by import"
+	lineMsgFareBuckets      = "Flight sells fare buckets; change its capacity and price through the flights API"
+	lineMsgBelowSold        = "Capacity is below the %d seats already sold"
+	lineMsgDuplicate        = "Flight is also on line %d"
+	lineMsgMultiLeg         = "Multi-leg flights are not supported; give each leg its own flight number"
+	lineMsgNoFlightNumber   = "Flight number is required"
+	lineMsgArrivalFirst     = "Arrival must be after departure"
+	lineMsgNoCapacity       = "Capacity must be positive"
+	lineMsgNegativePrice    = "Price must not be negative"
+)
+
+// importRow is one dated flight read from an import file
+type importRow struct {
+	line   int
+	flight *model.Flight
+	// priced is false for SSIM rows, which keep the price of a flight they update
+	priced bool
+}
+
+// ImportFlights validates a CSV or SSIM file of flights and upserts them by
+// flight number and local departure date. Every line is checked and reported;
+// flights are only written when the import is not a dry run and has no
+// errors, and then all in one batch.
+func (s *FlightService) ImportFlights(ctx context.Context, req model.ImportRequest, data io.Reader) (*model.ImportReport, error) {
+	var (
+		rows []importRow
+		errs []model.ImportLineError
+		err  error
+	)
+	switch strings.ToLower(req.Format) {
+	case model.FlightFileCSV:
+		rows, errs, err = readFlightCSV(data)
+	case model.FlightFileSSIM:
+		rows, errs, err = readFlightSSIM(data, req.Price)
+	default:
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgUnknownFileFormat, http.StatusBadRequest)
+	}
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgUnreadableImport, http.StatusBadRequest)
+	}
+	if len(rows) > model.MaxImportFlights {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgTooManyImported, http.StatusRequestEntityTooLarge)
+	}
+
+	report := &model.ImportReport{
+		Format:  strings.ToLower(req.Format),
+		DryRun:  req.DryRun,
+		Flights: len(rows),
+		Errors:  append([]model.ImportLineError{}, errs...),
+	}
+	locate := s.airportLocator(ctx)
+	rowError := func(row importRow, message string) {
+		report.Errors = append(report.Errors, model.ImportLineError{
+			Line:         row.line,
+			FlightNumber: row.flight.FlightNumber,
+			Date:         localDate(row.flight, locate),
+			Message:      message,
+		})
+	}
+
+	// Validate each row and find the flights the file names more than once
+	valid := make([]importRow, 0, len(rows))
+	firstLine := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if err := s.validateImportedFlight(ctx, row.flight); err != nil {
+			rowError(row, err.Error())
+			continue
+		}
+		key := row.flight.FlightNumber + "|" + localDate(row.flight, locate)
+		if line, ok := firstLine[key]; ok {
+			rowError(row, fmt.Sprintf(lineMsgDuplicate, line))
+			continue
+		}
+		firstLine[key] = row.line
+		valid = append(valid, row)
+	}
+
+	existing, err := s.findImportedFlights(ctx, valid, locate)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgImportFailed, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	var created, updated []*model.Flight
+	for _, row := range valid {
+		flight := row.flight
+		current, ok := existing[flight.FlightNumber+"|"+localDate(flight, locate)]
+		if !ok {
+			if !row.priced {
+				rowError(row, lineMsgMissingPrice)
+				continue
+			}
+			flight.ID = uuid.NewString()
+			flight.Status = model.FlightStatusScheduled
+			flight.AvailableSeats = flight.Capacity
+			flight.CreatedAt = now
+			flight.UpdatedAt = now
+			created = append(created, flight)
+			continue
+		}
+
+		if !row.priced {
+			flight.Price = current.Price
+		}
+		if importedUnchanged(current, flight) {
+			report.Unchanged++
+			continue
+		}
+		sold := current.Sold()
+		switch {
+		case current.Status != model.FlightStatusScheduled:
+			rowError(row, fmt.Sprintf(lineMsgNotScheduled, current.Status))
+			continue
+		case len(current.Fares) > 0 && (flight.Capacity != current.Capacity || flight.Price != current.Price):
+			rowError(row, lineMsgFareBuckets)
+			continue
+		case flight.Capacity < sold:
+			rowError(row, fmt.Sprintf(lineMsgBelowSold, sold))
+			continue
+		}
+
+		next := *current
+		next.DepartureCity = flight.DepartureCity
+		next.ArrivalCity = flight.ArrivalCity
+		next.DepartureTime = flight.DepartureTime
+		next.ArrivalTime = flight.ArrivalTime
+		next.Capacity = flight.Capacity
+		next.AvailableSeats = flight.Capacity + current.Overbooking - sold
+		next.Price = flight.Price
+		next.UpdatedAt = now
+		updated = append(updated, &next)
+	}
+
+	report.Created = len(created)
+	report.Updated = len(updated)
+	if req.DryRun || len(report.Errors) > 0 {
+		return report, nil
+	}
+	if err := s.overbook(ctx, append(append([]*model.Flight{}, created...), updated...)...); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveBatch(ctx, created, updated); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgImportFailed, http.StatusInternalServerError)
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// validateImportedFlight checks the route, times, capacity and price of a row
+func (s *FlightService) validateImportedFlight(ctx context.Context, flight *model.Flight) error {
+	flight.FlightNumber = strings.ToUpper(strings.ReplaceAll(flight.FlightNumber, " ", ""))
+	if flight.FlightNumber == "" {
+		return errors.New(lineMsgNoFlightNumber)
+	}
+	if _, err := s.resolveRoute(ctx, &flight.DepartureCity, &flight.ArrivalCity); err != nil {
+		return err
+	}
+	if flight.DepartureCity == "" || flight.ArrivalCity == "" || flight.DepartureCity == flight.ArrivalCity {
+		return errors.New(errMsgSameOriginAndDest)
+	}
+	if !flight.ArrivalTime.After(flight.DepartureTime) {
+		return errors.New(lineMsgArrivalFirst)
+	}
+	if flight.Capacity <= 0 {
+		return errors.New(lineMsgNoCapacity)
+	}
+	if flight.Price < 0 {
+		return errors.New(lineMsgNegativePrice)
+	}
+	return nil
+}
+
+// findImportedFlights loads the stored flights sharing a flight number and
+// local departure date with an imported row, keyed the same way
+func (s *FlightService) findImportedFlights(ctx context.Context, rows []importRow, locate func(string) *time.Location) (map[string]*model.Flight, error) {
+	type span struct{ from, to time.Time }
+	spans := make(map[string]*span)
+	for _, row := range rows {
+		flight := row.flight
+		sp, ok := spans[flight.FlightNumber]
+		if !ok {
+			spans[flight.FlightNumber] = &span{from: flight.DepartureTime, to: flight.DepartureTime}
+			continue
+		}
+		if flight.DepartureTime.Before(sp.from) {
+			sp.from = flight.DepartureTime
+		}
+		if flight.DepartureTime.After(sp.to) {
+			sp.to = flight.DepartureTime
+		}
+	}
+
+	existing := make(map[string]*model.Flight)
+	for number, sp := range spans {
+		// A day either side covers every time zone's idea of the same date
+		flights, err := s.repo.FindByFlightNumber(ctx, number, sp.from.AddDate(0, 0, -1), sp.to.AddDate(0, 0, 2))
+		if err != nil {
+			return nil, err
+		}
+		for _, flight := range flights {
+			existing[number+"|"+localDate(flight, locate)] = flight
+		}
+	}
+	return existing, nil
+}
+
+// importedUnchanged reports whether a stored flight already has every field an import sets
+func importedUnchanged(current, imported *model.Flight) bool {
+	return current.DepartureCity == imported.DepartureCity &&
+		current.ArrivalCity == imported.ArrivalCity &&
+		current.DepartureTime.Equal(imported.DepartureTime) &&
+		current.ArrivalTime.Equal(imported.ArrivalTime) &&
+		current.Capacity == imported.Capacity &&
+		current.Price == imported.Price
+}
+
+// localDate is the departure date of a flight at its departure airport
+func localDate(flight *model.Flight, locate func(string) *time.Location) string {
+	return flight.DepartureTime.In(locate(flight.DepartureCity)).Format("2006-01-02")
+}
+
+// readFlightCSV reads a CSV file with a header row naming FlightCSVColumns.
+// Times are RFC 3339. Line numbers count the header as line 1.
+func readFlightCSV(data io.Reader) ([]importRow, []model.ImportLineError, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, column := range model.FlightCSVColumns {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, []model.ImportLineError{{Line: 1, Message: "Missing columns: " + strings.Join(missing, ", ")}}, nil
+	}
+
+	var (
+		rows []importRow
+		errs []model.ImportLineError
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, model.ImportLineError{Line: parseErr.Line, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := func(column string) string {
+			if i := index[column]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		flight, err := parseCSVFlight(value)
+		if err != nil {
+			errs = append(errs, model.ImportLineError{Line: line, FlightNumber: value("flight_number"), Message: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{line: line, flight: flight, priced: true})
+	}
+	return rows, errs, nil
+}
+
+func parseCSVFlight(value func(string) string) (*model.Flight, error) {
+	flight := &model.Flight{
+		FlightNumber:  value("flight_number"),
+		DepartureCity: value("departure_city"),
+		ArrivalCity:   value("arrival_city"),
+	}
+	var err error
+	if flight.DepartureTime, err = time.Parse(time.RFC3339, value("departure_time")); err != nil {
+		return nil, fmt.Errorf("departure_time must be RFC 3339, e.g. 2026-06-01T08:30:00Z")
+	}
+	if flight.ArrivalTime, err = time.Parse(time.RFC3339, value("arrival_time")); err != nil {
+		return nil, fmt.Errorf("arrival_time must be RFC 3339, e.g. 2026-06-01T11:45:00Z")
+	}
+	flight.DepartureTime = flight.DepartureTime.UTC()
+	flight.ArrivalTime = flight.ArrivalTime.UTC()
+	if flight.Capacity, err = strconv.Atoi(value("capacity")); err != nil {
+		return nil, fmt.Errorf("capacity must be a whole number")
+	}
+	if flight.Price, err = strconv.ParseFloat(value("price"), 64); err != nil {
+		return nil, fmt.Errorf("price must be a number")
+	}
+	return flight, nil
+}
+
+// readFlightSSIM expands the flight leg records of an SSIM file into dated
+// flights. Open-ended periods stop at the longest schedule horizon.
+func readFlightSSIM(data io.Reader, price float64) ([]importRow, []model.ImportLineError, error) {
+	legs, legErrs, err := ssim.Read(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	errs := make([]model.ImportLineError, 0, len(legErrs))
+	for _, legErr := range legErrs {
+		errs = append(errs, model.ImportLineError{Line: legErr.Line, Message: legErr.Message})
+	}
+
+	until := time.Now().UTC().AddDate(0, 0, model.MaxScheduleHorizonDays)
+	var rows []importRow
+	for i := range legs {
+		leg := &legs[i]
+		if leg.LegSequence > 1 {
+			errs = append(errs, model.ImportLineError{Line: leg.Line, FlightNumber: leg.Designator(), Message: lineMsgMultiLeg})
+			continue
+		}
+		operations, err := leg.Operations(until)
+		if err != nil {
+			errs = append(errs, model.ImportLineError{Line: leg.Line, FlightNumber: leg.Designator(), Message: err.Error()})
+			continue
+		}
+		for _, operation := range operations {
+			rows = append(rows, importRow{
+				line: leg.Line,
+				flight: &model.Flight{
+					FlightNumber:  leg.Designator(),
+					DepartureCity: leg.DepartureStation,
+					ArrivalCity:   leg.ArrivalStation,
+					DepartureTime: operation.Departure,
+					ArrivalTime:   operation.Arrival,
+					Capacity:      leg.Seats(),
+					Price:         price,
+				},
+				priced: price > 0,
+			})
+		}
+	}
+	return rows, errs, nil
+}
+
+// ExportFlights writes the flights departing in the requested range as CSV
+// or SSIM. SSIM files get one leg record per dated flight, with times local
+// to each airport.
+func (s *FlightService) ExportFlights(ctx context.Context, req model.ExportRequest, w io.Writer) error {
+	format := strings.ToLower(req.Format)
+	if format != model.FlightFileCSV && format != model.FlightFileSSIM {
+		return common.NewAppError(common.ErrInvalidInput, errMsgUnknownFileFormat, http.StatusBadRequest)
+	}
+	if !req.To.After(req.From) {
+		return common.NewAppError(common.ErrInvalidInput, errMsgInvalidExport, http.StatusBadRequest)
+	}
+
+	flights, err := s.repo.FindDepartingBetween(ctx, req.From, req.To)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgExportFailed, http.StatusInternalServerError)
+	}
+
+	if format == model.FlightFileCSV {
+		return writeFlightCSV(w, flights)
+	}
+
+	locate := s.airportLocator(ctx)
+	legs := make([]ssim.Leg, 0, len(flights))
+	for _, flight := range flights {
+		leg, ok := ssimLeg(flight, locate)
+		if !ok {
+			return common.NewAppError(common.ErrInvalidInput, fmt.Sprintf(errMsgNoSSIMDesignator, flight.FlightNumber), http.StatusUnprocessableEntity)
+		}
+		legs = append(legs, leg)
+	}
+	return ssim.Write(w, legs, time.Now())
+}
+
+func writeFlightCSV(w io.Writer, flights []*model.Flight) error {
+	writer := csv.NewWriter(w)
+	writer.Write(append(append([]string{}, model.FlightCSVColumns...), model.FlightCSVExportColumns...))
+	for _, flight := range flights {
+		writer.Write([]string{
+			flight.FlightNumber,
+			flight.DepartureCity,
+			flight.ArrivalCity,
+			flight.DepartureTime.UTC().Format(time.RFC3339),
+			flight.ArrivalTime.UTC().Format(time.RFC3339),
+			strconv.Itoa(flight.Capacity),
+			strconv.FormatFloat(flight.Price, 'f', 2, 64),
+			flight.ID,
+			strconv.Itoa(flight.AvailableSeats),
+			flight.Status,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ssimLeg describes one dated flight as an SSIM leg operating on its local
+// departure date only
+func ssimLeg(flight *model.Flight, locate func(string) *time.Location) (ssim.Leg, bool) {
+	airline, number, suffix, ok := splitDesignator(flight.FlightNumber)
+	if !ok {
+		return ssim.Leg{}, false
+	}
+
+	departure := flight.DepartureTime.In(locate(flight.DepartureCity))
+	arrival := flight.ArrivalTime.In(locate(flight.ArrivalCity))
+	_, depOffset := departure.Zone()
+	_, arrOffset := arrival.Zone()
+	date := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, time.UTC)
+	arrDate := time.Date(arrival.Year(), arrival.Month(), arrival.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := []byte("       ")
+	weekday := int(departure.Weekday()+6)%7 + 1
+	days[weekday-1] = byte('0' + weekday)
+
+	return ssim.Leg{
+		TimeMode:            ssim.TimeModeLocal,
+		Airline:             airline,
+		FlightNumber:        number,
+		Suffix:              suffix,
+		ItineraryVariation:  1,
+		LegSequence:         1,
+		ServiceType:         "J",
+		PeriodFrom:          date,
+		PeriodTo:            date,
+		Days:                string(days),
+		DepartureStation:    flight.DepartureCity,
+		DepartureTime:       departure.Format("1504"),
+		DepartureOffset:     depOffset / 60,
+		ArrivalStation:      flight.ArrivalCity,
+		ArrivalTime:         arrival.Format("1504"),
+		ArrivalOffset:       arrOffset / 60,
+		Configuration:       "Y" + strconv.Itoa(flight.Capacity),
+		ArrivalDayVariation: int(arrDate.Sub(date).Hours() / 24),
+	}, true
+}
+
+// splitDesignator splits a flight number such as BA178 or U21234A into its
+// airline, number and operational suffix
+func splitDesignator(flightNumber string) (string, int, string, bool) {
+	designator := strings.ToUpper(strings.ReplaceAll(flightNumber, " ", ""))
+	if len(designator) < 3 {
+		return "", 0, "", false
+	}
+	airline, rest := designator[:2], designator[2:]
+	suffix := ""
+	if last := rest[len(rest)-1]; last >= 'A' && last <= 'Z' {
+		suffix, rest = string(last), rest[:len(rest)-1]
+	}
+	number, err := strconv.Atoi(rest)
+	if err != nil || number <= 0 || len(rest) > 4 {
+		return "", 0, "", false
+	}
+	return airline, number, suffix, true
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgInvalidSearch     = "Departure and arrival cities are required"
+	errMsgFailedToSearch    = "Failed to search flights"
+	errMsgSameOriginAndDest = "Departure and arrival cities must differ"
+)
+
+// SearchItineraries builds direct, one-stop and two-stop itineraries for the
+// requested route. Every leg must have enough seats for all passengers and each
+// connection must fall inside the connecting airport's window. The itineraries
+// are faceted, then filtered, sorted and paged as the search options ask;
+// max_stops filters like the other options, so the stops facet still counts
+// itineraries with more connections.
+func (s *FlightService) SearchItineraries(ctx context.Context, criteria model.ItinerarySearchRequest) (*model.ItinerarySearchResult, error) {
+	if criteria.Sort == "" {
+		criteria.Sort = model.SortByDuration
+	}
+	query, err := parseSearchOptions(criteria.SearchOptions)
+	if err != nil {
+		return nil, err
+	}
+	query.maxStops = stopLimit(criteria.MaxStops)
+
+	itineraries, err := s.findItineraries(ctx, criteria.SearchFlightRequest, model.MaxItineraryStops)
+	if err != nil {
+		return nil, err
+	}
+
+	locate := s.airportLocator(ctx)
+	result := &model.ItinerarySearchResult{Facets: itineraryFacets(itineraries, locate)}
+	query.applyItineraries(result, itineraries, locate)
+	return result, nil
+}
+
+// stopLimit returns the requested number of connections kept between none
+// and MaxItineraryStops, or MaxItineraryStops when none was requested
+func stopLimit(maxStops *int) int {
+	switch {
+	case maxStops == nil || *maxStops > model.MaxItineraryStops:
+		return model.MaxItineraryStops
+	case *maxStops < 0:
+		return 0
+	}
+	return *maxStops
+}
+
+// findItineraries searches a route for priced itineraries with up to maxStops connections
+func (s *FlightService) findItineraries(ctx context.Context, criteria model.SearchFlightRequest, maxStops int) ([]*model.Itinerary, error) {
+	if criteria.DepartureCity == "" || criteria.ArrivalCity == "" {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidSearch, http.StatusBadRequest)
+	}
+	if criteria.DepartureCity == criteria.ArrivalCity {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgSameOriginAndDest, http.StatusBadRequest)
+	}
+
+	passengers := criteria.Passengers
+	if passengers < 1 {
+		passengers = 1
+	}
+
+	criteria.DepartureDate = s.localDay(ctx, criteria.DepartureCity, criteria.DepartureDate)
+	from, to := criteria.DepartureWindow()
+	firstLegs, err := s.repo.FindDepartures(ctx, criteria.DepartureCity, from, to, passengers)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSearch, http.StatusInternalServerError)
+	}
+
+	search := &itinerarySearch{
+		service:     s,
+		origin:      criteria.DepartureCity,
+		destination: criteria.ArrivalCity,
+		passengers:  passengers,
+		maxStops:    maxStops,
+	}
+	paths := make([][]*model.Flight, len(firstLegs))
+	for i, leg := range firstLegs {
+		paths[i] = []*model.Flight{leg}
+	}
+	for len(paths) > 0 {
+		if paths, err = search.extend(ctx, paths); err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSearch, http.StatusInternalServerError)
+		}
+	}
+	if err := search.price(ctx); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSearch, http.StatusInternalServerError)
+	}
+	return search.results, nil
+}
+
+// itinerarySearch holds the state of a single depth-limited route search
+type itinerarySearch struct {
+	service     *FlightService
+	origin      string
+	destination string
+	passengers  int
+	maxStops    int
+	complete    [][]*model.Flight
+	results     []*model.Itinerary
+}
+
+// extend sets aside the paths that reach the destination and returns the
+// others extended by one onward flight departing within the connection
+// window. Onward flights are looked up once per connecting airport, across the
+// windows of every path arriving there.
+func (r *itinerarySearch) extend(ctx context.Context, paths [][]*model.Flight) ([][]*model.Flight, error) {
+	windows := make(map[string][2]time.Time)
+	var open [][]*model.Flight
+	for _, legs := range paths {
+		last := legs[len(legs)-1]
+		if last.ArrivalCity == r.destination {
+			r.complete = append(r.complete, legs)
+			continue
+		}
+		if len(legs) > r.maxStops {
+			continue
+		}
+		open = append(open, legs)
+
+		window := r.service.connections.WindowFor(last.ArrivalCity)
+		from, to := last.ArrivalTime.Add(window.Min), last.ArrivalTime.Add(window.Max)
+		if known, ok := windows[last.ArrivalCity]; ok {
+			if known[0].Before(from) {
+				from = known[0]
+			}
+			if known[1].After(to) {
+				to = known[1]
+			}
+		}
+		windows[last.ArrivalCity] = [2]time.Time{from, to}
+	}
+
+	onward := make(map[string][]*model.Flight, len(windows))
+	for city, window := range windows {
+		flights, err := r.service.repo.FindDepartures(ctx, city, window[0], window[1], r.passengers)
+		if err != nil {
+			return nil, err
+		}
+		onward[city] = flights
+	}
+
+	var extended [][]*model.Flight
+	for _, legs := range open {
+		last := legs[len(legs)-1]
+		window := r.service.connections.WindowFor(last.ArrivalCity)
+		from, to := last.ArrivalTime.Add(window.Min), last.ArrivalTime.Add(window.Max)
+		for _, next := range onward[last.ArrivalCity] {
+			if next.DepartureTime.Before(from) || next.DepartureTime.After(to) || r.visited(legs, next.ArrivalCity) {
+				continue
+			}
+			path := make([]*model.Flight, len(legs), len(legs)+1)
+			copy(path, legs)
+			extended = append(extended, append(path, next))
+		}
+	}
+	return extended, nil
+}
+
+// price prices each flight used by a complete itinerary once, then builds the
+// itineraries from the priced legs
+func (r *itinerarySearch) price(ctx context.Context) error {
+	seen := make(map[*model.Flight]bool)
+	var legs []*model.Flight
+	for _, path := range r.complete {
+		for _, leg := range path {
+			if !seen[leg] {
+				seen[leg] = true
+				legs = append(legs, leg)
+			}
+		}
+	}
+	if err := r.service.priceFlights(ctx, legs); err != nil {
+		return err
+	}
+	for _, path := range r.complete {
+		r.results = append(r.results, model.NewItinerary(path, r.passengers))
+	}
+	return nil
+}
+
+// visited reports whether the city already appears on the route, which would
+// make the itinerary double back on itself.
+func (r *itinerarySearch) visited(legs []*model.Flight, city string) bool {
+	if city == r.origin {
+		return true
+	}
+	for _, leg := range legs {
+		if leg.ArrivalCity == city {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgOverbookingDisabled   = "Overbooking is not configured"
+	errMsgInvalidOverbooking    = "Each overbooking rule needs a flight or a route, and a percentage of at most 100 or a number of seats"
+	errMsgFailedToLoadOverbook  = "Failed to load overbooking policy"
+	errMsgFailedToSaveOverbook  = "Failed to save overbooking policy"
+	errMsgFailedToApplyOverbook = "Overbooking policy saved but could not be applied to every flight"
+	errMsgOverbookSeatsChanged  = "Overbooking policy saved but seats were sold while it was applied, please retry"
+
+	// overbookingHorizon bounds which upcoming flights a policy change is applied to
+	overbookingHorizon = 366 * 24 * time.Hour
+)
+
+type OverbookingRepository interface {
+	Get(ctx context.Context) (*model.OverbookingPolicy, error)
+	Save(ctx context.Context, policy *model.OverbookingPolicy) error
+}
+
+// WithOverbooking enables admin-configured overbooking
+func (s *FlightService) WithOverbooking(repo OverbookingRepository) *FlightService {
+	s.overbooking = repo
+	return s
+}
+
+// GetOverbookingPolicy returns the active overbooking rules
+func (s *FlightService) GetOverbookingPolicy(ctx context.Context) (*model.OverbookingPolicy, error) {
+	if s.overbooking == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgOverbookingDisabled, http.StatusNotFound)
+	}
+	policy, err := s.overbookingPolicy(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToLoadOverbook, http.StatusInternalServerError)
+	}
+	return policy, nil
+}
+
+// UpdateOverbookingPolicy replaces the overbooking rules and resizes the
+// sellable capacity of every upcoming flight that can still be booked. Seats
+// already sold stay sold when an allowance shrinks.
+func (s *FlightService) UpdateOverbookingPolicy(ctx context.Context, adminID string, policy *model.OverbookingPolicy) (*model.OverbookingPolicy, error) {
+	if s.overbooking == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgOverbookingDisabled, http.StatusNotFound)
+	}
+	if err := validateOverbookingPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	policy.ID = model.OverbookingPolicyID
+	policy.UpdatedAt = time.Now()
+	policy.UpdatedBy = adminID
+	if err := s.overbooking.Save(ctx, policy); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveOverbook, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	flights, err := s.repo.FindDepartingBetween(ctx, now, now.Add(overbookingHorizon))
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToApplyOverbook, http.StatusInternalServerError)
+	}
+	for _, flight := range flights {
+		if !model.IsSellable(flight.Status) {
+			continue
+		}
+		fareCode, change := flight.Overbook(policy.Allowance(flight))
+		if change == 0 {
+			continue
+		}
+		ok, err := s.repo.UpdateOverbooking(ctx, flight.ID, fareCode, change)
+		if err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToApplyOverbook, http.StatusInternalServerError)
+		}
+		if !ok {
+			return nil, common.NewAppError(common.ErrInsufficientSeats, errMsgOverbookSeatsChanged, http.StatusConflict)
+		}
+	}
+	return policy, nil
+}
+
+// overbookingPolicy loads the stored policy; it is nil when overbooking is not configured
+func (s *FlightService) overbookingPolicy(ctx context.Context) (*model.OverbookingPolicy, error) {
+	if s.overbooking == nil {
+		return nil, nil
+	}
+	policy, err := s.overbooking.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &model.OverbookingPolicy{ID: model.OverbookingPolicyID}
+	}
+	return policy, nil
+}
+
+// overbook sizes the overbooking allowance of flights about to be saved
+func (s *FlightService) overbook(ctx context.Context, flights ...*model.Flight) error {
+	policy, err := s.overbookingPolicy(ctx)
+	if err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToLoadOverbook, http.StatusInternalServerError)
+	}
+	for _, flight := range flights {
+		flight.Overbook(policy.Allowance(flight))
+	}
+	return nil
+}
+
+// validateOverbookingPolicy checks every rule names either a flight or a
+// route and normalizes the route's airport codes
+func validateOverbookingPolicy(policy *model.OverbookingPolicy) error {
+	invalid := common.NewAppError(common.ErrInvalidInput, errMsgInvalidOverbooking, http.StatusBadRequest)
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		rule.DepartureCity = strings.ToUpper(strings.TrimSpace(rule.DepartureCity))
+		rule.ArrivalCity = strings.ToUpper(strings.TrimSpace(rule.ArrivalCity))
+		route := rule.DepartureCity != "" || rule.ArrivalCity != ""
+		switch {
+		case rule.FlightID != "" && route:
+			return invalid
+		case rule.FlightID == "" && (rule.DepartureCity == "" || rule.ArrivalCity == ""):
+			return invalid
+		case rule.Percent < 0 || rule.Percent > 100 || rule.Seats < 0:
+			return invalid
+		}
+	}
+	return nil
+}
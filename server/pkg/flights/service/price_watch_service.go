@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgPriceWatchDisabled   = "Price watches are not configured"
+	errMsgPriceWatchNotFound   = "Price watch not found"
+	errMsgInvalidPriceWatch    = "A price watch needs a target price and either a flight or a route and departure date"
+	errMsgFailedToSaveWatch    = "Failed to save price watch"
+	errMsgFailedToListWatches  = "Failed to fetch price watches"
+	errMsgFailedToGetPrices    = "Failed to fetch price history"
+	errMsgFailedToEvalWatches  = "Failed to evaluate price watches"
+	errMsgPriceHistoryDisabled = "Price history is not configured"
+	errMsgFlightNotWatchable   = "Flight is no longer open for booking"
+
+	// priceHistoryRefresh bounds how long a flight's last recorded price is
+	// trusted before it is read again, in case another instance recorded a change
+	priceHistoryRefresh = time.Minute
+	// maxTrackedPrices caps the last recorded prices kept in memory
+	maxTrackedPrices = 10000
+)
+
+type PriceWatchRepository interface {
+	Create(ctx context.Context, watch *model.PriceWatch) error
+	FindByID(ctx context.Context, id string) (*model.PriceWatch, error)
+	ListByUser(ctx context.Context, userID string) ([]*model.PriceWatch, error)
+	ListActive(ctx context.Context) ([]*model.PriceWatch, error)
+	// Update saves the outcome of an evaluation of an active watch
+	Update(ctx context.Context, watch *model.PriceWatch) error
+	// Close moves an active watch to status, reporting false if it was no longer active
+	Close(ctx context.Context, watch *model.PriceWatch, status model.PriceWatchStatus) (bool, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type PriceHistoryRepository interface {
+	Record(ctx context.Context, point *model.PricePoint) error
+	Latest(ctx context.Context, flightID string) (*model.PricePoint, error)
+	ListByFlight(ctx context.Context, flightID string) ([]*model.PricePoint, error)
+}
+
+// PriceAlertNotifier tells a user their watched fare has dropped to the target
+type PriceAlertNotifier interface {
+	NotifyPriceDrop(ctx context.Context, watch *model.PriceWatch, flight *model.Flight) error
+}
+
+// recordedPrice is the last price recorded for a flight and when it was read
+type recordedPrice struct {
+	price  float64
+	seenAt time.Time
+}
+
+// priceTracker writes a flight's price history only when its price changes
+type priceTracker struct {
+	repo PriceHistoryRepository
+	mu   sync.Mutex
+	last map[string]recordedPrice
+}
+
+// WithPriceWatches enables price history, price watches and the alerts they send
+func (s *FlightService) WithPriceWatches(watches PriceWatchRepository, history PriceHistoryRepository, notifier PriceAlertNotifier) *FlightService {
+	s.priceWatches = watches
+	s.priceHistory = &priceTracker{repo: history, last: make(map[string]recordedPrice)}
+	s.priceAlerts = notifier
+	return s
+}
+
+// CreatePriceWatch subscribes the user to price drops on a flight or route
+func (s *FlightService) CreatePriceWatch(ctx context.Context, userID string, req *model.PriceWatchRequest) (*model.PriceWatch, error) {
+	if s.priceWatches == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPriceWatchDisabled, http.StatusNotFound)
+	}
+	invalid := common.NewAppError(common.ErrInvalidInput, errMsgInvalidPriceWatch, http.StatusBadRequest)
+	route := req.DepartureCity != "" || req.ArrivalCity != "" || req.DepartureDate != nil
+	if req.TargetPrice <= 0 || (req.FlightID != "") == route {
+		return nil, invalid
+	}
+
+	now := time.Now()
+	watch := &model.PriceWatch{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		Passengers:  req.Passengers,
+		TargetPrice: req.TargetPrice,
+		Status:      model.PriceWatchActive,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if watch.Passengers < 1 {
+		watch.Passengers = 1
+	}
+
+	if req.FlightID != "" {
+		flight, err := s.repo.FindByID(ctx, req.FlightID)
+		if err != nil || flight == nil {
+			return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+		}
+		if !model.IsSellable(flight.Status) || !flight.DepartureTime.After(now) {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgFlightNotWatchable, http.StatusBadRequest)
+		}
+		watch.FlightID = flight.ID
+	} else {
+		if req.DepartureCity == "" || req.ArrivalCity == "" || req.DepartureDate == nil {
+			return nil, invalid
+		}
+		watch.DepartureCity, watch.ArrivalCity = req.DepartureCity, req.ArrivalCity
+		if _, err := s.resolveRoute(ctx, &watch.DepartureCity, &watch.ArrivalCity); err != nil {
+			return nil, err
+		}
+		date := *req.DepartureDate
+		watch.DepartureDate = &date
+		if s.watchEnded(ctx, watch, now) {
+			return nil, invalid
+		}
+	}
+
+	if err := s.priceWatches.Create(ctx, watch); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveWatch, http.StatusInternalServerError)
+	}
+	return watch, nil
+}
+
+// ListPriceWatches returns the user's price watches
+func (s *FlightService) ListPriceWatches(ctx context.Context, userID string) ([]*model.PriceWatch, error) {
+	if s.priceWatches == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPriceWatchDisabled, http.StatusNotFound)
+	}
+	watches, err := s.priceWatches.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToListWatches, http.StatusInternalServerError)
+	}
+	if watches == nil {
+		watches = []*model.PriceWatch{}
+	}
+	return watches, nil
+}
+
+// DeletePriceWatch unsubscribes the user from one of their price watches
+func (s *FlightService) DeletePriceWatch(ctx context.Context, userID, id string) error {
+	if s.priceWatches == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgPriceWatchDisabled, http.StatusNotFound)
+	}
+	watch, err := s.priceWatches.FindByID(ctx, id)
+	if err != nil || watch == nil || watch.UserID != userID {
+		return common.NewAppError(common.ErrNotFound, errMsgPriceWatchNotFound, http.StatusNotFound)
+	}
+	if err := s.priceWatches.Delete(ctx, id); err != nil {
+		return common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveWatch, http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// GetPriceHistory returns the recorded price changes of a flight, oldest first
+func (s *FlightService) GetPriceHistory(ctx context.Context, flightID string) ([]*model.PricePoint, error) {
+	if s.priceHistory == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPriceHistoryDisabled, http.StatusNotFound)
+	}
+	flight, err := s.repo.FindByID(ctx, flightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+	}
+	points, err := s.priceHistory.repo.ListByFlight(ctx, flightID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToGetPrices, http.StatusInternalServerError)
+	}
+	if points == nil {
+		points = []*model.PricePoint{}
+	}
+	return points, nil
+}
+
+// EvaluatePriceWatches prices every active watch and alerts the users whose
+// fare has dropped to their target. A watch alerts once; watches whose flight
+// or day has gone are expired. It returns the number of alerts sent.
+func (s *FlightService) EvaluatePriceWatches(ctx context.Context, now time.Time) (int, error) {
+	if s.priceWatches == nil {
+		return 0, nil
+	}
+	watches, err := s.priceWatches.ListActive(ctx)
+	if err != nil {
+		return 0, common.NewAppError(common.ErrInternalServer, errMsgFailedToEvalWatches, http.StatusInternalServerError)
+	}
+
+	triggered := 0
+	for _, watch := range watches {
+		if s.watchEnded(ctx, watch, now) {
+			s.priceWatches.Close(ctx, watch, model.PriceWatchExpired)
+			continue
+		}
+		flights, err := s.watchedFlights(ctx, watch, now)
+		if err != nil {
+			continue
+		}
+
+		var cheapest *model.Flight
+		lowest := 0.0
+		for _, flight := range flights {
+			price, ok := flight.LowestFare(watch.Passengers)
+			if ok && (cheapest == nil || price < lowest) {
+				cheapest, lowest = flight, price
+			}
+		}
+
+		checkedAt := now
+		watch.LastCheckedAt = &checkedAt
+		watch.UpdatedAt = now
+		if cheapest == nil {
+			watch.LastPrice, watch.LastFlightID = 0, ""
+			s.priceWatches.Update(ctx, watch)
+			continue
+		}
+		watch.LastPrice, watch.LastFlightID = lowest, cheapest.ID
+		if lowest > watch.TargetPrice {
+			s.priceWatches.Update(ctx, watch)
+			continue
+		}
+
+		// Only the instance that closes the watch sends the alert
+		watch.TriggeredAt = &checkedAt
+		if ok, err := s.priceWatches.Close(ctx, watch, model.PriceWatchTriggered); err != nil || !ok {
+			continue
+		}
+		watch.Status = model.PriceWatchTriggered
+		if s.priceAlerts != nil {
+			s.priceAlerts.NotifyPriceDrop(ctx, watch, cheapest)
+		}
+		triggered++
+	}
+	return triggered, nil
+}
+
+// watchEnded reports whether a watched flight can no longer be booked or a
+// watched day has passed at the departure airport
+func (s *FlightService) watchEnded(ctx context.Context, watch *model.PriceWatch, now time.Time) bool {
+	if watch.FlightID != "" {
+		flight, err := s.repo.FindByID(ctx, watch.FlightID)
+		if err != nil {
+			return false
+		}
+		return flight == nil || !model.IsSellable(flight.Status) || !flight.DepartureTime.After(now)
+	}
+	day := s.localDay(ctx, watch.DepartureCity, *watch.DepartureDate)
+	return !day.AddDate(0, 0, 1).After(now)
+}
+
+// watchedFlights finds the flights a watch covers, priced as a search would show them
+func (s *FlightService) watchedFlights(ctx context.Context, watch *model.PriceWatch, now time.Time) ([]*model.Flight, error) {
+	var flights []*model.Flight
+	if watch.FlightID != "" {
+		flight, err := s.repo.FindByID(ctx, watch.FlightID)
+		if err != nil || flight == nil {
+			return nil, err
+		}
+		flights = []*model.Flight{flight}
+	} else {
+		found, err := s.findCatalog(ctx, model.SearchFlightRequest{
+			DepartureCity: watch.DepartureCity,
+			ArrivalCity:   watch.ArrivalCity,
+			DepartureDate: *watch.DepartureDate,
+			Passengers:    watch.Passengers,
+		})
+		if err != nil {
+			return nil, err
+		}
+		flights = found
+	}
+
+	for _, flight := range flights {
+		if _, err := s.priceFlight(ctx, flight, now); err != nil {
+			return nil, err
+		}
+	}
+	return flights, nil
+}
+
+// recordPrice adds a flight's price to its history when it differs from the
+// last price recorded. History is best effort and never fails the caller.
+func (s *FlightService) recordPrice(ctx context.Context, flight *model.Flight, source string) {
+	if s.priceHistory == nil || flight.ID == "" {
+		return
+	}
+	s.priceHistory.record(ctx, flight.ID, flight.Price, source)
+}
+
+func (t *priceTracker) record(ctx context.Context, flightID string, price float64, source string) {
+	now := time.Now()
+	t.mu.Lock()
+	last, known := t.last[flightID]
+	t.mu.Unlock()
+
+	fresh := known && now.Sub(last.seenAt) < priceHistoryRefresh
+	if !fresh {
+		latest, err := t.repo.Latest(ctx, flightID)
+		if err != nil {
+			return
+		}
+		known = latest != nil
+		if known {
+			last.price = latest.Price
+		}
+	}
+	if known && last.price == price {
+		if !fresh {
+			t.remember(flightID, price, now)
+		}
+		return
+	}
+
+	point := &model.PricePoint{
+		ID:         uuid.NewString(),
+		FlightID:   flightID,
+		Price:      price,
+		Source:     source,
+		RecordedAt: now,
+	}
+	if err := t.repo.Record(ctx, point); err != nil {
+		return
+	}
+	t.remember(flightID, price, now)
+}
+
+func (t *priceTracker) remember(flightID string, price float64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.last) >= maxTrackedPrices {
+		t.last = make(map[string]recordedPrice)
+	}
+	t.last[flightID] = recordedPrice{price: price, seenAt: at}
+}
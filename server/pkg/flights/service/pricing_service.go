@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgQuoteExpired         = "Price quote has expired, please search again"
+	errMsgFareNotQuoted        = "Fare was not part of the price quote"
+	errMsgPricingNotAdjustable = "Pricing rules are not configurable"
+	errMsgFailedToLoadRules    = "Failed to load pricing rules"
+	errMsgFailedToSaveRules    = "Failed to save pricing rules"
+	errMsgInvalidPricingRules  = "Multipliers must be positive and min_multiplier may not exceed max_multiplier"
+	errMsgFailedToPrice        = "Failed to price flight"
+
+	// rulesRefreshInterval bounds how stale another instance's rule change can be
+	rulesRefreshInterval = time.Minute
+	// repricingHorizon bounds which upcoming flights have their new price
+	// recorded when the pricing rules change
+	repricingHorizon = 366 * 24 * time.Hour
+)
+
+// PricingEngine computes the price a fare sells at, at a given moment
+type PricingEngine interface {
+	Price(ctx context.Context, flight *model.Flight, fare model.FareBucket, at time.Time) (float64, error)
+}
+
+// PricingRuleManager is implemented by engines whose rules admins can edit
+type PricingRuleManager interface {
+	Rules(ctx context.Context) (*model.PricingRules, error)
+	SaveRules(ctx context.Context, rules *model.PricingRules) error
+}
+
+type PricingRulesRepository interface {
+	Get(ctx context.Context) (*model.PricingRules, error)
+	Save(ctx context.Context, rules *model.PricingRules) error
+}
+
+// QuoteStore keeps issued fare quotes until they expire
+type QuoteStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+}
+
+// RuleBasedPricing adjusts base fares by load factor, days to departure and
+// departure day of week using rules stored in the repository.
+type RuleBasedPricing struct {
+	repo     PricingRulesRepository
+	mu       sync.RWMutex
+	rules    *model.PricingRules
+	loadedAt time.Time
+}
+
+func NewRuleBasedPricing(repo PricingRulesRepository) *RuleBasedPricing {
+	return &RuleBasedPricing{repo: repo}
+}
+
+// Price applies the current rules to the fare's base price
+func (p *RuleBasedPricing) Price(ctx context.Context, flight *model.Flight, fare model.FareBucket, at time.Time) (float64, error) {
+	rules, err := p.Rules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	daysOut := int(flight.DepartureTime.Sub(at).Hours() / 24)
+	if daysOut < 0 {
+		daysOut = 0
+	}
+	multiplier := rules.Multiplier(flight.LoadFactor(), daysOut, flight.DepartureTime.Weekday())
+	return math.Round(fare.Price*multiplier*100) / 100, nil
+}
+
+// Rules returns the active rules, reloading them once they are stale
+func (p *RuleBasedPricing) Rules(ctx context.Context) (*model.PricingRules, error) {
+	p.mu.RLock()
+	rules, loadedAt := p.rules, p.loadedAt
+	p.mu.RUnlock()
+	if rules != nil && time.Since(loadedAt) < rulesRefreshInterval {
+		return rules, nil
+	}
+
+	rules, err := p.repo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = &model.PricingRules{ID: model.PricingRulesID}
+	}
+
+	p.mu.Lock()
+	p.rules, p.loadedAt = rules, time.Now()
+	p.mu.Unlock()
+	return rules, nil
+}
+
+// SaveRules stores new rules and applies them immediately
+func (p *RuleBasedPricing) SaveRules(ctx context.Context, rules *model.PricingRules) error {
+	rules.ID = model.PricingRulesID
+	if err := p.repo.Save(ctx, rules); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.rules, p.loadedAt = rules, time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// WithPricing sets the pricing engine and the store used to honour quoted prices
+func (s *FlightService) WithPricing(engine PricingEngine, quotes QuoteStore) *FlightService {
+	s.pricing = engine
+	s.quotes = quotes
+	return s
+}
+
+// GetPricingRules returns the rules of a configurable pricing engine
+func (s *FlightService) GetPricingRules(ctx context.Context) (*model.PricingRules, error) {
+	manager, ok := s.pricing.(PricingRuleManager)
+	if !ok {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPricingNotAdjustable, http.StatusNotFound)
+	}
+
+	rules, err := manager.Rules(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToLoadRules, http.StatusInternalServerError)
+	}
+	return rules, nil
+}
+
+// UpdatePricingRules replaces the rules of a configurable pricing engine and
+// records the price every upcoming flight now sells at
+func (s *FlightService) UpdatePricingRules(ctx context.Context, adminID string, rules *model.PricingRules) (*model.PricingRules, error) {
+	manager, ok := s.pricing.(PricingRuleManager)
+	if !ok {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgPricingNotAdjustable, http.StatusNotFound)
+	}
+	if err := validatePricingRules(rules); err != nil {
+		return nil, err
+	}
+
+	rules.UpdatedAt = time.Now()
+	rules.UpdatedBy = adminID
+	if err := manager.SaveRules(ctx, rules); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveRules, http.StatusInternalServerError)
+	}
+	s.recordRepricing(ctx, rules.UpdatedAt)
+	return rules, nil
+}
+
+// recordRepricing adds the price of every upcoming sellable flight under the
+// current rules to its history. Like all history it is best effort, so the
+// rules stand even when it fails.
+func (s *FlightService) recordRepricing(ctx context.Context, now time.Time) {
+	if s.priceHistory == nil {
+		return
+	}
+	flights, err := s.repo.FindDepartingBetween(ctx, now, now.Add(repricingHorizon))
+	if err != nil {
+		return
+	}
+	for _, flight := range flights {
+		if !model.IsSellable(flight.Status) {
+			continue
+		}
+		if _, err := s.priceFlight(ctx, flight, now); err == nil {
+			s.recordPrice(ctx, flight, model.PriceSourcePricing)
+		}
+	}
+}
+
+// FarePrice returns the unit price to charge for a fare. A quote issued at
+// search time fixes the price; without one the fare is priced now.
+func (s *FlightService) FarePrice(ctx context.Context, flight *model.Flight, fare model.FareBucket, quoteID string) (float64, error) {
+	if quoteID == "" || s.quotes == nil {
+		price, err := s.currentPrice(ctx, flight, fare, time.Now())
+		if err != nil {
+			return 0, common.NewAppError(common.ErrInternalServer, errMsgFailedToPrice, http.StatusInternalServerError)
+		}
+		return price, nil
+	}
+
+	value, err := s.quotes.Get(ctx, fmt.Sprintf(common.CacheKeyFareQuote, quoteID))
+	if err != nil {
+		return 0, common.NewAppError(common.ErrInvalidInput, errMsgQuoteExpired, http.StatusConflict)
+	}
+	var quote model.FareQuote
+	if err := json.Unmarshal([]byte(value), &quote); err != nil || quote.FlightID != flight.ID || time.Now().After(quote.ExpiresAt) {
+		return 0, common.NewAppError(common.ErrInvalidInput, errMsgQuoteExpired, http.StatusConflict)
+	}
+
+	price, ok := quote.Prices[fare.Code]
+	if !ok {
+		return 0, common.NewAppError(common.ErrInvalidInput, errMsgFareNotQuoted, http.StatusConflict)
+	}
+	return price, nil
+}
+
+// currentPrice prices a fare with the engine, or returns its base price
+func (s *FlightService) currentPrice(ctx context.Context, flight *model.Flight, fare model.FareBucket, at time.Time) (float64, error) {
+	if s.pricing == nil {
+		return fare.Price, nil
+	}
+	return s.pricing.Price(ctx, flight, fare, at)
+}
+
+// priceFlights reprices search results with the pricing engine and records a
+// quote per flight so the shown prices can be honoured at booking time
+func (s *FlightService) priceFlights(ctx context.Context, flights []*model.Flight) error {
+	if s.pricing == nil {
+		return nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(model.DefaultQuoteTTL)
+	for _, flight := range flights {
+		prices, err := s.priceFlight(ctx, flight, now)
+		if err != nil {
+			return err
+		}
+
+		if s.quotes == nil {
+			continue
+		}
+		quote := model.FareQuote{
+			ID:        uuid.NewString(),
+			FlightID:  flight.ID,
+			Prices:    prices,
+			ExpiresAt: expiresAt,
+		}
+		if err := s.quotes.Set(ctx, fmt.Sprintf(common.CacheKeyFareQuote, quote.ID), quote, model.DefaultQuoteTTL); err != nil {
+			return err
+		}
+		flight.QuoteID = quote.ID
+		flight.QuoteExpiresAt = &quote.ExpiresAt
+	}
+	return nil
+}
+
+// priceFlight sets the current price of every fare of a flight and its
+// headline price, returning the prices by fare code. Without a pricing
+// engine the stored prices stand.
+func (s *FlightService) priceFlight(ctx context.Context, flight *model.Flight, at time.Time) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	if s.pricing == nil {
+		return prices, nil
+	}
+
+	if len(flight.Fares) == 0 {
+		price, err := s.pricing.Price(ctx, flight, model.FareBucket{Cabin: model.CabinEconomy, Price: flight.Price}, at)
+		if err != nil {
+			return nil, err
+		}
+		flight.Price = price
+		prices[""] = price
+		return prices, nil
+	}
+
+	for i := range flight.Fares {
+		price, err := s.pricing.Price(ctx, flight, flight.Fares[i], at)
+		if err != nil {
+			return nil, err
+		}
+		flight.Fares[i].Price = price
+		prices[flight.Fares[i].Code] = price
+	}
+	flight.Price = flight.HeadlinePrice()
+	return prices, nil
+}
+
+// validatePricingRules rejects rules that would produce zero or inverted prices
+func validatePricingRules(rules *model.PricingRules) error {
+	invalid := common.NewAppError(common.ErrInvalidInput, errMsgInvalidPricingRules, http.StatusBadRequest)
+	for _, tier := range rules.LoadFactor {
+		if tier.Multiplier <= 0 || tier.MinLoadFactor < 0 || tier.MinLoadFactor > 1 {
+			return invalid
+		}
+	}
+	for _, tier := range rules.AdvancePurchase {
+		if tier.Multiplier <= 0 || tier.MaxDaysOut < 0 {
+			return invalid
+		}
+	}
+	for _, multiplier := range rules.DayOfWeek {
+		if multiplier <= 0 {
+			return invalid
+		}
+	}
+	if rules.MinMultiplier < 0 || rules.MaxMultiplier < 0 ||
+		(rules.MaxMultiplier > 0 && rules.MinMultiplier > rules.MaxMultiplier) {
+		return invalid
+	}
+	return nil
+}
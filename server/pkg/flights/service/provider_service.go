@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgSearchFailed    = "Flight search failed"
+	errMsgProviderFailed  = "Provider search failed"
+	errMsgProviderTimeout = "Provider did not answer in time"
+)
+
+// FlightProvider supplies flights from a content source other than our own
+// catalog, such as a GDS
+type FlightProvider interface {
+	Name() string
+	SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error)
+}
+
+// registeredProvider is a provider with the time it is given to answer
+type registeredProvider struct {
+	provider FlightProvider
+	timeout  time.Duration
+}
+
+// WithProvider adds an external flight provider to searches. A provider
+// that has not answered within the timeout is left out of the results.
+func (s *FlightService) WithProvider(provider FlightProvider, timeout time.Duration) *FlightService {
+	s.providers = append(s.providers, registeredProvider{provider: provider, timeout: timeout})
+	return s
+}
+
+// catalogProvider exposes our own inventory as a provider
+type catalogProvider struct {
+	service *FlightService
+}
+
+func (p catalogProvider) Name() string {
+	return model.CatalogProvider
+}
+
+func (p catalogProvider) SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	return p.service.searchCatalog(ctx, criteria)
+}
+
+// SearchFlights finds direct flights on a route. The catalog and every
+// external provider are searched concurrently; results are merged with
+// duplicates removed, preferring the catalog and then providers in the order
+// they were added. The merged flights are faceted, then filtered, sorted and
+// paged as the search options ask. The search only fails if every provider
+// does.
+func (s *FlightService) SearchFlights(ctx context.Context, criteria model.SearchFlightRequest) (*model.FlightSearchResult, error) {
+	query, err := parseSearchOptions(criteria.SearchOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := append([]registeredProvider{{provider: catalogProvider{service: s}}}, s.providers...)
+
+	found := make([][]*model.Flight, len(sources))
+	statuses := make([]model.ProviderStatus, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source registeredProvider) {
+			defer wg.Done()
+			found[i], statuses[i] = queryProvider(ctx, source, criteria)
+		}(i, source)
+	}
+	wg.Wait()
+
+	result := &model.FlightSearchResult{Providers: statuses}
+	var flights []*model.Flight
+	seen := make(map[string]bool)
+	failed := 0
+	for i, status := range statuses {
+		if status.Status != model.ProviderStatusOK {
+			result.Partial = true
+			failed++
+			continue
+		}
+		for _, flight := range found[i] {
+			key := dedupeKey(flight)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			flights = append(flights, flight)
+		}
+	}
+	if failed == len(sources) {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgSearchFailed, http.StatusBadGateway)
+	}
+
+	locate := s.airportLocator(ctx)
+	result.Facets = searchFacets(flights, locate)
+	query.apply(result, flights, locate)
+	return result, nil
+}
+
+// searchCatalog searches our own inventory. Either end may be a metro code,
+// in which case every airport of the metro area is searched. Results carry
+// dynamic prices and quotes.
+func (s *FlightService) searchCatalog(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	flights, err := s.findCatalog(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.priceFlights(ctx, flights); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToPrice, http.StatusInternalServerError)
+	}
+	return flights, nil
+}
+
+// findCatalog finds the flights of our own inventory matching a search, at their stored prices
+func (s *FlightService) findCatalog(ctx context.Context, criteria model.SearchFlightRequest) ([]*model.Flight, error) {
+	origins := s.searchAirports(ctx, criteria.DepartureCity)
+	destinations := s.searchAirports(ctx, criteria.ArrivalCity)
+
+	var flights []*model.Flight
+	for _, origin := range origins {
+		for _, destination := range destinations {
+			pair := criteria
+			pair.DepartureCity = origin
+			pair.ArrivalCity = destination
+			pair.DepartureDate = s.localDay(ctx, origin, criteria.DepartureDate)
+
+			found, err := s.repo.Search(ctx, pair)
+			if err != nil {
+				return nil, err
+			}
+			flights = append(flights, found...)
+		}
+	}
+	return flights, nil
+}
+
+// queryProvider runs one provider search, abandoning it once its timeout passes
+func queryProvider(ctx context.Context, source registeredProvider, criteria model.SearchFlightRequest) ([]*model.Flight, model.ProviderStatus) {
+	status := model.ProviderStatus{Name: source.provider.Name(), Status: model.ProviderStatusOK}
+	if source.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, source.timeout)
+		defer cancel()
+	}
+
+	type answer struct {
+		flights []*model.Flight
+		err     error
+	}
+	// Buffered so a provider that ignores its context can still finish
+	answers := make(chan answer, 1)
+	go func() {
+		flights, err := source.provider.SearchFlights(ctx, criteria)
+		answers <- answer{flights: flights, err: err}
+	}()
+
+	var got answer
+	select {
+	case got = <-answers:
+	case <-ctx.Done():
+		got.err = ctx.Err()
+	}
+
+	// Only our own error messages are passed on; others may expose internals
+	var appErr *common.AppError
+	switch {
+	case errors.Is(got.err, context.DeadlineExceeded):
+		status.Status = model.ProviderStatusTimeout
+		status.Error = errMsgProviderTimeout
+		return nil, status
+	case errors.As(got.err, &appErr):
+		status.Status = model.ProviderStatusError
+		status.Error = appErr.Error()
+		return nil, status
+	case got.err != nil:
+		status.Status = model.ProviderStatusError
+		status.Error = errMsgProviderFailed
+		return nil, status
+	}
+	status.Flights = len(got.flights)
+	return got.flights, status
+}
+
+// dedupeKey identifies the same flight across providers
+func dedupeKey(flight *model.Flight) string {
+	return strings.ToUpper(strings.ReplaceAll(flight.FlightNumber, " ", "")) + "|" + flight.DepartureTime.UTC().Format(time.RFC3339)
+}
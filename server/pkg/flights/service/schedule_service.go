@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgSchedulesDisabled   = "Flight schedules are not configured"
+	errMsgScheduleNotFound    = "Schedule not found"
+	errMsgInvalidSchedule     = "Schedules need a flight number, two different cities, a departure time (HH:MM), a time zone, a positive duration, capacity and days of week, and a validity period"
+	errMsgFailedToSaveSched   = "Failed to save schedule"
+	errMsgFailedToGenerate    = "Failed to generate flights from schedule"
+	conflictFlightNumberTaken = "Flight number already operates on this date"
+	conflictInstanceSold      = "Flight has bookings or has left scheduled status; left unchanged"
+	conflictInstanceDropped   = "Flight is no longer in the schedule but has bookings or has left scheduled status"
+)
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *model.ScheduleTemplate) error
+	FindByID(ctx context.Context, id string) (*model.ScheduleTemplate, error)
+	Update(ctx context.Context, schedule *model.ScheduleTemplate) error
+	List(ctx context.Context) ([]*model.ScheduleTemplate, error)
+}
+
+// WithSchedules sets the repository holding recurring schedule templates
+func (s *FlightService) WithSchedules(repo ScheduleRepository) *FlightService {
+	s.schedules = repo
+	return s
+}
+
+// CreateSchedule saves a schedule template and materializes its flights over
+// the default horizon.
+func (s *FlightService) CreateSchedule(ctx context.Context, schedule *model.ScheduleTemplate) (*model.ScheduleResponse, error) {
+	if s.schedules == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSchedulesDisabled, http.StatusNotFound)
+	}
+	if err := s.prepareSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule.ID = uuid.NewString()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	if err := s.schedules.Create(ctx, schedule); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSched, http.StatusInternalServerError)
+	}
+
+	report, err := s.materializeSchedule(ctx, schedule, model.DefaultScheduleHorizonDays)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ScheduleResponse{Schedule: schedule, Generation: report}, nil
+}
+
+func (s *FlightService) GetSchedule(ctx context.Context, id string) (*model.ScheduleTemplate, error) {
+	if s.schedules == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSchedulesDisabled, http.StatusNotFound)
+	}
+	schedule, err := s.schedules.FindByID(ctx, id)
+	if err != nil || schedule == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgScheduleNotFound, http.StatusNotFound)
+	}
+	return schedule, nil
+}
+
+func (s *FlightService) ListSchedules(ctx context.Context) ([]*model.ScheduleTemplate, error) {
+	if s.schedules == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSchedulesDisabled, http.StatusNotFound)
+	}
+	schedules, err := s.schedules.List(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, "Failed to list schedules", http.StatusInternalServerError)
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule replaces a schedule template. Future instances without
+// bookings are brought in line with it; sold instances are left alone and
+// reported as conflicts.
+func (s *FlightService) UpdateSchedule(ctx context.Context, id string, schedule *model.ScheduleTemplate) (*model.ScheduleResponse, error) {
+	existing, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prepareSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = id
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.schedules.Update(ctx, schedule); err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSched, http.StatusInternalServerError)
+	}
+
+	report, err := s.materializeSchedule(ctx, schedule, model.DefaultScheduleHorizonDays)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ScheduleResponse{Schedule: schedule, Generation: report}, nil
+}
+
+// GenerateSchedule materializes the flights of one schedule over the requested horizon
+func (s *FlightService) GenerateSchedule(ctx context.Context, id string, req model.GenerateScheduleRequest) (*model.ScheduleGenerationReport, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.materializeSchedule(ctx, schedule, req.HorizonDays)
+}
+
+// GenerateSchedules rolls every schedule forward over the requested horizon.
+// It is meant to be run periodically so the horizon keeps moving.
+func (s *FlightService) GenerateSchedules(ctx context.Context, req model.GenerateScheduleRequest) ([]*model.ScheduleGenerationReport, error) {
+	schedules, err := s.ListSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*model.ScheduleGenerationReport, 0, len(schedules))
+	for _, schedule := range schedules {
+		report, err := s.materializeSchedule(ctx, schedule, req.HorizonDays)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// materializeSchedule creates the missing flights of a schedule between now
+// and the horizon, updates or removes its unsold future flights to match the
+// template, and reports every departure it had to leave alone.
+func (s *FlightService) materializeSchedule(ctx context.Context, schedule *model.ScheduleTemplate, horizonDays int) (*model.ScheduleGenerationReport, error) {
+	if horizonDays <= 0 {
+		horizonDays = model.DefaultScheduleHorizonDays
+	}
+	if horizonDays > model.MaxScheduleHorizonDays {
+		horizonDays = model.MaxScheduleHorizonDays
+	}
+
+	now := time.Now()
+	to := now.AddDate(0, 0, horizonDays)
+	failed := common.NewAppError(common.ErrInternalServer, errMsgFailedToGenerate, http.StatusInternalServerError)
+
+	instances, err := s.repo.FindBySchedule(ctx, schedule.ID, now)
+	if err != nil {
+		return nil, failed
+	}
+	// Instances generated past the horizon still have to follow the template
+	for _, instance := range instances {
+		if !instance.DepartureTime.Before(to) {
+			to = instance.DepartureTime.Add(time.Minute)
+		}
+	}
+
+	departures, err := schedule.Departures(now, to)
+	if err != nil {
+		return nil, failed
+	}
+	sameNumber, err := s.repo.FindByFlightNumber(ctx, schedule.FlightNumber, now.AddDate(0, 0, -1), to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, failed
+	}
+
+	// Flights are matched to departures by local calendar date
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return nil, failed
+	}
+	dateOf := func(t time.Time) string {
+		return t.In(loc).Format("2006-01-02")
+	}
+	own := make(map[string]*model.Flight, len(instances))
+	for _, instance := range instances {
+		own[dateOf(instance.DepartureTime)] = instance
+	}
+	taken := make(map[string]*model.Flight, len(sameNumber))
+	for _, flight := range sameNumber {
+		if flight.ScheduleID != schedule.ID {
+			taken[dateOf(flight.DepartureTime)] = flight
+		}
+	}
+
+	report := &model.ScheduleGenerationReport{
+		ScheduleID: schedule.ID,
+		From:       now,
+		To:         to,
+		Conflicts:  []model.ScheduleConflict{},
+	}
+
+	for _, departure := range departures {
+		date := dateOf(departure)
+
+		if instance, ok := own[date]; ok {
+			delete(own, date)
+			if schedule.Matches(instance, departure) {
+				continue
+			}
+			if !isUnsold(instance) {
+				report.Conflicts = append(report.Conflicts, model.ScheduleConflict{Departure: departure, FlightID: instance.ID, Reason: conflictInstanceSold})
+				continue
+			}
+			schedule.Apply(instance, departure)
+			if err := s.overbook(ctx, instance); err != nil {
+				return nil, err
+			}
+			instance.UpdatedAt = now
+			if err := s.repo.Update(ctx, instance); err != nil {
+				return nil, failed
+			}
+			report.Updated++
+			continue
+		}
+
+		if other, ok := taken[date]; ok {
+			report.Conflicts = append(report.Conflicts, model.ScheduleConflict{Departure: departure, FlightID: other.ID, Reason: conflictFlightNumberTaken})
+			continue
+		}
+
+		flight := &model.Flight{
+			ID:        uuid.NewString(),
+			Status:    model.FlightStatusScheduled,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		schedule.Apply(flight, departure)
+		if err := s.overbook(ctx, flight); err != nil {
+			return nil, err
+		}
+		if err := s.repo.Create(ctx, flight); err != nil {
+			return nil, failed
+		}
+		report.Created++
+	}
+
+	// Whatever is left no longer operates under the template
+	for _, instance := range own {
+		if !isUnsold(instance) {
+			report.Conflicts = append(report.Conflicts, model.ScheduleConflict{Departure: instance.DepartureTime, FlightID: instance.ID, Reason: conflictInstanceDropped})
+			continue
+		}
+		if err := s.repo.Delete(ctx, instance.ID); err != nil {
+			return nil, failed
+		}
+		report.Removed++
+	}
+
+	return report, nil
+}
+
+// isUnsold reports whether a flight is still scheduled with none of its seats sold
+func isUnsold(flight *model.Flight) bool {
+	return flight.Status == model.FlightStatusScheduled && flight.Sold() == 0
+}
+
+// prepareSchedule checks the route of a schedule against the airport registry,
+// defaulting its time zone to the departure airport's, then validates it
+func (s *FlightService) prepareSchedule(ctx context.Context, schedule *model.ScheduleTemplate) error {
+	origin, err := s.resolveRoute(ctx, &schedule.DepartureCity, &schedule.ArrivalCity)
+	if err != nil {
+		return err
+	}
+	if schedule.TimeZone == "" && origin != nil {
+		schedule.TimeZone = origin.TimeZone
+	}
+	return validateSchedule(schedule)
+}
+
+// validateSchedule checks a schedule template and normalizes its days of week
+// and, when it has fare buckets, its capacity and headline price
+func validateSchedule(schedule *model.ScheduleTemplate) error {
+	invalid := common.NewAppError(common.ErrInvalidInput, errMsgInvalidSchedule, http.StatusBadRequest)
+
+	if schedule.FlightNumber == "" || schedule.DepartureCity == "" || schedule.ArrivalCity == "" ||
+		schedule.DepartureCity == schedule.ArrivalCity || schedule.DurationMinutes <= 0 ||
+		schedule.ValidFrom.IsZero() || schedule.ValidTo.Before(schedule.ValidFrom) {
+		return invalid
+	}
+	if _, err := time.Parse(model.ScheduleTimeLayout, schedule.DepartureTime); err != nil {
+		return invalid
+	}
+	if _, err := time.LoadLocation(schedule.TimeZone); err != nil || schedule.TimeZone == "" {
+		return invalid
+	}
+
+	weekdays := make(map[string]bool, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		weekdays[strings.ToLower(day.String())] = true
+	}
+	days := make([]string, 0, len(schedule.DaysOfWeek))
+	seen := make(map[string]bool, len(schedule.DaysOfWeek))
+	for _, day := range schedule.DaysOfWeek {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if !weekdays[day] {
+			return invalid
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return invalid
+	}
+	schedule.DaysOfWeek = days
+
+	// Fare buckets are validated the same way as on a single flight
+	probe := &model.Flight{Fares: schedule.Fares}
+	if err := normalizeFares(probe); err != nil {
+		return err
+	}
+	if len(schedule.Fares) > 0 {
+		schedule.Capacity = probe.AvailableSeats
+		schedule.Price = probe.Price
+	}
+	if schedule.Capacity <= 0 || schedule.Price < 0 {
+		return invalid
+	}
+	return nil
+}
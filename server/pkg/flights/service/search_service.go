@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgInvalidSort       = "Sort must be one of departure, arrival, price or duration"
+	errMsgInvalidPriceRange = "Price range must not be negative and min_price must not exceed max_price"
+	errMsgInvalidTimeWindow = "Time windows must be HH:MM and cover part of the day"
+	errMsgInvalidCursor     = "Invalid search cursor"
+	minutesPerDay           = 24 * 60
+)
+
+// searchQuery holds validated search options
+type searchQuery struct {
+	sort      string
+	minPrice  float64
+	maxPrice  float64
+	departure *timeWindow
+	arrival   *timeWindow
+	carriers  map[string]bool
+	maxStops  int
+	limit     int
+	after     *searchCursor
+}
+
+// timeWindow is a range of minutes after local midnight, excluding to. It
+// wraps past midnight when from is after to.
+type timeWindow struct {
+	from int
+	to   int
+}
+
+func (w *timeWindow) contains(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if w.from < w.to {
+		return minute >= w.from && minute < w.to
+	}
+	return minute >= w.from || minute < w.to
+}
+
+// searchCursor marks the last flight of a page by its position in the sort
+// order, so the next page still lines up when prices or inventory change
+type searchCursor struct {
+	Sort      string    `json:"s"`
+	Price     float64   `json:"p"`
+	Departure time.Time `json:"d"`
+	Arrival   time.Time `json:"a"`
+	Key       string    `json:"k"`
+}
+
+func cursorFor(flight *model.Flight, order string) searchCursor {
+	return searchCursor{
+		Sort:      order,
+		Price:     flight.Price,
+		Departure: flight.DepartureTime,
+		Arrival:   flight.ArrivalTime,
+		Key:       dedupeKey(flight),
+	}
+}
+
+// itineraryCursor places an itinerary by its per-passenger price, first
+// departure and last arrival, and the flights it is made of
+func itineraryCursor(itinerary *model.Itinerary, order string) searchCursor {
+	keys := make([]string, len(itinerary.Legs))
+	for i, leg := range itinerary.Legs {
+		keys[i] = dedupeKey(leg)
+	}
+	return searchCursor{
+		Sort:      order,
+		Price:     itinerary.PricePerPassenger,
+		Departure: itinerary.DepartureTime,
+		Arrival:   itinerary.ArrivalTime,
+		Key:       strings.Join(keys, ","),
+	}
+}
+
+func (c searchCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// compareCursors orders two positions by the sort key, then departure time,
+// then flight identity so every flight has a single place in the order
+func compareCursors(a, b searchCursor) int {
+	switch a.Sort {
+	case model.SortByPrice:
+		if a.Price != b.Price {
+			return compareFloats(a.Price, b.Price)
+		}
+	case model.SortByArrival:
+		if !a.Arrival.Equal(b.Arrival) {
+			return a.Arrival.Compare(b.Arrival)
+		}
+	case model.SortByDuration:
+		da, db := a.Arrival.Sub(a.Departure), b.Arrival.Sub(b.Departure)
+		if da != db {
+			return compareFloats(float64(da), float64(db))
+		}
+	}
+	if !a.Departure.Equal(b.Departure) {
+		return a.Departure.Compare(b.Departure)
+	}
+	return strings.Compare(a.Key, b.Key)
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// parseSearchOptions validates the sort, filter and paging options of a search
+func parseSearchOptions(options model.SearchOptions) (*searchQuery, error) {
+	query := &searchQuery{
+		sort:     strings.ToLower(strings.TrimSpace(options.Sort)),
+		minPrice: options.MinPrice,
+		maxPrice: options.MaxPrice,
+		maxStops: model.MaxItineraryStops,
+		limit:    options.Limit,
+	}
+
+	switch query.sort {
+	case "":
+		query.sort = model.SortByDeparture
+	case model.SortByDeparture, model.SortByArrival, model.SortByPrice, model.SortByDuration:
+	default:
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidSort, http.StatusBadRequest)
+	}
+
+	if query.minPrice < 0 || query.maxPrice < 0 || (query.maxPrice > 0 && query.minPrice > query.maxPrice) {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidPriceRange, http.StatusBadRequest)
+	}
+
+	var err error
+	if query.departure, err = parseTimeWindow(options.DepartureTimeFrom, options.DepartureTimeTo); err != nil {
+		return nil, err
+	}
+	if query.arrival, err = parseTimeWindow(options.ArrivalTimeFrom, options.ArrivalTimeTo); err != nil {
+		return nil, err
+	}
+
+	if len(options.Carriers) > 0 {
+		query.carriers = make(map[string]bool, len(options.Carriers))
+		for _, carrier := range options.Carriers {
+			query.carriers[strings.ToUpper(strings.TrimSpace(carrier))] = true
+		}
+	}
+
+	if query.limit <= 0 {
+		query.limit = model.DefaultSearchPageSize
+	}
+	if query.limit > model.MaxSearchPageSize {
+		query.limit = model.MaxSearchPageSize
+	}
+
+	if options.Cursor != "" {
+		cursor, err := decodeCursor(options.Cursor)
+		if err != nil || cursor.Sort != query.sort {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidCursor, http.StatusBadRequest)
+		}
+		query.after = cursor
+	}
+	return query, nil
+}
+
+// parseTimeWindow builds a window from optional "HH:MM" bounds. A missing
+// start means midnight and a missing end means the end of the day.
+func parseTimeWindow(from, to string) (*timeWindow, error) {
+	if from == "" && to == "" {
+		return nil, nil
+	}
+	invalid := common.NewAppError(common.ErrInvalidInput, errMsgInvalidTimeWindow, http.StatusBadRequest)
+
+	window := &timeWindow{from: 0, to: minutesPerDay}
+	var err error
+	if from != "" {
+		if window.from, err = parseClock(from); err != nil {
+			return nil, invalid
+		}
+	}
+	if to != "" {
+		if window.to, err = parseClock(to); err != nil {
+			return nil, invalid
+		}
+	}
+	if window.from == window.to {
+		return nil, invalid
+	}
+	return window, nil
+}
+
+// parseClock returns the minutes after midnight of an "HH:MM" time, allowing
+// "24:00" as the end of the day
+func parseClock(value string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || len(value) != len("15:04") {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	total := hours*60 + minutes
+	if hours < 0 || minutes < 0 || minutes > 59 || total > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return total, nil
+}
+
+// airportLocator memoizes airport time zones for the length of one search
+func (s *FlightService) airportLocator(ctx context.Context) func(string) *time.Location {
+	locations := make(map[string]*time.Location)
+	return func(code string) *time.Location {
+		loc, ok := locations[code]
+		if !ok {
+			loc = s.airportLocation(ctx, code)
+			locations[code] = loc
+		}
+		return loc
+	}
+}
+
+// matches reports whether a flight passes every filter of the query
+func (q *searchQuery) matches(flight *model.Flight, locate func(string) *time.Location) bool {
+	if flight.Price < q.minPrice || (q.maxPrice > 0 && flight.Price > q.maxPrice) {
+		return false
+	}
+	if q.carriers != nil && !q.carriers[flight.Carrier()] {
+		return false
+	}
+	if q.departure != nil && !q.departure.contains(flight.DepartureTime, locate(flight.DepartureCity)) {
+		return false
+	}
+	if q.arrival != nil && !q.arrival.contains(flight.ArrivalTime, locate(flight.ArrivalCity)) {
+		return false
+	}
+	return true
+}
+
+// matchesItinerary reports whether an itinerary passes every filter of the
+// query. Prices are per passenger, times are those of the first departure
+// and last arrival, and every leg must be flown by one of the carriers.
+func (q *searchQuery) matchesItinerary(itinerary *model.Itinerary, locate func(string) *time.Location) bool {
+	if itinerary.Stops > q.maxStops {
+		return false
+	}
+	price := itinerary.PricePerPassenger
+	if price < q.minPrice || (q.maxPrice > 0 && price > q.maxPrice) {
+		return false
+	}
+	if q.carriers != nil {
+		for _, carrier := range itinerary.Carriers() {
+			if !q.carriers[carrier] {
+				return false
+			}
+		}
+	}
+	if q.departure != nil && !q.departure.contains(itinerary.DepartureTime, locate(itinerary.DepartureCity)) {
+		return false
+	}
+	if q.arrival != nil && !q.arrival.contains(itinerary.ArrivalTime, locate(itinerary.ArrivalCity)) {
+		return false
+	}
+	return true
+}
+
+// apply filters and sorts the merged flights of a search into the result and
+// cuts out the requested page
+func (q *searchQuery) apply(result *model.FlightSearchResult, flights []*model.Flight, locate func(string) *time.Location) {
+	filtered := make([]*model.Flight, 0, len(flights))
+	for _, flight := range flights {
+		if q.matches(flight, locate) {
+			filtered = append(filtered, flight)
+		}
+	}
+	result.Flights, result.NextCursor = paginate(q, filtered, cursorFor)
+	result.Total = len(filtered)
+}
+
+// applyItineraries filters and sorts the itineraries of a search into the
+// result and cuts out the requested page
+func (q *searchQuery) applyItineraries(result *model.ItinerarySearchResult, itineraries []*model.Itinerary, locate func(string) *time.Location) {
+	filtered := make([]*model.Itinerary, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		if q.matchesItinerary(itinerary, locate) {
+			filtered = append(filtered, itinerary)
+		}
+	}
+	result.Itineraries, result.NextCursor = paginate(q, filtered, itineraryCursor)
+	result.Total = len(filtered)
+}
+
+// paginate sorts results into the query's order and returns the requested
+// page with the cursor of the next one, if more follow
+func paginate[T any](q *searchQuery, results []T, position func(T, string) searchCursor) ([]T, string) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return compareCursors(position(results[i], q.sort), position(results[j], q.sort)) < 0
+	})
+
+	start := 0
+	if q.after != nil {
+		start = sort.Search(len(results), func(i int) bool {
+			return compareCursors(position(results[i], q.sort), *q.after) > 0
+		})
+	}
+	end := start + q.limit
+	if end >= len(results) {
+		return results[start:], ""
+	}
+	return results[start:end], position(results[end-1], q.sort).encode()
+}
+
+// searchFacets counts carriers and times of day across every flight a
+// search found, and the range of their prices
+func searchFacets(flights []*model.Flight, locate func(string) *time.Location) *model.SearchFacets {
+	facets := &model.SearchFacets{
+		Carriers:       []model.FacetCount{},
+		DepartureTimes: timeOfDayFacet(),
+		ArrivalTimes:   timeOfDayFacet(),
+	}
+
+	carriers := make(map[string]int)
+	for _, flight := range flights {
+		carriers[flight.Carrier()]++
+		countTimeOfDay(facets.DepartureTimes, flight.DepartureTime.In(locate(flight.DepartureCity)))
+		countTimeOfDay(facets.ArrivalTimes, flight.ArrivalTime.In(locate(flight.ArrivalCity)))
+		facets.Price = widenPriceRange(facets.Price, flight.Price)
+	}
+	facets.Carriers = countFacet(carriers)
+	return facets
+}
+
+// itineraryFacets counts carriers, stops and times of day across every
+// itinerary a search found, and the range of their per-passenger prices. An
+// itinerary counts once towards every carrier flying one of its legs.
+func itineraryFacets(itineraries []*model.Itinerary, locate func(string) *time.Location) *model.SearchFacets {
+	facets := &model.SearchFacets{
+		Carriers:       []model.FacetCount{},
+		Stops:          []model.FacetCount{},
+		DepartureTimes: timeOfDayFacet(),
+		ArrivalTimes:   timeOfDayFacet(),
+	}
+
+	carriers := make(map[string]int)
+	stops := make(map[string]int)
+	for _, itinerary := range itineraries {
+		for _, carrier := range itinerary.Carriers() {
+			carriers[carrier]++
+		}
+		stops[strconv.Itoa(itinerary.Stops)]++
+		countTimeOfDay(facets.DepartureTimes, itinerary.DepartureTime.In(locate(itinerary.DepartureCity)))
+		countTimeOfDay(facets.ArrivalTimes, itinerary.ArrivalTime.In(locate(itinerary.ArrivalCity)))
+		facets.Price = widenPriceRange(facets.Price, itinerary.PricePerPassenger)
+	}
+	facets.Carriers = countFacet(carriers)
+	for stop := 0; stop <= model.MaxItineraryStops; stop++ {
+		if count := stops[strconv.Itoa(stop)]; count > 0 {
+			facets.Stops = append(facets.Stops, model.FacetCount{Value: strconv.Itoa(stop), Count: count})
+		}
+	}
+	return facets
+}
+
+// countFacet lists the counts of a facet, most common value first
+func countFacet(counts map[string]int) []model.FacetCount {
+	facet := make([]model.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facet = append(facet, model.FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facet, func(i, j int) bool {
+		a, b := facet[i], facet[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	return facet
+}
+
+// widenPriceRange extends a price range, starting one if there is none, to cover price
+func widenPriceRange(prices *model.PriceRange, price float64) *model.PriceRange {
+	if prices == nil {
+		return &model.PriceRange{Min: price, Max: price}
+	}
+	if price < prices.Min {
+		prices.Min = price
+	}
+	if price > prices.Max {
+		prices.Max = price
+	}
+	return prices
+}
+
+func timeOfDayFacet() []model.FacetCount {
+	counts := make([]model.FacetCount, len(model.TimeOfDayBuckets))
+	for i, bucket := range model.TimeOfDayBuckets {
+		counts[i] = model.FacetCount{Value: bucket.Name, From: bucket.From, To: bucket.To}
+	}
+	return counts
+}
+
+func countTimeOfDay(counts []model.FacetCount, local time.Time) {
+	minute := local.Hour()*60 + local.Minute()
+	for i := range counts {
+		from, _ := parseClock(counts[i].From)
+		to, _ := parseClock(counts[i].To)
+		if minute >= from && minute < to {
+			counts[i].Count++
+			return
+		}
+	}
+}
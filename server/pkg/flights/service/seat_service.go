@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgSeatMapNotFound     = "Seat map not found"
+	errMsgSeatMapsDisabled    = "Seat maps are not configured"
+	errMsgInvalidLayout       = "Invalid cabin layout"
+	errMsgAssignedSeatRemoved = "Layout removes or blocks a seat that is already assigned"
+	errMsgSeatUnavailable     = "Seat %s is not available"
+	errMsgFailedToSaveSeatMap = "Failed to save seat map"
+	errMsgSeatMapChanged      = "Seat map changed while saving, please retry"
+	errMsgFailedToReleaseSeat = "Failed to release seat"
+)
+
+type SeatMapRepository interface {
+	FindByFlightID(ctx context.Context, flightID string) (*model.SeatMap, error)
+	Create(ctx context.Context, seatMap *model.SeatMap) error
+	// Replace swaps the stored seat map only if it was last updated at expectedUpdatedAt
+	Replace(ctx context.Context, seatMap *model.SeatMap, expectedUpdatedAt time.Time) (bool, error)
+	// AssignSeat sets the booking on a free, unblocked seat and reports whether it succeeded
+	AssignSeat(ctx context.Context, flightID, seatNumber, bookingID string) (bool, error)
+	ReleaseSeat(ctx context.Context, flightID, seatNumber, bookingID string) error
+}
+
+// WithSeatMaps sets the repository used for per-seat inventory
+func (s *FlightService) WithSeatMaps(repo SeatMapRepository) *FlightService {
+	s.seatMaps = repo
+	return s
+}
+
+// GetSeatMap returns the seat map of a flight with live occupancy
+func (s *FlightService) GetSeatMap(ctx context.Context, flightID string) (*model.SeatMap, error) {
+	if s.seatMaps == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSeatMapsDisabled, http.StatusNotFound)
+	}
+
+	seatMap, err := s.seatMaps.FindByFlightID(ctx, flightID)
+	if err != nil || seatMap == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSeatMapNotFound, http.StatusNotFound)
+	}
+
+	for i := range seatMap.Seats {
+		seatMap.Seats[i].Occupied = seatMap.Seats[i].BookingID != ""
+	}
+	return seatMap, nil
+}
+
+// ConfigureSeatMap generates the seats of a flight from its cabin layout.
+// Existing assignments are carried over; a layout that would drop or block an
+// assigned seat is rejected.
+func (s *FlightService) ConfigureSeatMap(ctx context.Context, flightID string, req *model.ConfigureSeatMapRequest) (*model.SeatMap, error) {
+	if s.seatMaps == nil {
+		return nil, common.NewAppError(common.ErrNotFound, errMsgSeatMapsDisabled, http.StatusNotFound)
+	}
+
+	flight, err := s.repo.FindByID(ctx, flightID)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+	}
+
+	seats, err := buildSeats(req.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.seatMaps.FindByFlightID(ctx, flightID)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSeatMap, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	seatMap := &model.SeatMap{
+		FlightID:  flightID,
+		Layout:    req.Layout,
+		Seats:     seats,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if existing == nil {
+		if err := s.seatMaps.Create(ctx, seatMap); err != nil {
+			return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSeatMap, http.StatusInternalServerError)
+		}
+		return seatMap, nil
+	}
+
+	if err := carryOverAssignments(existing, seatMap); err != nil {
+		return nil, err
+	}
+	seatMap.CreatedAt = existing.CreatedAt
+
+	ok, err := s.seatMaps.Replace(ctx, seatMap, existing.UpdatedAt)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSeatMap, http.StatusInternalServerError)
+	}
+	if !ok {
+		return nil, common.NewAppError(common.ErrInvalidInput, errMsgSeatMapChanged, http.StatusConflict)
+	}
+	return seatMap, nil
+}
+
+// AssignSeats gives the listed seats to a booking. Each assignment is atomic;
+// if any seat is taken, the seats assigned so far are released again.
+func (s *FlightService) AssignSeats(ctx context.Context, flightID, bookingID string, seats []string) error {
+	if len(seats) == 0 {
+		return nil
+	}
+	if s.seatMaps == nil {
+		return common.NewAppError(common.ErrNotFound, errMsgSeatMapsDisabled, http.StatusNotFound)
+	}
+
+	for i, seat := range seats {
+		ok, err := s.seatMaps.AssignSeat(ctx, flightID, seat, bookingID)
+		if err == nil && ok {
+			continue
+		}
+
+		s.ReleaseSeats(ctx, flightID, bookingID, seats[:i])
+		if err != nil {
+			return common.NewAppError(common.ErrInternalServer, errMsgFailedToSaveSeatMap, http.StatusInternalServerError)
+		}
+		return common.NewAppError(common.ErrSeatUnavailable, fmt.Sprintf(errMsgSeatUnavailable, seat), http.StatusConflict)
+	}
+	return nil
+}
+
+// ReleaseSeats frees seats held by a booking
+func (s *FlightService) ReleaseSeats(ctx context.Context, flightID, bookingID string, seats []string) error {
+	if len(seats) == 0 || s.seatMaps == nil {
+		return nil
+	}
+
+	for _, seat := range seats {
+		if err := s.seatMaps.ReleaseSeat(ctx, flightID, seat, bookingID); err != nil {
+			return common.NewAppError(common.ErrInternalServer, errMsgFailedToReleaseSeat, http.StatusInternalServerError)
+		}
+	}
+	return nil
+}
+
+// buildSeats expands cabin layouts into individual seats. Letters at either
+// end of a row are windows and letters next to an aisle marker are aisles.
+func buildSeats(layout []model.CabinLayout) ([]model.Seat, error) {
+	var seats []model.Seat
+	numbers := make(map[string]bool)
+
+	for _, cabin := range layout {
+		letters := strings.ToUpper(cabin.SeatLetters)
+		if cabin.Cabin == "" || cabin.FirstRow < 1 || cabin.LastRow < cabin.FirstRow || strings.TrimSpace(letters) == "" {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidLayout, http.StatusBadRequest)
+		}
+
+		exitRows := make(map[int]bool, len(cabin.ExitRows))
+		for _, row := range cabin.ExitRows {
+			exitRows[row] = true
+		}
+		blocked := make(map[string]bool, len(cabin.BlockedSeats))
+		for _, number := range cabin.BlockedSeats {
+			blocked[strings.ToUpper(number)] = true
+		}
+
+		first := strings.IndexFunc(letters, func(r rune) bool { return r != model.AisleMarker })
+		last := strings.LastIndexFunc(letters, func(r rune) bool { return r != model.AisleMarker })
+		besideAisle := func(i int) bool {
+			return (i > 0 && letters[i-1] == model.AisleMarker) || (i < len(letters)-1 && letters[i+1] == model.AisleMarker)
+		}
+
+		for row := cabin.FirstRow; row <= cabin.LastRow; row++ {
+			for i, letter := range letters {
+				if letter == model.AisleMarker {
+					continue
+				}
+				number := fmt.Sprintf("%d%c", row, letter)
+				if numbers[number] {
+					return nil, common.NewAppError(common.ErrInvalidInput, errMsgInvalidLayout, http.StatusBadRequest)
+				}
+				numbers[number] = true
+
+				seats = append(seats, model.Seat{
+					Number:  number,
+					Row:     row,
+					Letter:  string(letter),
+					Cabin:   cabin.Cabin,
+					Window:  i == first || i == last,
+					Aisle:   besideAisle(i),
+					ExitRow: exitRows[row],
+					Blocked: blocked[number],
+				})
+			}
+		}
+	}
+
+	return seats, nil
+}
+
+// carryOverAssignments copies bookings from the old seat map onto the new one
+func carryOverAssignments(existing, updated *model.SeatMap) error {
+	index := make(map[string]int, len(updated.Seats))
+	for i, seat := range updated.Seats {
+		index[seat.Number] = i
+	}
+
+	for _, seat := range existing.Seats {
+		if seat.BookingID == "" {
+			continue
+		}
+		i, ok := index[seat.Number]
+		if !ok || updated.Seats[i].Blocked {
+			return common.NewAppError(common.ErrInvalidInput, errMsgAssignedSeatRemoved, http.StatusConflict)
+		}
+		updated.Seats[i].BookingID = seat.BookingID
+	}
+	return nil
+}
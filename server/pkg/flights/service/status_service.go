@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+	"github.com/google/uuid"
+)
+
+const (
+	errMsgIllegalTransition    = "Cannot change flight status from %s to %s"
+	errMsgStatusChanged        = "Flight status changed concurrently, please retry"
+	errMsgStatusViaEndpoint    = "Flight status can only be changed through the status endpoints"
+	errMsgNewFlightStatus      = "New flights must be scheduled"
+	errMsgDelayNeedsEstimate   = "A delay needs an estimated departure time after the scheduled departure"
+	errMsgDiversionNeedsPort   = "A diversion needs the airport the flight diverted to"
+	errMsgFailedToUpdateStatus = "Failed to update flight status"
+	errMsgFailedToGetHistory   = "Failed to fetch flight status history"
+)
+
+type FlightStatusRepository interface {
+	Record(ctx context.Context, event *model.FlightStatusEvent) error
+	ListByFlight(ctx context.Context, flightID string) ([]*model.FlightStatusEvent, error)
+}
+
+// WithStatusHistory sets the repository that records flight status transitions
+func (s *FlightService) WithStatusHistory(repo FlightStatusRepository) *FlightService {
+	s.statusHistory = repo
+	return s
+}
+
+// TransitionStatus moves a flight to a new status if the lifecycle allows it,
+// recording estimated and actual times along with a history event.
+func (s *FlightService) TransitionStatus(ctx context.Context, id, status, actorID string, req *model.FlightStatusRequest) (*model.Flight, error) {
+	flight, err := s.repo.FindByID(ctx, id)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+	}
+
+	stored := flight.Status
+	from := stored
+	if from == "" {
+		from = model.FlightStatusScheduled
+	}
+	if !model.CanTransition(from, status) {
+		return nil, common.NewAppError(common.ErrInvalidTransition, fmt.Sprintf(errMsgIllegalTransition, from, status), http.StatusConflict)
+	}
+
+	now := time.Now()
+	actual := now
+	if req.ActualTime != nil {
+		actual = *req.ActualTime
+	}
+
+	switch status {
+	case model.FlightStatusDelayed:
+		if req.EstimatedDepartureTime == nil || req.EstimatedDepartureTime.Before(flight.DepartureTime) {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgDelayNeedsEstimate, http.StatusBadRequest)
+		}
+		flight.EstimatedDepartureTime = req.EstimatedDepartureTime
+		estimatedArrival := flight.ArrivalTime.Add(req.EstimatedDepartureTime.Sub(flight.DepartureTime))
+		if req.EstimatedArrivalTime != nil {
+			estimatedArrival = *req.EstimatedArrivalTime
+		}
+		flight.EstimatedArrivalTime = &estimatedArrival
+	case model.FlightStatusDeparted:
+		flight.ActualDepartureTime = &actual
+		if req.EstimatedArrivalTime != nil {
+			flight.EstimatedArrivalTime = req.EstimatedArrivalTime
+		}
+	case model.FlightStatusLanded:
+		flight.ActualArrivalTime = &actual
+	case model.FlightStatusDiverted:
+		if req.DivertedTo == "" {
+			return nil, common.NewAppError(common.ErrInvalidInput, errMsgDiversionNeedsPort, http.StatusBadRequest)
+		}
+		flight.DivertedTo = req.DivertedTo
+		flight.ActualArrivalTime = &actual
+	}
+
+	flight.Status = status
+	flight.UpdatedAt = now
+
+	ok, err := s.repo.UpdateStatus(ctx, flight, stored)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToUpdateStatus, http.StatusInternalServerError)
+	}
+	if !ok {
+		return nil, common.NewAppError(common.ErrInvalidTransition, errMsgStatusChanged, http.StatusConflict)
+	}
+
+	event := &model.FlightStatusEvent{
+		ID:                     uuid.NewString(),
+		FlightID:               flight.ID,
+		From:                   from,
+		To:                     status,
+		Reason:                 req.Reason,
+		EstimatedDepartureTime: flight.EstimatedDepartureTime,
+		EstimatedArrivalTime:   flight.EstimatedArrivalTime,
+		DivertedTo:             flight.DivertedTo,
+		RecordedBy:             actorID,
+		CreatedAt:              now,
+	}
+	if status == model.FlightStatusDeparted || status == model.FlightStatusLanded || status == model.FlightStatusDiverted {
+		event.ActualTime = &actual
+	}
+	if s.statusHistory != nil {
+		s.statusHistory.Record(ctx, event)
+	}
+
+	// Bookings on cancelled and delayed flights are looked after by the handler
+	if s.disruptions != nil && (status == model.FlightStatusCancelled || status == model.FlightStatusDelayed) {
+		s.disruptions.HandleDisruption(ctx, flight, event)
+	}
+
+	return flight, nil
+}
+
+// GetStatusHistory lists the status transitions of a flight, oldest first
+func (s *FlightService) GetStatusHistory(ctx context.Context, id string) ([]*model.FlightStatusEvent, error) {
+	flight, err := s.repo.FindByID(ctx, id)
+	if err != nil || flight == nil {
+		return nil, common.NewAppError(common.ErrNotFound, "Flight not found", http.StatusNotFound)
+	}
+	if s.statusHistory == nil {
+		return []*model.FlightStatusEvent{}, nil
+	}
+
+	events, err := s.statusHistory.ListByFlight(ctx, id)
+	if err != nil {
+		return nil, common.NewAppError(common.ErrInternalServer, errMsgFailedToGetHistory, http.StatusInternalServerError)
+	}
+	return events, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/Siya360/take-flight/server/pkg/common"
+	"github.com/Siya360/take-flight/server/pkg/flights/model"
+)
+
+const (
+	errMsgInvalidTripSlices = "Trip slices do not match the trip type"
+	errMsgTooManySlices     = "Too many trip slices"
+	errMsgSlicesOutOfOrder  = "Trip slices must be in chronological order"
+)
+
+// SearchTrips searches every slice of a one-way, round-trip or multi-city
+// request and combines the results into options priced as a whole. A slice
+// may only depart after the previous slice has arrived.
+func (s *FlightService) SearchTrips(ctx context.Context, req model.TripSearchRequest) ([]*model.TripOption, error) {
+	if err := validateTripSlices(req); err != nil {
+		return nil, err
+	}
+
+	candidates := make([][]*model.Itinerary, len(req.Slices))
+	for i, slice := range req.Slices {
+		itineraries, err := s.findItineraries(ctx, model.SearchFlightRequest{
+			DepartureCity: slice.DepartureCity,
+			ArrivalCity:   slice.ArrivalCity,
+			DepartureDate: slice.DepartureDate,
+			Passengers:    req.Passengers,
+		}, stopLimit(req.MaxStops))
+		if err != nil {
+			return nil, err
+		}
+		if len(itineraries) == 0 {
+			return []*model.TripOption{}, nil
+		}
+
+		sort.SliceStable(itineraries, func(a, b int) bool {
+			return itineraries[a].TotalPrice < itineraries[b].TotalPrice
+		})
+		candidates[i] = itineraries
+	}
+
+	// Extend the cheapest combinations one slice at a time. Each combination
+	// takes the MaxItinerariesPerSlice cheapest itineraries that depart after
+	// it arrives, so cheap itineraries that cannot follow it do not crowd out
+	// ones that can, and no more than MaxTripOptions combinations are kept
+	// after each slice so the work stays bounded.
+	combinations := [][]*model.Itinerary{nil}
+	for _, slice := range candidates {
+		var next [][]*model.Itinerary
+		for _, chosen := range combinations {
+			taken := 0
+			for _, itinerary := range slice {
+				if taken == model.MaxItinerariesPerSlice {
+					break
+				}
+				if len(chosen) > 0 {
+					previous := chosen[len(chosen)-1]
+					minConnection := s.connections.WindowFor(previous.ArrivalCity).Min
+					if itinerary.DepartureTime.Before(previous.ArrivalTime.Add(minConnection)) {
+						continue
+					}
+				}
+				taken++
+				combination := make([]*model.Itinerary, len(chosen), len(chosen)+1)
+				copy(combination, chosen)
+				next = append(next, append(combination, itinerary))
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool {
+			return combinedPrice(next[i]) < combinedPrice(next[j])
+		})
+		if len(next) > model.MaxTripOptions {
+			next = next[:model.MaxTripOptions]
+		}
+		combinations = next
+	}
+
+	options := make([]*model.TripOption, len(combinations))
+	for i, chosen := range combinations {
+		options[i] = model.NewTripOption(req.TripType, chosen)
+	}
+
+	return options, nil
+}
+
+// combinedPrice is the total price of a combination of itineraries
+func combinedPrice(itineraries []*model.Itinerary) float64 {
+	total := 0.0
+	for _, itinerary := range itineraries {
+		total += itinerary.TotalPrice
+	}
+	return total
+}
+
+// validateTripSlices checks that the slices fit the requested trip type
+func validateTripSlices(req model.TripSearchRequest) error {
+	if len(req.Slices) > model.MaxTripSlices {
+		return common.NewAppError(common.ErrInvalidInput, errMsgTooManySlices, http.StatusBadRequest)
+	}
+
+	switch req.TripType {
+	case model.TripTypeOneWay:
+		if len(req.Slices) != 1 {
+			return common.NewAppError(common.ErrInvalidInput, errMsgInvalidTripSlices, http.StatusBadRequest)
+		}
+	case model.TripTypeRoundTrip:
+		if len(req.Slices) != 2 ||
+			req.Slices[0].DepartureCity != req.Slices[1].ArrivalCity ||
+			req.Slices[0].ArrivalCity != req.Slices[1].DepartureCity {
+			return common.NewAppError(common.ErrInvalidInput, errMsgInvalidTripSlices, http.StatusBadRequest)
+		}
+	case model.TripTypeMultiCity:
+		if len(req.Slices) < 2 {
+			return common.NewAppError(common.ErrInvalidInput, errMsgInvalidTripSlices, http.StatusBadRequest)
+		}
+	default:
+		return common.NewAppError(common.ErrInvalidInput, errMsgInvalidTripSlices, http.StatusBadRequest)
+	}
+
+	for i := 1; i < len(req.Slices); i++ {
+		if req.Slices[i].DepartureDate.Before(req.Slices[i-1].DepartureDate) {
+			return common.NewAppError(common.ErrInvalidInput, errMsgSlicesOutOfOrder, http.StatusBadRequest)
+		}
+	}
+	return nil
+}
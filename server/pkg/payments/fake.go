@@ -0,0 +1,241 @@
+// pkg/payments/fake.go
+
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+)
+
+// GatewayFake names the in-process fake processor in configuration.
+const GatewayFake = "fake"
+
+// Outcomes of an authorization on the fake processor.
+const (
+	OutcomeApprove   = "approve"
+	OutcomeDecline   = "decline"
+	OutcomeChallenge = "three_d_secure"
+)
+
+var (
+	ErrUnknownPayment   = errors.New("unknown payment reference")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNotCaptured      = errors.New("payment has not been captured")
+	ErrRefundTooLarge   = errors.New("refund exceeds the captured amount")
+	ErrDuplicatePayment = errors.New("payment was already authorized")
+)
+
+// Scenario decides how the fake processor answers an authorization with a
+// payment method.
+type Scenario struct {
+	Outcome     string `yaml:"outcome"`
+	DeclineCode string `yaml:"declineCode"`
+}
+
+// DefaultScenarios are the payment methods the fake processor knows out of
+// the box. Payment methods without a scenario are approved.
+func DefaultScenarios() map[string]Scenario {
+	return map[string]Scenario{
+		"tok_approve":            {Outcome: OutcomeApprove},
+		"tok_decline":            {Outcome: OutcomeDecline, DeclineCode: "card_declined"},
+		"tok_insufficient_funds": {Outcome: OutcomeDecline, DeclineCode: "insufficient_funds"},
+		"tok_3ds":                {Outcome: OutcomeChallenge},
+	}
+}
+
+// FakeGateway is a deterministic payment processor kept in memory, for
+// taking payments offline. References are made from the payment's ID, so
+// they stay unique across restarts, scenarios are picked by payment method,
+// and 3-D Secure challenges are completed with CompleteChallenge, which
+// returns the webhook a real processor would send, signed with the webhook
+// secret.
+type FakeGateway struct {
+	secret    []byte
+	scenarios map[string]Scenario
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	amount   float64
+	status   model.PaymentState
+	captured float64
+	refunded float64
+}
+
+// NewFakeGateway creates a fake processor signing webhooks with secret.
+// Scenarios are added to, and override, the default scenarios.
+func NewFakeGateway(secret string, scenarios map[string]Scenario) *FakeGateway {
+	all := DefaultScenarios()
+	for method, scenario := range scenarios {
+		all[method] = scenario
+	}
+	return &FakeGateway{
+		secret:    []byte(secret),
+		scenarios: all,
+		payments:  make(map[string]*fakePayment),
+	}
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req *model.AuthorizationRequest) (*model.GatewayResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	reference := "fake_" + req.PaymentID
+	if req.PaymentID == "" {
+		random := make([]byte, 8)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		reference = "fake_" + hex.EncodeToString(random)
+	}
+	if _, ok := g.payments[reference]; ok {
+		return nil, ErrDuplicatePayment
+	}
+	payment := &fakePayment{amount: req.Amount}
+	g.payments[reference] = payment
+
+	result := &model.GatewayResult{Reference: reference}
+	scenario := g.scenarios[req.PaymentMethod]
+	switch scenario.Outcome {
+	case OutcomeDecline:
+		payment.status = model.PaymentDeclined
+		result.DeclineCode = scenario.DeclineCode
+	case OutcomeChallenge:
+		payment.status = model.PaymentRequiresAction
+		result.ActionURL = fmt.Sprintf("/api/payments/%s/challenge", reference)
+	default:
+		payment.status = model.PaymentAuthorized
+	}
+	result.Status = payment.status
+	return result, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status == model.PaymentAuthorized {
+		payment.status = model.PaymentCaptured
+		payment.captured = amount
+	}
+	return &model.GatewayResult{Reference: reference, Status: payment.status}, nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) (*model.GatewayResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status == model.PaymentAuthorized || payment.status == model.PaymentRequiresAction {
+		payment.status = model.PaymentVoided
+	}
+	return &model.GatewayResult{Reference: reference, Status: payment.status}, nil
+}
+
+// Refund gives back part or all of a captured payment. The payment is
+// reported refunded after every refund.
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount float64) (*model.GatewayResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, ok := g.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status != model.PaymentCaptured && payment.status != model.PaymentRefunded {
+		return nil, ErrNotCaptured
+	}
+	if payment.refunded+amount > payment.captured+0.005 {
+		return nil, ErrRefundTooLarge
+	}
+	payment.refunded += amount
+	payment.status = model.PaymentRefunded
+	return &model.GatewayResult{Reference: reference, Status: payment.status}, nil
+}
+
+// CompleteChallenge ends the 3-D Secure challenge of a payment, authorizing
+// it when approved and declining it otherwise, and returns the signed webhook
+// reporting the outcome.
+func (g *FakeGateway) CompleteChallenge(reference string, approve bool) ([]byte, string, error) {
+	g.mu.Lock()
+	payment, ok := g.payments[reference]
+	if !ok {
+		g.mu.Unlock()
+		return nil, "", ErrUnknownPayment
+	}
+	event := &model.PaymentEvent{
+		ID:        fmt.Sprintf("evt_%s_challenge", reference),
+		Reference: reference,
+		Amount:    payment.amount,
+	}
+	if payment.status == model.PaymentRequiresAction {
+		if approve {
+			payment.status = model.PaymentAuthorized
+		} else {
+			payment.status = model.PaymentDeclined
+		}
+	}
+	switch payment.status {
+	case model.PaymentAuthorized:
+		event.Type = model.PaymentEventAuthorized
+	case model.PaymentDeclined:
+		event.Type = model.PaymentEventDeclined
+		event.DeclineCode = "authentication_failed"
+	default:
+		event.Type = "payment." + string(payment.status)
+	}
+	g.mu.Unlock()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, g.Sign(payload), nil
+}
+
+// ParseWebhook checks a webhook was signed with the webhook secret and
+// returns its event. Without a secret no webhook is accepted.
+func (g *FakeGateway) ParseWebhook(payload []byte, signature string) (*model.PaymentEvent, error) {
+	if len(g.secret) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, g.mac(payload)) {
+		return nil, ErrInvalidSignature
+	}
+	var event model.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Sign returns the signature of a webhook payload: the hex HMAC-SHA256 of it
+// under the webhook secret
+func (g *FakeGateway) Sign(payload []byte) string {
+	return hex.EncodeToString(g.mac(payload))
+}
+
+func (g *FakeGateway) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,125 @@
+package payments_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Siya360/take-flight/server/pkg/bookings/model"
+	"github.com/Siya360/take-flight/server/pkg/payments"
+)
+
+func TestFakeGatewayScenarios(t *testing.T) {
+	gateway := payments.NewFakeGateway("secret", map[string]payments.Scenario{
+		"tok_expired_card": {Outcome: payments.OutcomeDecline, DeclineCode: "expired_card"},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		method      string
+		status      model.PaymentState
+		declineCode string
+	}{
+		{"tok_approve", model.PaymentAuthorized, ""},
+		{"tok_unknown", model.PaymentAuthorized, ""},
+		{"tok_decline", model.PaymentDeclined, "card_declined"},
+		{"tok_insufficient_funds", model.PaymentDeclined, "insufficient_funds"},
+		{"tok_expired_card", model.PaymentDeclined, "expired_card"},
+		{"tok_3ds", model.PaymentRequiresAction, ""},
+	}
+	for i, tt := range tests {
+		paymentID := fmt.Sprintf("pay%d", i)
+		result, err := gateway.Authorize(ctx, &model.AuthorizationRequest{PaymentID: paymentID, Amount: 100, PaymentMethod: tt.method})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.method, err)
+		}
+		if result.Status != tt.status || result.DeclineCode != tt.declineCode {
+			t.Fatalf("%s: got %s %q, want %s %q", tt.method, result.Status, result.DeclineCode, tt.status, tt.declineCode)
+		}
+		// References come from the payment ID so they never repeat after a restart
+		if want := "fake_" + paymentID; result.Reference != want {
+			t.Fatalf("%s: expected reference %s, got %s", tt.method, want, result.Reference)
+		}
+	}
+
+	if _, err := gateway.Authorize(ctx, &model.AuthorizationRequest{PaymentID: "pay0", Amount: 100}); err == nil {
+		t.Fatal("expected a payment not to be authorized twice")
+	}
+	first, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 100})
+	second, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 100})
+	if first.Reference == second.Reference {
+		t.Fatalf("expected payments without an ID to get distinct references, got %s twice", first.Reference)
+	}
+}
+
+func TestFakeGatewayCaptureVoidAndRefund(t *testing.T) {
+	gateway := payments.NewFakeGateway("secret", nil)
+	ctx := context.Background()
+
+	authorized, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 200, PaymentMethod: "tok_approve"})
+	if _, err := gateway.Refund(ctx, authorized.Reference, 50); err == nil {
+		t.Fatal("expected refunding an uncaptured payment to fail")
+	}
+	captured, err := gateway.Capture(ctx, authorized.Reference, 200)
+	if err != nil || captured.Status != model.PaymentCaptured {
+		t.Fatalf("expected capture, got %+v, %v", captured, err)
+	}
+	if voided, _ := gateway.Void(ctx, authorized.Reference); voided.Status != model.PaymentCaptured {
+		t.Fatalf("expected a captured payment not to be voided, got %s", voided.Status)
+	}
+	if refunded, err := gateway.Refund(ctx, authorized.Reference, 150); err != nil || refunded.Status != model.PaymentRefunded {
+		t.Fatalf("expected partial refund, got %+v, %v", refunded, err)
+	}
+	if _, err := gateway.Refund(ctx, authorized.Reference, 60); err != payments.ErrRefundTooLarge {
+		t.Fatalf("expected refunds beyond the captured amount to fail, got %v", err)
+	}
+
+	held, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 80, PaymentMethod: "tok_approve"})
+	if voided, _ := gateway.Void(ctx, held.Reference); voided.Status != model.PaymentVoided {
+		t.Fatalf("expected authorization to be voided, got %s", voided.Status)
+	}
+}
+
+func TestFakeGatewayChallengeWebhooks(t *testing.T) {
+	gateway := payments.NewFakeGateway("secret", nil)
+	ctx := context.Background()
+
+	challenged, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 120, PaymentMethod: "tok_3ds"})
+	if challenged.ActionURL == "" {
+		t.Fatal("expected a challenge URL")
+	}
+	payload, signature, err := gateway.CompleteChallenge(challenged.Reference, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	event, err := gateway.ParseWebhook(payload, signature)
+	if err != nil {
+		t.Fatalf("expected signature to verify, got %v", err)
+	}
+	if event.Type != model.PaymentEventAuthorized || event.Reference != challenged.Reference || event.Amount != 120 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if captured, _ := gateway.Capture(ctx, challenged.Reference, 120); captured.Status != model.PaymentCaptured {
+		t.Fatalf("expected an approved challenge to be capturable, got %s", captured.Status)
+	}
+
+	// Tampered payloads and other secrets are rejected
+	if _, err := gateway.ParseWebhook(append(payload, ' '), signature); err != payments.ErrInvalidSignature {
+		t.Fatalf("expected tampered payload to be rejected, got %v", err)
+	}
+	other := payments.NewFakeGateway("other", nil)
+	if _, err := other.ParseWebhook(payload, signature); err != payments.ErrInvalidSignature {
+		t.Fatalf("expected signature under another secret to be rejected, got %v", err)
+	}
+	unsigned := payments.NewFakeGateway("", nil)
+	if _, err := unsigned.ParseWebhook(payload, unsigned.Sign(payload)); err != payments.ErrInvalidSignature {
+		t.Fatalf("expected webhooks to be rejected without a secret, got %v", err)
+	}
+
+	failed, _ := gateway.Authorize(ctx, &model.AuthorizationRequest{Amount: 90, PaymentMethod: "tok_3ds"})
+	payload, signature, _ = gateway.CompleteChallenge(failed.Reference, false)
+	event, err = gateway.ParseWebhook(payload, signature)
+	if err != nil || event.Type != model.PaymentEventDeclined || event.DeclineCode == "" {
+		t.Fatalf("expected a declined event, got %+v, %v", event, err)
+	}
+}
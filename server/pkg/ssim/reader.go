@@ -0,0 +1,146 @@
+// pkg/ssim/reader.go
+
+package ssim
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Read parses the flight leg records of an SSIM Chapter 7 file. Records that
+// cannot be used are reported as line errors and skipped; the returned error
+// is only set when the input itself cannot be read. Segment data records are
+// ignored.
+func Read(r io.Reader) ([]Leg, []*LineError, error) {
+	var (
+		legs    []Leg
+		errs    []*LineError
+		carrier record
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, RecordLength*2), RecordLength*4)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(text) > RecordLength {
+			errs = append(errs, &LineError{Line: line, Message: fmt.Sprintf("record is longer than %d characters", RecordLength)})
+			continue
+		}
+		// Trailing spaces are often trimmed by editors
+		rec := newRecord(text[0])
+		copy(rec, text)
+
+		switch rec[0] {
+		case RecordHeader, RecordSegment, RecordTrailer, RecordFiller:
+		case RecordCarrier:
+			if mode := rec[1]; mode != TimeModeUTC && mode != TimeModeLocal {
+				errs = append(errs, &LineError{Line: line, Message: "time mode must be U or L"})
+				carrier = nil
+				continue
+			}
+			carrier = rec
+		case RecordLeg:
+			if carrier == nil {
+				errs = append(errs, &LineError{Line: line, Message: "flight leg record without a valid carrier record"})
+				continue
+			}
+			leg, err := parseLeg(rec, carrier[1])
+			if err != nil {
+				errs = append(errs, &LineError{Line: line, Message: err.Error()})
+				continue
+			}
+			leg.Line = line
+			legs = append(legs, *leg)
+		default:
+			errs = append(errs, &LineError{Line: line, Message: fmt.Sprintf("unknown record type %q", rec[0])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return legs, errs, nil
+}
+
+func parseLeg(rec record, timeMode byte) (*Leg, error) {
+	leg := &Leg{
+		TimeMode:         timeMode,
+		Suffix:           rec.field(2, 2),
+		Airline:          rec.field(3, 5),
+		ServiceType:      rec.field(14, 14),
+		Days:             string(rec[28:35]),
+		DepartureStation: rec.field(37, 39),
+		DepartureTime:    rec.field(40, 43),
+		ArrivalStation:   rec.field(55, 57),
+		ArrivalTime:      rec.field(62, 65),
+		AircraftType:     rec.field(73, 75),
+		Configuration:    rec.field(173, 192),
+	}
+
+	var err error
+	if leg.Airline == "" {
+		return nil, fmt.Errorf("airline designator is missing")
+	}
+	if leg.FlightNumber, err = strconv.Atoi(rec.field(6, 9)); err != nil || leg.FlightNumber <= 0 {
+		return nil, fmt.Errorf("invalid flight number %q", rec.field(6, 9))
+	}
+	if leg.ItineraryVariation, err = strconv.Atoi(rec.field(10, 11)); err != nil {
+		return nil, fmt.Errorf("invalid itinerary variation identifier %q", rec.field(10, 11))
+	}
+	if leg.LegSequence, err = strconv.Atoi(rec.field(12, 13)); err != nil {
+		return nil, fmt.Errorf("invalid leg sequence number %q", rec.field(12, 13))
+	}
+
+	if leg.PeriodFrom, err = parseDate(rec.field(15, 21)); err != nil {
+		return nil, fmt.Errorf("invalid period of operation start %q", rec.field(15, 21))
+	}
+	if to := rec.field(22, 28); to != openDate {
+		if leg.PeriodTo, err = parseDate(to); err != nil {
+			return nil, fmt.Errorf("invalid period of operation end %q", to)
+		}
+		if leg.PeriodTo.Before(leg.PeriodFrom) {
+			return nil, fmt.Errorf("period of operation ends before it starts")
+		}
+	}
+	for i, c := range leg.Days {
+		if c != ' ' && c != rune('1'+i) {
+			return nil, fmt.Errorf("invalid days of operation %q", leg.Days)
+		}
+	}
+	if strings.TrimSpace(leg.Days) == "" {
+		return nil, fmt.Errorf("days of operation are missing")
+	}
+
+	if leg.DepartureStation == "" || leg.ArrivalStation == "" {
+		return nil, fmt.Errorf("departure and arrival stations are required")
+	}
+	if _, _, err := parseClock(leg.DepartureTime); err != nil {
+		return nil, fmt.Errorf("invalid departure time %q", leg.DepartureTime)
+	}
+	if _, _, err := parseClock(leg.ArrivalTime); err != nil {
+		return nil, fmt.Errorf("invalid arrival time %q", leg.ArrivalTime)
+	}
+	if leg.DepartureOffset, err = parseOffset(rec.field(48, 52)); err != nil {
+		return nil, err
+	}
+	if leg.ArrivalOffset, err = parseOffset(rec.field(66, 70)); err != nil {
+		return nil, err
+	}
+	if leg.DepartureDayVariation, err = parseDayVariation(rec[192]); err != nil {
+		return nil, err
+	}
+	if leg.ArrivalDayVariation, err = parseDayVariation(rec[193]); err != nil {
+		return nil, err
+	}
+	if leg.Seats() <= 0 {
+		return nil, fmt.Errorf("aircraft configuration %q has no seats", leg.Configuration)
+	}
+	return leg, nil
+}
@@ -0,0 +1,251 @@
+// pkg/ssim/ssim.go
+
+package ssim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordLength is the fixed width of every SSIM record.
+const RecordLength = 200
+
+// Record types of a Chapter 7 schedule file.
+const (
+	RecordHeader  = '1'
+	RecordCarrier = '2'
+	RecordLeg     = '3'
+	RecordSegment = '4'
+	RecordTrailer = '5'
+	RecordFiller  = '0'
+)
+
+// Time modes of a carrier section: times are either UTC or local to each station.
+const (
+	TimeModeUTC   = 'U'
+	TimeModeLocal = 'L'
+)
+
+// DateLayout is the layout of SSIM dates, e.g. 01JUN26.
+const DateLayout = "02Jan06"
+
+// openDate marks a period of operation without an end date.
+const openDate = "00XXX00"
+
+// Leg is one flight leg record (type 3): a leg operated on the given days of
+// the week over a period. Times are "HHMM" in the leg's time mode, and the
+// period holds the dates of the departure from the first station of the flight.
+type Leg struct {
+	Line               int
+	TimeMode           byte
+	Airline            string
+	FlightNumber       int
+	Suffix             string
+	ItineraryVariation int
+	LegSequence        int
+	ServiceType        string
+	PeriodFrom         time.Time
+	// PeriodTo is zero when the period is open-ended
+	PeriodTo time.Time
+	// Days holds the ISO weekday digits operated, Monday being 1, with spaces
+	// for the days not operated: "1 3 5  "
+	Days             string
+	DepartureStation string
+	DepartureTime    string
+	// Offsets are the stations' variations from UTC in minutes
+	DepartureOffset       int
+	ArrivalStation        string
+	ArrivalTime           string
+	ArrivalOffset         int
+	AircraftType          string
+	Configuration         string
+	DepartureDayVariation int
+	ArrivalDayVariation   int
+}
+
+// Designator returns the flight designator, e.g. BA178.
+func (l *Leg) Designator() string {
+	return l.Airline + strconv.Itoa(l.FlightNumber) + l.Suffix
+}
+
+// Seats returns the total of the seat counts in the aircraft configuration,
+// e.g. 162 for J12Y150.
+func (l *Leg) Seats() int {
+	seats, digits := 0, ""
+	for _, c := range l.Configuration + " " {
+		if c >= '0' && c <= '9' {
+			digits += string(c)
+			continue
+		}
+		if n, err := strconv.Atoi(digits); err == nil {
+			seats += n
+		}
+		digits = ""
+	}
+	return seats
+}
+
+// OperatesOn reports whether the leg operates on the given weekday.
+func (l *Leg) OperatesOn(weekday time.Weekday) bool {
+	iso := int(weekday)
+	if iso == 0 {
+		iso = 7
+	}
+	return strings.ContainsRune(l.Days, rune('0'+iso))
+}
+
+// Operation is one dated instance of a leg.
+type Operation struct {
+	Departure time.Time
+	Arrival   time.Time
+}
+
+// Operations expands the leg into its dated instances up to the end of its
+// period, or up to until when that comes first or the period is open.
+func (l *Leg) Operations(until time.Time) ([]Operation, error) {
+	depHour, depMinute, err := parseClock(l.DepartureTime)
+	if err != nil {
+		return nil, err
+	}
+	arrHour, arrMinute, err := parseClock(l.ArrivalTime)
+	if err != nil {
+		return nil, err
+	}
+
+	depZone, arrZone := time.UTC, time.UTC
+	if l.TimeMode == TimeModeLocal {
+		depZone = time.FixedZone("", l.DepartureOffset*60)
+		arrZone = time.FixedZone("", l.ArrivalOffset*60)
+	}
+
+	last := l.PeriodTo
+	if last.IsZero() || last.After(until) {
+		last = until
+	}
+
+	var operations []Operation
+	for day := l.PeriodFrom; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !l.OperatesOn(day.Weekday()) {
+			continue
+		}
+		dep := day.AddDate(0, 0, l.DepartureDayVariation)
+		arr := day.AddDate(0, 0, l.ArrivalDayVariation)
+		operations = append(operations, Operation{
+			Departure: time.Date(dep.Year(), dep.Month(), dep.Day(), depHour, depMinute, 0, 0, depZone).UTC(),
+			Arrival:   time.Date(arr.Year(), arr.Month(), arr.Day(), arrHour, arrMinute, 0, 0, arrZone).UTC(),
+		})
+	}
+	return operations, nil
+}
+
+// LineError reports a record that could not be used.
+type LineError struct {
+	Line    int
+	Message string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// record is a fixed-width SSIM record. Field positions are 1-based and
+// inclusive, as in the SSIM manual.
+type record []byte
+
+func newRecord(kind byte) record {
+	r := make(record, RecordLength)
+	for i := range r {
+		r[i] = ' '
+	}
+	r[0] = kind
+	return r
+}
+
+func fillerRecord() record {
+	r := make(record, RecordLength)
+	for i := range r {
+		r[i] = RecordFiller
+	}
+	return r
+}
+
+func (r record) field(start, end int) string {
+	return strings.TrimSpace(string(r[start-1 : end]))
+}
+
+func (r record) set(start, end int, value string) {
+	copy(r[start-1:end], value)
+}
+
+func (r record) setSerial(serial int) {
+	r.set(195, 200, fmt.Sprintf("%06d", serial%1000000))
+}
+
+func parseClock(value string) (int, int, error) {
+	if len(value) != 4 {
+		return 0, 0, fmt.Errorf("invalid time %q", value)
+	}
+	hours, err1 := strconv.Atoi(value[:2])
+	minutes, err2 := strconv.Atoi(value[2:])
+	if err1 != nil || err2 != nil || hours > 24 || minutes > 59 {
+		return 0, 0, fmt.Errorf("invalid time %q", value)
+	}
+	return hours, minutes, nil
+}
+
+// parseOffset reads a UTC variation such as +0100 or -0430 as minutes
+func parseOffset(value string) (int, error) {
+	if len(value) != 5 || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC variation %q", value)
+	}
+	hours, minutes, err := parseClock(value[1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid UTC variation %q", value)
+	}
+	offset := hours*60 + minutes
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+func formatOffset(minutes int) string {
+	sign := '+'
+	if minutes < 0 {
+		sign, minutes = '-', -minutes
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, minutes/60, minutes%60)
+}
+
+// parseDayVariation reads a date variation: a digit, or A for the day before
+func parseDayVariation(value byte) (int, error) {
+	switch {
+	case value == ' ':
+		return 0, nil
+	case value == 'A':
+		return -1, nil
+	case value >= '0' && value <= '9':
+		return int(value - '0'), nil
+	}
+	return 0, fmt.Errorf("invalid date variation %q", value)
+}
+
+func formatDayVariation(days int) byte {
+	if days < 0 {
+		return 'A'
+	}
+	return byte('0' + days)
+}
+
+func parseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return openDate
+	}
+	return strings.ToUpper(t.Format(DateLayout))
+}
@@ -0,0 +1,103 @@
+package ssim
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteThenReadRoundTrips(t *testing.T) {
+	from := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	leg := Leg{
+		TimeMode:            TimeModeLocal,
+		Airline:             "BA",
+		FlightNumber:        178,
+		ItineraryVariation:  1,
+		LegSequence:         1,
+		ServiceType:         "J",
+		PeriodFrom:          from,
+		PeriodTo:            from.AddDate(0, 0, 13),
+		Days:                "1 3 5  ",
+		DepartureStation:    "JFK",
+		DepartureTime:       "1800",
+		DepartureOffset:     -240,
+		ArrivalStation:      "LHR",
+		ArrivalTime:         "0600",
+		ArrivalOffset:       60,
+		Configuration:       "J20Y180",
+		ArrivalDayVariation: 1,
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []Leg{leg}, from); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(records)%recordsPerBlock != 0 {
+		t.Fatalf("expected whole blocks of records, got %d", len(records))
+	}
+	for _, rec := range records {
+		if len(rec) != RecordLength {
+			t.Fatalf("expected %d character records, got %d", RecordLength, len(rec))
+		}
+	}
+
+	legs, errs, err := Read(&buf)
+	if err != nil || len(errs) != 0 || len(legs) != 1 {
+		t.Fatalf("unexpected read result %+v %v %v", legs, errs, err)
+	}
+	read := legs[0]
+	read.Line = 0
+	if read != leg {
+		t.Fatalf("expected %+v, got %+v", leg, read)
+	}
+	if read.Designator() != "BA178" || read.Seats() != 200 {
+		t.Fatalf("unexpected designator %s or seats %d", read.Designator(), read.Seats())
+	}
+
+	// Wednesday 1 July to Tuesday 14 July: Wed, Fri, Mon, Wed, Fri, Mon
+	operations, err := read.Operations(from.AddDate(1, 0, 0))
+	if err != nil || len(operations) != 6 {
+		t.Fatalf("expected 6 operations, got %d (%v)", len(operations), err)
+	}
+	first := operations[0]
+	if !first.Departure.Equal(time.Date(2026, 7, 1, 22, 0, 0, 0, time.UTC)) || !first.Arrival.Equal(time.Date(2026, 7, 2, 5, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected first operation %+v", first)
+	}
+}
+
+func TestReadReportsBadRecordsByLine(t *testing.T) {
+	good := newRecord(RecordLeg)
+	good.set(3, 5, "AA")
+	good.set(6, 9, "0100")
+	good.set(10, 13, "0101")
+	good.set(15, 28, "01JUL2600XXX00")
+	good.set(29, 35, "1234567")
+	good.set(37, 52, "JFK08000800-0400")
+	good.set(55, 70, "LAX15001500-0700")
+	good.set(173, 176, "Y150")
+
+	badTime := append(record{}, good...)
+	badTime.set(40, 43, "8:00")
+
+	carrier := newRecord(RecordCarrier)
+	carrier[1] = TimeModeUTC
+
+	input := strings.Join([]string{string(good), string(carrier), string(good), string(badTime), "9 unknown"}, "\n")
+	legs, errs, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(legs) != 1 || legs[0].Line != 3 || !legs[0].PeriodTo.IsZero() {
+		t.Fatalf("expected the open-ended leg on line 3, got %+v", legs)
+	}
+	if len(errs) != 3 || errs[0].Line != 1 || errs[1].Line != 4 || errs[2].Line != 5 {
+		t.Fatalf("unexpected line errors %+v", errs)
+	}
+
+	operations, err := legs[0].Operations(time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(operations) != 3 {
+		t.Fatalf("expected an open period to stop at the limit, got %d (%v)", len(operations), err)
+	}
+}
@@ -0,0 +1,134 @@
+// pkg/ssim/writer.go
+
+package ssim
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// recordsPerBlock is the block size SSIM sections are padded to with filler records.
+const recordsPerBlock = 5
+
+// Write writes legs as an SSIM Chapter 7 file in local time mode, with one
+// carrier section per airline. The time mode of the legs is ignored: their
+// times must already be local to each station.
+func Write(w io.Writer, legs []Leg, created time.Time) error {
+	out := &writer{w: bufio.NewWriter(w)}
+
+	header := newRecord(RecordHeader)
+	header.set(2, 35, "AIRLINE STANDARD SCHEDULE DATA SET")
+	header.set(192, 194, "001")
+	out.write(header)
+	out.pad()
+
+	byAirline := make(map[string][]Leg)
+	var airlines []string
+	for _, leg := range legs {
+		if _, ok := byAirline[leg.Airline]; !ok {
+			airlines = append(airlines, leg.Airline)
+		}
+		byAirline[leg.Airline] = append(byAirline[leg.Airline], leg)
+	}
+	sort.Strings(airlines)
+
+	for _, airline := range airlines {
+		section := byAirline[airline]
+		from, to := section[0].PeriodFrom, section[0].PeriodTo
+		for _, leg := range section {
+			if leg.PeriodFrom.Before(from) {
+				from = leg.PeriodFrom
+			}
+			if leg.PeriodTo.After(to) {
+				to = leg.PeriodTo
+			}
+		}
+
+		carrier := newRecord(RecordCarrier)
+		carrier[1] = TimeModeLocal
+		carrier.set(3, 5, airline)
+		carrier.set(15, 21, formatDate(from))
+		carrier.set(22, 28, formatDate(to))
+		carrier.set(29, 35, formatDate(created))
+		carrier.set(72, 72, "P")
+		out.write(carrier)
+		out.pad()
+
+		for _, leg := range section {
+			out.write(legRecord(leg))
+		}
+		last := out.serial
+		out.pad()
+
+		trailer := newRecord(RecordTrailer)
+		trailer.set(3, 5, airline)
+		trailer.set(6, 12, formatDate(created))
+		trailer.set(188, 193, fmt.Sprintf("%06d", last%1000000))
+		trailer.set(194, 194, "E")
+		out.write(trailer)
+		out.pad()
+	}
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+func legRecord(leg Leg) record {
+	rec := newRecord(RecordLeg)
+	rec.set(2, 2, leg.Suffix)
+	rec.set(3, 5, leg.Airline)
+	rec.set(6, 9, fmt.Sprintf("%04d", leg.FlightNumber%10000))
+	rec.set(10, 11, fmt.Sprintf("%02d", leg.ItineraryVariation%100))
+	rec.set(12, 13, fmt.Sprintf("%02d", leg.LegSequence%100))
+	rec.set(14, 14, leg.ServiceType)
+	rec.set(15, 21, formatDate(leg.PeriodFrom))
+	rec.set(22, 28, formatDate(leg.PeriodTo))
+	rec.set(29, 35, leg.Days)
+	rec.set(37, 39, leg.DepartureStation)
+	rec.set(40, 43, leg.DepartureTime)
+	rec.set(44, 47, leg.DepartureTime)
+	rec.set(48, 52, formatOffset(leg.DepartureOffset))
+	rec.set(55, 57, leg.ArrivalStation)
+	rec.set(58, 61, leg.ArrivalTime)
+	rec.set(62, 65, leg.ArrivalTime)
+	rec.set(66, 70, formatOffset(leg.ArrivalOffset))
+	rec.set(73, 75, leg.AircraftType)
+	rec.set(173, 192, leg.Configuration)
+	rec[192] = formatDayVariation(leg.DepartureDayVariation)
+	rec[193] = formatDayVariation(leg.ArrivalDayVariation)
+	return rec
+}
+
+// writer numbers records as it writes them and keeps the first error
+type writer struct {
+	w      *bufio.Writer
+	serial int
+	err    error
+}
+
+func (w *writer) write(rec record) {
+	if w.err != nil {
+		return
+	}
+	w.serial++
+	if rec[0] != RecordFiller {
+		rec.setSerial(w.serial)
+	}
+	if _, err := w.w.Write(rec); err != nil {
+		w.err = err
+		return
+	}
+	w.err = w.w.WriteByte('\n')
+}
+
+// pad fills the current block with filler records
+func (w *writer) pad() {
+	for w.serial%recordsPerBlock != 0 {
+		w.write(fillerRecord())
+	}
+}